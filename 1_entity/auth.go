@@ -0,0 +1,49 @@
+package entity
+
+import "time"
+
+// Session is one login; its refresh tokens are rotated and only the latest, Nonce, is accepted.
+type Session struct {
+	ID        string
+	UserID    int
+	Nonce     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	RevokedAt time.Time
+}
+
+const (
+	TokenVerifyEmail   = "verify_email"
+	TokenResetPassword = "reset_password"
+)
+
+// AccountToken is a single-use secret mailed to a user, such as an email verification
+// link. Only its SHA-256 Hash is stored; Email is the address it was sent to.
+type AccountToken struct {
+	Hash      string
+	UserID    int
+	Purpose   string
+	Email     string
+	CreatedAt time.Time
+	ExpiresAt time.Time
+	UsedAt    time.Time
+}
+
+type Credentials struct {
+	Email    string `json:"email"`
+	Password string `json:"password"`
+}
+
+// PasswordReset sets a new Password with the Token from a password reset mail.
+type PasswordReset struct {
+	Token    string `json:"token"`
+	Password string `json:"password"`
+}
+
+// TokenPair follows the OAuth 2.0 token response (RFC 6749 section 5.1).
+type TokenPair struct {
+	AccessToken  string `json:"access_token"`
+	RefreshToken string `json:"refresh_token"`
+	TokenType    string `json:"token_type"`
+	ExpiresIn    int    `json:"expires_in"`
+}
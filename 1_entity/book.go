@@ -2,12 +2,40 @@ package entity
 
 import "time"
 
+const (
+	FormatHardcover = "hardcover"
+	FormatPaperback = "paperback"
+	FormatEbook     = "ebook"
+	FormatAudiobook = "audiobook"
+)
+
 type Book struct {
-	ID        int       `json:"ID"`
-	Tittle    string    `json:"Tittle"`
-	Author    string    `json:"Author"`
-	Pages     int       `json:"Pages"`
-	Quantity  int       `json:"Quantity"`
-	CreatedAt time.Time `json:"CreatedAt"`
-	UpdatedAt time.Time `json:"UpdatedAt"`
+	ID              int    `json:"ID"`
+	ISBN            string `json:"ISBN"`
+	Tittle          string `json:"Tittle"`
+	Author          string `json:"Author"`
+	Pages           int    `json:"Pages"`
+	Quantity        int    `json:"Quantity"`
+	Available       int    `json:"Available"`
+	Publisher       string `json:"Publisher"`
+	PublicationYear int    `json:"PublicationYear"`
+	Edition         string `json:"Edition"`
+	Language        string `json:"Language"`
+	Description     string `json:"Description"`
+	Format          string `json:"Format"`
+	SeriesID        int    `json:"SeriesID"`
+	Volume          int    `json:"Volume"`
+	// Branch is where the copies are shelved and Barcode the library label on them;
+	// a stocktake of a branch counts its books by scanning the labels.
+	Branch  string `json:"Branch"`
+	Barcode string `json:"Barcode"`
+	// Rating is the average of the published reviews, 0 when there are none; both are read-only.
+	Rating      float64   `json:"Rating"`
+	RatingCount int       `json:"RatingCount"`
+	Version     int       `json:"Version"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+	UpdatedAt   time.Time `json:"UpdatedAt"`
+	// Movement, when set, says why Quantity is being changed and by whom; the
+	// repository fills in the deltas when it writes the ledger entry.
+	Movement *Movement `json:"-"`
 }
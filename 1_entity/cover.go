@@ -0,0 +1,17 @@
+package entity
+
+import "time"
+
+const (
+	CoverSizeOriginal = "original"
+	CoverSizeMedium   = "medium"
+	CoverSizeSmall    = "small"
+)
+
+type Cover struct {
+	BookID      int
+	Size        string
+	ContentType string
+	Data        []byte
+	UpdatedAt   time.Time
+}
@@ -0,0 +1,8 @@
+package entity
+
+// How a delete treats outstanding loans: refuse, return the copies to stock, or write them off as lost.
+const (
+	DeleteRestrict    = ""
+	DeleteForceReturn = "return"
+	DeleteWriteOff    = "writeoff"
+)
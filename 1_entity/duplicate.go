@@ -0,0 +1,21 @@
+package entity
+
+const (
+	DuplicateISBN        = "isbn"
+	DuplicateTitleAuthor = "title_author"
+)
+
+// DuplicateCandidate is a pair of books that look like the same title entered twice.
+type DuplicateCandidate struct {
+	Book   *Book   `json:"Book"`
+	Other  *Book   `json:"Other"`
+	Score  float64 `json:"Score"`
+	Reason string  `json:"Reason"`
+}
+
+// BookMerge folds the duplicates into the survivor: their copies, loans and
+// ledger continue on the survivor and the duplicates are deleted.
+type BookMerge struct {
+	SurvivorID   int   `json:"SurvivorID"`
+	DuplicateIDs []int `json:"DuplicateIDs"`
+}
@@ -1,7 +1,88 @@
 package entity
 
-import "errors"
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+)
 
 var ErrNotFound = errors.New("not found")
 var ErrInvalidEntity = errors.New("invalid entity")
 var ErrConflict = errors.New("item already exists")
+var ErrNoCopiesAvailable = errors.New("no copies available")
+var ErrBelowOnLoan = errors.New("quantity is lower than the copies on loan")
+var ErrOutstandingLoans = errors.New("there are outstanding loans")
+var ErrVersionConflict = errors.New("item was changed by someone else")
+var ErrWeakPassword = errors.New("password must be at least 8 characters, at most 72 bytes, and mix letters with digits or symbols")
+var ErrUnauthorized = errors.New("invalid credentials or token")
+var ErrForbidden = errors.New("not allowed for this user")
+var ErrClientID = errors.New("id is assigned by the server")
+var ErrEmailTaken = errors.New("email is already in use")
+var ErrNotVerified = errors.New("email address is not verified")
+var ErrInvalidToken = errors.New("token is invalid or was already used")
+var ErrTokenExpired = errors.New("token has expired")
+var ErrNotInSeries = errors.New("book is not part of a series")
+var ErrStocktakeClosed = errors.New("stocktake is closed")
+var ErrNotBorrowed = errors.New("book was never borrowed by this user")
+var ErrUnsupportedImage = errors.New("image must be JPEG or PNG")
+var ErrImageTooLarge = errors.New("image is too large")
+
+// OutstandingLoansError lists what still blocks a delete: the borrowers of a book or the books of a user.
+type OutstandingLoansError struct {
+	UserIDs []int
+	BookIDs []int
+}
+
+func (e *OutstandingLoansError) Error() string {
+	if len(e.UserIDs) > 0 {
+		return fmt.Sprintf("%s: borrowed by users %v", ErrOutstandingLoans, e.UserIDs)
+	}
+	return fmt.Sprintf("%s: books %v are not returned", ErrOutstandingLoans, e.BookIDs)
+}
+
+func (e *OutstandingLoansError) Is(target error) bool {
+	return target == ErrOutstandingLoans
+}
+
+// ValidationError says what is wrong with each field of a rejected entity, keyed by
+// the field's JSON name. It is an ErrInvalidEntity.
+type ValidationError struct {
+	Fields map[string]string
+}
+
+// Add records a problem with field, keeping the first one reported.
+func (e *ValidationError) Add(field, problem string) {
+	if e.Fields == nil {
+		e.Fields = make(map[string]string)
+	}
+	if _, ok := e.Fields[field]; !ok {
+		e.Fields[field] = problem
+	}
+}
+
+// OrNil is e as an error when it holds any problem, so that callers can return it as is.
+func (e *ValidationError) OrNil() error {
+	if len(e.Fields) == 0 {
+		return nil
+	}
+	return e
+}
+
+func (e *ValidationError) Error() string {
+	fields := make([]string, 0, len(e.Fields))
+	for f := range e.Fields {
+		fields = append(fields, f)
+	}
+	sort.Strings(fields)
+
+	problems := make([]string, 0, len(fields))
+	for _, f := range fields {
+		problems = append(problems, f+" "+e.Fields[f])
+	}
+	return fmt.Sprintf("%s: %s", ErrInvalidEntity, strings.Join(problems, "; "))
+}
+
+func (e *ValidationError) Is(target error) bool {
+	return target == ErrInvalidEntity
+}
@@ -0,0 +1,23 @@
+package entity
+
+const (
+	ImportCreated  = "created"
+	ImportUpdated  = "updated"
+	ImportRejected = "rejected"
+)
+
+type ImportRow struct {
+	Row    int    `json:"Row"`
+	ID     int    `json:"ID"`
+	ISBN   string `json:"ISBN"`
+	Status string `json:"Status"`
+	Error  string `json:"Error,omitempty"`
+}
+
+type ImportReport struct {
+	DryRun   bool         `json:"DryRun"`
+	Created  int          `json:"Created"`
+	Updated  int          `json:"Updated"`
+	Rejected int          `json:"Rejected"`
+	Rows     []*ImportRow `json:"Rows"`
+}
@@ -0,0 +1,34 @@
+package entity
+
+import "time"
+
+const (
+	MovementPurchase   = "purchase"
+	MovementLoan       = "loan"
+	MovementReturn     = "return"
+	MovementLoss       = "loss"
+	MovementCorrection = "correction"
+)
+
+// Movement is one entry of a book's inventory ledger. Delta is the change to the
+// total number of copies and AvailableDelta the change to the copies on the shelf,
+// so summing a book's movements gives its Quantity and Available.
+type Movement struct {
+	ID             int       `json:"ID"`
+	BookID         int       `json:"BookID"`
+	Delta          int       `json:"Delta"`
+	AvailableDelta int       `json:"AvailableDelta"`
+	Reason         string    `json:"Reason"`
+	Actor          string    `json:"Actor"`
+	Reference      string    `json:"Reference"`
+	CreatedAt      time.Time `json:"CreatedAt"`
+}
+
+// StockMismatch is a book whose stock columns disagree with its ledger.
+type StockMismatch struct {
+	BookID          int `json:"BookID"`
+	Quantity        int `json:"Quantity"`
+	Available       int `json:"Available"`
+	LedgerQuantity  int `json:"LedgerQuantity"`
+	LedgerAvailable int `json:"LedgerAvailable"`
+}
@@ -0,0 +1,20 @@
+package entity
+
+import "time"
+
+const (
+	ReviewPublished = "published"
+	ReviewHidden    = "hidden"
+)
+
+type Review struct {
+	ID          int       `json:"ID"`
+	BookID      int       `json:"BookID"`
+	UserID      int       `json:"UserID"`
+	Rating      int       `json:"Rating"`
+	Text        string    `json:"Text"`
+	Status      string    `json:"Status"`
+	ModeratedBy string    `json:"ModeratedBy"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+	UpdatedAt   time.Time `json:"UpdatedAt"`
+}
@@ -0,0 +1,10 @@
+package entity
+
+import "time"
+
+type Series struct {
+	ID          int       `json:"ID"`
+	Name        string    `json:"Name"`
+	Description string    `json:"Description"`
+	CreatedAt   time.Time `json:"CreatedAt"`
+}
@@ -0,0 +1,64 @@
+package entity
+
+import "time"
+
+const (
+	StocktakeOpen   = "open"
+	StocktakeClosed = "closed"
+)
+
+const (
+	DiscrepancyMissing    = "missing"
+	DiscrepancyUnexpected = "unexpected"
+	DiscrepancyMiscounted = "miscounted"
+)
+
+// Stocktake is one shelf count session of a branch; it expects the books shelved
+// there and nothing else.
+type Stocktake struct {
+	ID       int        `json:"ID"`
+	Branch   string     `json:"Branch"`
+	Status   string     `json:"Status"`
+	OpenedAt time.Time  `json:"OpenedAt"`
+	ClosedAt *time.Time `json:"ClosedAt"`
+}
+
+// StocktakeCount is a number of copies found on the shelf, either for a book or for
+// a barcode that matched no book.
+type StocktakeCount struct {
+	BookID  int    `json:"BookID"`
+	Barcode string `json:"Barcode"`
+	Counted int    `json:"Counted"`
+}
+
+// StocktakeSubmission is one batch from a scanner: each barcode is the library label,
+// or failing that the ISBN, scanned off one copy; each count is a number of copies
+// counted by hand.
+type StocktakeSubmission struct {
+	Barcodes []string          `json:"Barcodes"`
+	Counts   []*StocktakeCount `json:"Counts"`
+}
+
+// Discrepancy compares the copies expected on the shelf (the available ones) with
+// the copies counted. Corrected tells that the count was written to the inventory ledger.
+type Discrepancy struct {
+	BookID    int    `json:"BookID"`
+	Barcode   string `json:"Barcode"`
+	Kind      string `json:"Kind"`
+	Expected  int    `json:"Expected"`
+	Counted   int    `json:"Counted"`
+	Corrected bool   `json:"Corrected"`
+}
+
+// StockCorrection is a ledger entry a stocktake writes for a book of its branch.
+// It was worked out from the book at Version and only applies while the book
+// is still at that version.
+type StockCorrection struct {
+	Version  int
+	Movement *Movement
+}
+
+type StocktakeReport struct {
+	Stocktake     *Stocktake     `json:"Stocktake"`
+	Discrepancies []*Discrepancy `json:"Discrepancies"`
+}
@@ -5,6 +5,15 @@ import (
 	"time"
 )
 
+const (
+	RoleMember    = "member"
+	RoleLibrarian = "librarian"
+	RoleAdmin     = "admin"
+)
+
+// LoanPeriod is how long a borrowed book may be kept before it is overdue.
+const LoanPeriod = 14 * 24 * time.Hour
+
 type User struct {
 	ID              int       `json:"id"`
 	FirstName       string    `json:"first_name"`
@@ -13,12 +22,62 @@ type User struct {
 	Location        string    `json:"location"`
 	CellPhoneNumber string    `json:"cellphone_number"`
 	Email           string    `json:"email"`
-	Password        string    `json:"password"`
-	CreatedAt       time.Time `json:"created_at"`
-	UpdatedAt       time.Time `json:"updated_at"`
+	// Password is only ever read from requests: the service hashes it into
+	// PasswordHash and clears it, and the hash is never serialized.
+	Password     string `json:"password,omitempty"`
+	PasswordHash string `json:"-"`
+	Role         string `json:"role"`
+	// EmailVerifiedAt is when the user opened the verification link, nil until then.
+	EmailVerifiedAt *time.Time `json:"email_verified_at"`
+	Version         int        `json:"version"`
+	CreatedAt       time.Time  `json:"created_at"`
+	UpdatedAt       time.Time  `json:"updated_at"`
 	Books           []int
 }
 
+// UserFilter selects a page of users. Query is looked for in the name, email and
+// phone number; Sort names a field, descending when prefixed with "-".
+type UserFilter struct {
+	Query      string
+	Location   string
+	HasOverdue *bool
+	Sort       string
+	Page       int
+	PerPage    int
+}
+
+// EmailDuplicate is an address that several live users share, which has to be
+// resolved before the unique index on email can be created.
+type EmailDuplicate struct {
+	Email   string `json:"email"`
+	UserIDs []int  `json:"user_ids"`
+}
+
+// IsStaff tells librarians and admins, who run the catalogue and everybody's loans, from members.
+func (u *User) IsStaff() bool {
+	return u.Role == RoleLibrarian || u.Role == RoleAdmin
+}
+
+func (u *User) IsVerified() bool {
+	return u.EmailVerifiedAt != nil
+}
+
+// CanActFor tells whether u may handle the account and loans of the user with id userID.
+func (u *User) CanActFor(userID int) bool {
+	return u.ID == userID || u.IsStaff()
+}
+
+var roleRank = map[string]int{RoleMember: 0, RoleLibrarian: 1, RoleAdmin: 2}
+
+// CanEdit tells whether u may change or delete target's account: their own, or as
+// staff one whose role is not above theirs. Only the owner sets a password.
+func (u *User) CanEdit(target *User, setsPassword bool) bool {
+	if u.ID == target.ID {
+		return true
+	}
+	return !setsPassword && u.IsStaff() && roleRank[u.Role] >= roleRank[target.Role]
+}
+
 func (u *User) AddBook(idBook int) error {
 	for _, b := range u.Books {
 		if b == idBook {
@@ -0,0 +1,35 @@
+package auth
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type Repository interface {
+	CreateSession(s *entity.Session) error
+	GetSession(id string) (*entity.Session, error)
+	RotateNonce(id, nonce, newNonce string, expiresAt time.Time) error
+	RevokeSession(id string, at time.Time) error
+	CreateToken(t *entity.AccountToken) error
+	GetToken(hash string) (*entity.AccountToken, error)
+	LatestToken(userID int, purpose string) (*entity.AccountToken, error)
+	ConfirmEmail(hash string, at time.Time) error
+	ResetPassword(hash, passwordHash string, at time.Time) error
+}
+
+// Notifier delivers a message to an email address.
+type Notifier interface {
+	Notify(to, subject, body string) error
+}
+
+type UseCase interface {
+	Login(c *entity.Credentials) (*entity.TokenPair, error)
+	Refresh(refreshToken string) (*entity.TokenPair, error)
+	Logout(accessToken string) error
+	Authenticate(accessToken string) (*entity.User, error)
+	SendVerification(u *entity.User) error
+	VerifyEmail(token string) error
+	ResendVerification(email string) error
+	ForgotPassword(email string) error
+	ResetPassword(r *entity.PasswordReset) error
+}
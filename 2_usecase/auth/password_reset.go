@@ -0,0 +1,95 @@
+package auth
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
+	"log"
+	"time"
+)
+
+const ResetTTL = time.Hour
+
+// ForgotPassword mails the user a token to choose a new password with. Whether or not
+// the address belongs to anyone, and whether or not the mail goes out, it reports
+// success, so the answer doesn't tell who has an account; failures are only logged.
+// The token is made and mailed in the background, so the answer doesn't take longer
+// for a known address either.
+func (a *Auth) ForgotPassword(email string) error {
+	u, err := a.user.GetByEmailUser(email)
+	if err == entity.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a.background(func() {
+		err := a.sendReset(u)
+		if err != nil {
+			log.Printf("sending a password reset to user %d: %v", u.ID, err)
+		}
+	})
+	return nil
+}
+
+func (a *Auth) sendReset(u *entity.User) error {
+	if a.notifier == nil {
+		return errNoNotifier
+	}
+
+	now := a.now()
+	last, err := a.repo.LatestToken(u.ID, entity.TokenResetPassword)
+	if err != nil && err != entity.ErrNotFound {
+		return err
+	}
+	if err == nil && now.Sub(last.CreatedAt) < resendInterval {
+		return nil
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	t := &entity.AccountToken{Hash: hashToken(secret), UserID: u.ID, Purpose: entity.TokenResetPassword, Email: u.Email, CreatedAt: now, ExpiresAt: now.Add(ResetTTL)}
+	err = a.repo.CreateToken(t)
+	if err != nil {
+		return err
+	}
+
+	body := fmt.Sprintf("Hello %s,\n\nsomebody asked to reset the password of your account. To choose a new one, send this token with it to %s/auth/reset-password:\n\n%s\n\nThe token works once and expires in %d minutes. If it wasn't you, ignore this mail; your password stays as it is.\n",
+		u.FirstName, a.linkBase, secret, int(ResetTTL.Minutes()))
+	return a.notifier.Notify(u.Email, "Reset your password", body)
+}
+
+// ResetPassword sets the new password and ends every session of the user. The token
+// is only used up once the password has been accepted, and the repository does the
+// rest in one transaction, refusing a token sent to an address the user no longer has.
+func (a *Auth) ResetPassword(r *entity.PasswordReset) error {
+	hash := hashToken(r.Token)
+	t, err := a.repo.GetToken(hash)
+	if err == entity.ErrNotFound {
+		return entity.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	if t.Purpose != entity.TokenResetPassword || !t.UsedAt.IsZero() {
+		return entity.ErrInvalidToken
+	}
+	if !now.Before(t.ExpiresAt) {
+		return entity.ErrTokenExpired
+	}
+	if !password.Strong(r.Password) {
+		return entity.ErrWeakPassword
+	}
+
+	passwordHash, err := password.Hash(r.Password)
+	if err != nil {
+		return err
+	}
+	return a.repo.ResetPassword(hash, passwordHash, now)
+}
@@ -0,0 +1,115 @@
+package auth
+
+import (
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	amock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth/mocks"
+	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestForgotPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	n := amock.NewMockNotifier(controller)
+	a := NewService(m, u, secret)
+	a.SetNotifier(n, "https://library.example.com")
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	var pending []func()
+	a.background = func(f func()) { pending = append(pending, f) }
+
+	u.EXPECT().GetByEmailUser("nobody@gmail.com").Return(nil, entity.ErrNotFound)
+	assert.NoError(t, a.ForgotPassword("nobody@gmail.com"))
+	assert.Empty(t, pending)
+
+	peter := &entity.User{ID: 7, FirstName: "Peter", Email: "peter@gmail.com"}
+	u.EXPECT().GetByEmailUser("peter@gmail.com").Return(peter, nil).Times(3)
+	var stored *entity.AccountToken
+	var body string
+	m.EXPECT().LatestToken(7, entity.TokenResetPassword).Return(nil, entity.ErrNotFound)
+	m.EXPECT().CreateToken(gomock.Any()).DoAndReturn(func(tok *entity.AccountToken) error {
+		stored = tok
+		return nil
+	})
+	n.EXPECT().Notify("peter@gmail.com", "Reset your password", gomock.Any()).DoAndReturn(func(to, subject, b string) error {
+		body = b
+		return nil
+	})
+	// the token is only made after the answer, like the work for an unknown address
+	assert.NoError(t, a.ForgotPassword("peter@gmail.com"))
+	assert.Nil(t, stored)
+	assert.Len(t, pending, 1)
+	pending[0]()
+	assert.Equal(t, entity.TokenResetPassword, stored.Purpose)
+	assert.Equal(t, now.Add(ResetTTL), stored.ExpiresAt)
+	assert.NotContains(t, body, stored.Hash)
+
+	a.background = func(f func()) { f() }
+
+	// a second request right away sends nothing
+	m.EXPECT().LatestToken(7, entity.TokenResetPassword).Return(stored, nil)
+	assert.NoError(t, a.ForgotPassword("peter@gmail.com"))
+
+	// a mail that can't be sent doesn't give the account away
+	now = now.Add(time.Hour)
+	m.EXPECT().LatestToken(7, entity.TokenResetPassword).Return(stored, nil)
+	m.EXPECT().CreateToken(gomock.Any()).Return(nil)
+	n.EXPECT().Notify("peter@gmail.com", gomock.Any(), gomock.Any()).Return(errors.New("mail server is down"))
+	assert.NoError(t, a.ForgotPassword("peter@gmail.com"))
+}
+
+func TestResetPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	a := NewService(m, u, secret)
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	valid := &entity.AccountToken{Hash: hashToken("good"), UserID: 7, Purpose: entity.TokenResetPassword, Email: "peter@gmail.com", CreatedAt: now.Add(-time.Minute), ExpiresAt: now.Add(time.Hour)}
+	var stored string
+	gomock.InOrder(
+		m.EXPECT().GetToken(hashToken("good")).Return(valid, nil),
+		m.EXPECT().ResetPassword(hashToken("good"), gomock.Any(), now).DoAndReturn(func(hash, passwordHash string, at time.Time) error {
+			stored = passwordHash
+			return nil
+		}),
+	)
+	assert.NoError(t, a.ResetPassword(&entity.PasswordReset{Token: "good", Password: "newpassword1"}))
+	assert.True(t, password.Verify(stored, "newpassword1"))
+
+	// a weak password leaves the token for another try
+	m.EXPECT().GetToken(hashToken("good")).Return(valid, nil)
+	assert.Equal(t, entity.ErrWeakPassword, a.ResetPassword(&entity.PasswordReset{Token: "good", Password: "short"}))
+
+	tests := []struct {
+		token string
+		found *entity.AccountToken
+		err   error
+		want  error
+	}{
+		{token: "unknown", err: entity.ErrNotFound, want: entity.ErrInvalidToken},
+		{token: "used", found: &entity.AccountToken{Purpose: entity.TokenResetPassword, ExpiresAt: now.Add(time.Hour), UsedAt: now.Add(-time.Minute)}, want: entity.ErrInvalidToken},
+		{token: "expired", found: &entity.AccountToken{Purpose: entity.TokenResetPassword, ExpiresAt: now}, want: entity.ErrTokenExpired},
+		{token: "verification", found: &entity.AccountToken{Purpose: entity.TokenVerifyEmail, ExpiresAt: now.Add(time.Hour)}, want: entity.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		m.EXPECT().GetToken(hashToken(tt.token)).Return(tt.found, tt.err)
+		assert.Equal(t, tt.want, a.ResetPassword(&entity.PasswordReset{Token: tt.token, Password: "newpassword1"}), tt.token)
+	}
+
+	// used up by a concurrent reset, or sent to an address the user no longer has
+	m.EXPECT().GetToken(hashToken("raced")).Return(&entity.AccountToken{UserID: 7, Purpose: entity.TokenResetPassword, Email: "peter@gmail.com", ExpiresAt: now.Add(time.Hour)}, nil)
+	m.EXPECT().ResetPassword(hashToken("raced"), gomock.Any(), now).Return(entity.ErrInvalidToken)
+	assert.Equal(t, entity.ErrInvalidToken, a.ResetPassword(&entity.PasswordReset{Token: "raced", Password: "newpassword1"}))
+}
@@ -0,0 +1,182 @@
+package auth
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/token"
+	"strconv"
+	"time"
+)
+
+const (
+	DefaultAccessTTL  = 15 * time.Minute
+	DefaultRefreshTTL = 30 * 24 * time.Hour
+)
+
+// dummyHash is verified against when the email is unknown, so a login takes
+// as long whether or not the account exists.
+var dummyHash, _ = password.Hash("not a real password 0")
+
+type Auth struct {
+	repo       Repository
+	user       user.UseCase
+	secret     []byte
+	accessTTL  time.Duration
+	refreshTTL time.Duration
+	notifier   Notifier
+	linkBase   string
+	now        func() time.Time
+	// background runs the work behind answers that mustn't take longer for a known
+	// address than for an unknown one, such as mailing a reset token.
+	background func(func())
+}
+
+func NewService(repo Repository, u user.UseCase, secret []byte) *Auth {
+	return &Auth{repo: repo, user: u, secret: secret, accessTTL: DefaultAccessTTL, refreshTTL: DefaultRefreshTTL, now: time.Now, background: goroutine}
+}
+
+func goroutine(f func()) {
+	go f()
+}
+
+// SetTTLs changes how long access tokens and sessions (through their refresh tokens) last.
+func (a *Auth) SetTTLs(access, refresh time.Duration) {
+	a.accessTTL = access
+	a.refreshTTL = refresh
+}
+
+// Login checks the email and password and opens a session.
+func (a *Auth) Login(c *entity.Credentials) (*entity.TokenPair, error) {
+	u, err := a.user.GetByEmailUser(c.Email)
+	if err == entity.ErrNotFound {
+		password.Verify(dummyHash, c.Password)
+		return nil, entity.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+	if !password.Verify(u.PasswordHash, c.Password) {
+		return nil, entity.ErrUnauthorized
+	}
+
+	id, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	nonce, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	s := &entity.Session{ID: id, UserID: u.ID, Nonce: nonce, CreatedAt: now, ExpiresAt: now.Add(a.refreshTTL)}
+	err = a.repo.CreateSession(s)
+	if err != nil {
+		return nil, err
+	}
+	return a.issue(s, now)
+}
+
+// Refresh trades a refresh token for a new pair. Each refresh token works once: presenting
+// an old one again means it was stolen, and the whole session is revoked.
+func (a *Auth) Refresh(refreshToken string) (*entity.TokenPair, error) {
+	s, claims, err := a.session(refreshToken, token.UseRefresh)
+	if err != nil {
+		return nil, err
+	}
+
+	now := a.now()
+	if claims.ID != s.Nonce {
+		err = a.repo.RevokeSession(s.ID, now)
+		if err != nil {
+			return nil, err
+		}
+		return nil, entity.ErrUnauthorized
+	}
+
+	nonce, err := randomID()
+	if err != nil {
+		return nil, err
+	}
+	err = a.repo.RotateNonce(s.ID, s.Nonce, nonce, now.Add(a.refreshTTL))
+	if err == entity.ErrNotFound {
+		// a concurrent refresh with the same token won
+		return nil, entity.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	s.Nonce = nonce
+	s.ExpiresAt = now.Add(a.refreshTTL)
+	return a.issue(s, now)
+}
+
+func (a *Auth) Logout(accessToken string) error {
+	s, _, err := a.session(accessToken, token.UseAccess)
+	if err != nil {
+		return err
+	}
+
+	return a.repo.RevokeSession(s.ID, a.now())
+}
+
+// Authenticate returns the user behind an access token whose session is still open.
+func (a *Auth) Authenticate(accessToken string) (*entity.User, error) {
+	s, _, err := a.session(accessToken, token.UseAccess)
+	if err != nil {
+		return nil, err
+	}
+
+	u, err := a.user.GetByIDUser(s.UserID)
+	if err == entity.ErrNotFound {
+		return nil, entity.ErrUnauthorized
+	}
+	return u, err
+}
+
+func (a *Auth) session(tok, use string) (*entity.Session, *token.Claims, error) {
+	now := a.now()
+	claims, err := token.Parse(tok, a.secret, now)
+	if err != nil || claims.Use != use {
+		return nil, nil, entity.ErrUnauthorized
+	}
+
+	s, err := a.repo.GetSession(claims.Session)
+	if err == entity.ErrNotFound {
+		return nil, nil, entity.ErrUnauthorized
+	}
+	if err != nil {
+		return nil, nil, err
+	}
+	if !s.RevokedAt.IsZero() || !now.Before(s.ExpiresAt) || strconv.Itoa(s.UserID) != claims.Subject {
+		return nil, nil, entity.ErrUnauthorized
+	}
+	return s, claims, nil
+}
+
+func (a *Auth) issue(s *entity.Session, now time.Time) (*entity.TokenPair, error) {
+	subject := strconv.Itoa(s.UserID)
+	access, err := token.Sign(token.Claims{Subject: subject, Session: s.ID, Use: token.UseAccess, IssuedAt: now.Unix(), ExpiresAt: now.Add(a.accessTTL).Unix()}, a.secret)
+	if err != nil {
+		return nil, err
+	}
+	refresh, err := token.Sign(token.Claims{Subject: subject, Session: s.ID, Use: token.UseRefresh, ID: s.Nonce, IssuedAt: now.Unix(), ExpiresAt: s.ExpiresAt.Unix()}, a.secret)
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.TokenPair{AccessToken: access, RefreshToken: refresh, TokenType: "Bearer", ExpiresIn: int(a.accessTTL.Seconds())}, nil
+}
+
+func randomID() (string, error) {
+	b := make([]byte, 16)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
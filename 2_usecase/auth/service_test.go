@@ -0,0 +1,131 @@
+package auth
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	amock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth/mocks"
+	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/token"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+var secret = []byte("0123456789abcdef0123456789abcdef")
+
+func TestLogin(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	a := NewService(m, u, secret)
+
+	hash, err := password.Hash("qwerty12345")
+	assert.NoError(t, err)
+	user := &entity.User{ID: 7, Email: "peter@gmail.com", PasswordHash: hash}
+
+	var session *entity.Session
+	u.EXPECT().GetByEmailUser("Peter@gmail.com").Return(user, nil)
+	m.EXPECT().CreateSession(gomock.Any()).DoAndReturn(func(s *entity.Session) error {
+		session = s
+		return nil
+	})
+	tokens, err := a.Login(&entity.Credentials{Email: "Peter@gmail.com", Password: "qwerty12345"})
+	assert.NoError(t, err)
+	assert.Equal(t, "Bearer", tokens.TokenType)
+	assert.Equal(t, 900, tokens.ExpiresIn)
+	assert.Equal(t, 7, session.UserID)
+
+	claims, err := token.Parse(tokens.AccessToken, secret, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, "7", claims.Subject)
+	assert.Equal(t, session.ID, claims.Session)
+	assert.Equal(t, token.UseAccess, claims.Use)
+	claims, err = token.Parse(tokens.RefreshToken, secret, time.Now())
+	assert.NoError(t, err)
+	assert.Equal(t, session.Nonce, claims.ID)
+
+	u.EXPECT().GetByEmailUser("peter@gmail.com").Return(user, nil)
+	_, err = a.Login(&entity.Credentials{Email: "peter@gmail.com", Password: "wrong12345"})
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	u.EXPECT().GetByEmailUser("nobody@gmail.com").Return(nil, entity.ErrNotFound)
+	_, err = a.Login(&entity.Credentials{Email: "nobody@gmail.com", Password: "qwerty12345"})
+	assert.Equal(t, entity.ErrUnauthorized, err)
+}
+
+func TestAuthenticate(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	a := NewService(m, u, secret)
+
+	now := time.Now()
+	session := &entity.Session{ID: "s1", UserID: 7, Nonce: "n1", ExpiresAt: now.Add(time.Hour)}
+	tokens, err := a.issue(session, now)
+	assert.NoError(t, err)
+
+	user := &entity.User{ID: 7}
+	m.EXPECT().GetSession("s1").Return(session, nil)
+	u.EXPECT().GetByIDUser(7).Return(user, nil)
+	userGot, err := a.Authenticate(tokens.AccessToken)
+	assert.NoError(t, err)
+	assert.Equal(t, user, userGot)
+
+	// a refresh token doesn't open the API
+	_, err = a.Authenticate(tokens.RefreshToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	m.EXPECT().GetSession("s1").Return(&entity.Session{ID: "s1", UserID: 7, ExpiresAt: now.Add(time.Hour), RevokedAt: now}, nil)
+	_, err = a.Authenticate(tokens.AccessToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	m.EXPECT().GetSession("s1").Return(session, nil)
+	u.EXPECT().GetByIDUser(7).Return(nil, entity.ErrNotFound)
+	_, err = a.Authenticate(tokens.AccessToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	a.now = func() time.Time { return now.Add(DefaultAccessTTL) }
+	_, err = a.Authenticate(tokens.AccessToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+}
+
+func TestRefresh(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	a := NewService(m, u, secret)
+
+	now := time.Now()
+	session := &entity.Session{ID: "s1", UserID: 7, Nonce: "n1", ExpiresAt: now.Add(time.Hour)}
+	tokens, err := a.issue(session, now)
+	assert.NoError(t, err)
+
+	m.EXPECT().GetSession("s1").Return(&entity.Session{ID: "s1", UserID: 7, Nonce: "n1", ExpiresAt: now.Add(time.Hour)}, nil)
+	m.EXPECT().RotateNonce("s1", "n1", gomock.Any(), gomock.Any()).Return(nil)
+	refreshed, err := a.Refresh(tokens.RefreshToken)
+	assert.NoError(t, err)
+	claims, err := token.Parse(refreshed.RefreshToken, secret, now)
+	assert.NoError(t, err)
+	assert.NotEqual(t, "n1", claims.ID)
+
+	// the old refresh token comes back: somebody else has a copy, so the session ends
+	m.EXPECT().GetSession("s1").Return(&entity.Session{ID: "s1", UserID: 7, Nonce: claims.ID, ExpiresAt: now.Add(time.Hour)}, nil)
+	m.EXPECT().RevokeSession("s1", gomock.Any()).Return(nil)
+	_, err = a.Refresh(tokens.RefreshToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	_, err = a.Refresh(tokens.AccessToken)
+	assert.Equal(t, entity.ErrUnauthorized, err)
+
+	m.EXPECT().GetSession("s1").Return(session, nil)
+	m.EXPECT().RevokeSession("s1", gomock.Any()).Return(nil)
+	assert.NoError(t, a.Logout(tokens.AccessToken))
+	assert.Equal(t, entity.ErrUnauthorized, a.Logout("garbage"))
+}
@@ -0,0 +1,129 @@
+package auth
+
+import (
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"log"
+	"net/url"
+	"strings"
+	"time"
+)
+
+const (
+	VerificationTTL = 48 * time.Hour
+	// resendInterval keeps a resend button from flooding an inbox.
+	resendInterval = time.Minute
+)
+
+var errNoNotifier = errors.New("no notifier is configured")
+
+// SetNotifier sets how account links are delivered and the public URL of the API
+// they point to, such as https://library.example.com.
+func (a *Auth) SetNotifier(n Notifier, linkBase string) {
+	a.notifier = n
+	a.linkBase = strings.TrimSuffix(linkBase, "/")
+}
+
+// SendVerification mails u a link that confirms their current email address.
+func (a *Auth) SendVerification(u *entity.User) error {
+	if a.notifier == nil {
+		return errNoNotifier
+	}
+
+	secret, err := randomToken()
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	t := &entity.AccountToken{Hash: hashToken(secret), UserID: u.ID, Purpose: entity.TokenVerifyEmail, Email: u.Email, CreatedAt: now, ExpiresAt: now.Add(VerificationTTL)}
+	err = a.repo.CreateToken(t)
+	if err != nil {
+		return err
+	}
+
+	link := a.linkBase + "/auth/verify?token=" + url.QueryEscape(secret)
+	body := fmt.Sprintf("Hello %s,\n\nplease confirm your email address by opening\n\n%s\n\nThe link works once and expires in %d hours. You can borrow books once the address is confirmed.\n",
+		u.FirstName, link, int(VerificationTTL.Hours()))
+	return a.notifier.Notify(u.Email, "Confirm your email address", body)
+}
+
+// VerifyEmail marks the address the token was sent to as confirmed, as long as it
+// is still the user's address.
+func (a *Auth) VerifyEmail(secret string) error {
+	hash := hashToken(secret)
+	t, err := a.repo.GetToken(hash)
+	if err == entity.ErrNotFound {
+		return entity.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	now := a.now()
+	if t.Purpose != entity.TokenVerifyEmail || !t.UsedAt.IsZero() {
+		return entity.ErrInvalidToken
+	}
+	if !now.Before(t.ExpiresAt) {
+		return entity.ErrTokenExpired
+	}
+
+	return a.repo.ConfirmEmail(hash, now)
+}
+
+// ResendVerification sends a new link to an unverified user. It succeeds whether or
+// not the address belongs to anyone, so it can't be used to find out who has an account;
+// the link is sent in the background and failures are only logged, so the time it
+// takes to answer doesn't tell either.
+func (a *Auth) ResendVerification(email string) error {
+	u, err := a.user.GetByEmailUser(email)
+	if err == entity.ErrNotFound {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	a.background(func() {
+		err := a.resendVerification(u)
+		if err != nil {
+			log.Printf("resending the verification link to user %d: %v", u.ID, err)
+		}
+	})
+	return nil
+}
+
+func (a *Auth) resendVerification(u *entity.User) error {
+	if u.IsVerified() {
+		return nil
+	}
+
+	last, err := a.repo.LatestToken(u.ID, entity.TokenVerifyEmail)
+	if err != nil && err != entity.ErrNotFound {
+		return err
+	}
+	if err == nil && a.now().Sub(last.CreatedAt) < resendInterval {
+		return nil
+	}
+
+	return a.SendVerification(u)
+}
+
+// randomToken is the secret that goes into a link; it is only stored hashed.
+func randomToken() (string, error) {
+	b := make([]byte, 32)
+	_, err := rand.Read(b)
+	if err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+func hashToken(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
@@ -0,0 +1,113 @@
+package auth
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	amock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth/mocks"
+	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"net/url"
+	"regexp"
+	"testing"
+	"time"
+)
+
+var linkPattern = regexp.MustCompile(`https://library\.example\.com/auth/verify\?token=(\S+)`)
+
+func TestSendVerification(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	n := amock.NewMockNotifier(controller)
+	a := NewService(m, umock.NewMockUseCase(controller), secret)
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	user := &entity.User{ID: 7, FirstName: "Peter", Email: "peter@gmail.com"}
+	assert.Error(t, a.SendVerification(user))
+
+	a.SetNotifier(n, "https://library.example.com/")
+	var stored *entity.AccountToken
+	var body string
+	m.EXPECT().CreateToken(gomock.Any()).DoAndReturn(func(tok *entity.AccountToken) error {
+		stored = tok
+		return nil
+	})
+	n.EXPECT().Notify("peter@gmail.com", gomock.Any(), gomock.Any()).DoAndReturn(func(to, subject, b string) error {
+		body = b
+		return nil
+	})
+	assert.NoError(t, a.SendVerification(user))
+
+	match := linkPattern.FindStringSubmatch(body)
+	assert.Len(t, match, 2)
+	plain, err := url.QueryUnescape(match[1])
+	assert.NoError(t, err)
+	// only the hash of the link's token is kept
+	assert.Equal(t, hashToken(plain), stored.Hash)
+	assert.NotEqual(t, plain, stored.Hash)
+	assert.Equal(t, &entity.AccountToken{Hash: stored.Hash, UserID: 7, Purpose: entity.TokenVerifyEmail, Email: "peter@gmail.com", CreatedAt: now, ExpiresAt: now.Add(VerificationTTL)}, stored)
+}
+
+func TestVerifyEmail(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	a := NewService(m, umock.NewMockUseCase(controller), secret)
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+
+	valid := &entity.AccountToken{Hash: hashToken("good"), UserID: 7, Purpose: entity.TokenVerifyEmail, CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	m.EXPECT().GetToken(hashToken("good")).Return(valid, nil)
+	m.EXPECT().ConfirmEmail(hashToken("good"), now).Return(nil)
+	assert.NoError(t, a.VerifyEmail("good"))
+
+	tests := []struct {
+		secret string
+		token  *entity.AccountToken
+		err    error
+		want   error
+	}{
+		{secret: "unknown", err: entity.ErrNotFound, want: entity.ErrInvalidToken},
+		{secret: "used", token: &entity.AccountToken{Purpose: entity.TokenVerifyEmail, ExpiresAt: now.Add(time.Hour), UsedAt: now.Add(-time.Minute)}, want: entity.ErrInvalidToken},
+		{secret: "expired", token: &entity.AccountToken{Purpose: entity.TokenVerifyEmail, ExpiresAt: now}, want: entity.ErrTokenExpired},
+		{secret: "other", token: &entity.AccountToken{Purpose: "other", ExpiresAt: now.Add(time.Hour)}, want: entity.ErrInvalidToken},
+	}
+	for _, tt := range tests {
+		m.EXPECT().GetToken(hashToken(tt.secret)).Return(tt.token, tt.err)
+		assert.Equal(t, tt.want, a.VerifyEmail(tt.secret), tt.secret)
+	}
+}
+
+func TestResendVerification(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockRepository(controller)
+	u := umock.NewMockUseCase(controller)
+	n := amock.NewMockNotifier(controller)
+	a := NewService(m, u, secret)
+	a.SetNotifier(n, "https://library.example.com")
+	now := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	a.now = func() time.Time { return now }
+	a.background = func(f func()) { f() }
+	verifiedAt := now.Add(-time.Hour)
+
+	// unknown and verified addresses are answered the same, without a mail
+	u.EXPECT().GetByEmailUser("nobody@gmail.com").Return(nil, entity.ErrNotFound)
+	assert.NoError(t, a.ResendVerification("nobody@gmail.com"))
+	u.EXPECT().GetByEmailUser("anna@gmail.com").Return(&entity.User{ID: 8, EmailVerifiedAt: &verifiedAt}, nil)
+	assert.NoError(t, a.ResendVerification("anna@gmail.com"))
+
+	peter := &entity.User{ID: 7, Email: "peter@gmail.com"}
+	u.EXPECT().GetByEmailUser("peter@gmail.com").Return(peter, nil).Times(2)
+	m.EXPECT().LatestToken(7, entity.TokenVerifyEmail).Return(&entity.AccountToken{CreatedAt: now.Add(-10 * time.Second)}, nil)
+	assert.NoError(t, a.ResendVerification("peter@gmail.com"))
+
+	m.EXPECT().LatestToken(7, entity.TokenVerifyEmail).Return(&entity.AccountToken{CreatedAt: now.Add(-time.Hour)}, nil)
+	m.EXPECT().CreateToken(gomock.Any()).Return(nil)
+	n.EXPECT().Notify("peter@gmail.com", gomock.Any(), gomock.Any()).Return(nil)
+	assert.NoError(t, a.ResendVerification("peter@gmail.com"))
+}
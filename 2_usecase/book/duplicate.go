@@ -0,0 +1,122 @@
+package book
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/isbn"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/textmatch"
+	"sort"
+)
+
+// DefaultDuplicateThreshold is the title/author score from which two books are reported.
+const DefaultDuplicateThreshold = 0.85
+
+// the title weighs more than the author, whose spelling varies more ("Caldwell E.")
+const titleWeight = 0.7
+
+// minBlockToken keeps short words like "of" from pairing every book with every other.
+const minBlockToken = 4
+
+type normalizedBook struct {
+	book   *entity.Book
+	title  string
+	author string
+}
+
+// FindDuplicates pairs books with the same ISBN, counting an ISBN-10 and its
+// ISBN-13 as the same, and books whose normalized title and author are similar.
+// Only books sharing a title word are compared, which keeps it well below n².
+func (u *Books) FindDuplicates(threshold float64) ([]*entity.DuplicateCandidate, error) {
+	if threshold <= 0 || threshold > 1 {
+		return nil, entity.ErrInvalidEntity
+	}
+
+	books, err := u.repo.GetAll()
+	if err != nil {
+		return nil, err
+	}
+	sort.Slice(books, func(i, j int) bool { return books[i].ID < books[j].ID })
+
+	seen := make(map[[2]int]bool)
+	var candidates []*entity.DuplicateCandidate
+	add := func(a, b *entity.Book, score float64, reason string) {
+		key := [2]int{a.ID, b.ID}
+		if seen[key] {
+			return
+		}
+		seen[key] = true
+		candidates = append(candidates, &entity.DuplicateCandidate{Book: a, Other: b, Score: score, Reason: reason})
+	}
+
+	byISBN := make(map[string][]*entity.Book)
+	for _, b := range books {
+		if b.ISBN != "" {
+			code := isbn.To13(b.ISBN)
+			byISBN[code] = append(byISBN[code], b)
+		}
+	}
+	for _, b := range books {
+		if b.ISBN == "" {
+			continue
+		}
+		for _, other := range byISBN[isbn.To13(b.ISBN)] {
+			if other.ID > b.ID {
+				add(b, other, 1, entity.DuplicateISBN)
+			}
+		}
+	}
+
+	normalized := make([]*normalizedBook, len(books))
+	blocks := make(map[string][]int)
+	for i, b := range books {
+		normalized[i] = &normalizedBook{book: b, title: textmatch.Normalize(b.Tittle), author: textmatch.Normalize(b.Author)}
+		for _, token := range textmatch.Tokens(normalized[i].title) {
+			if len([]rune(token)) >= minBlockToken {
+				blocks[token] = append(blocks[token], i)
+			}
+		}
+	}
+	for _, block := range blocks {
+		for x := 0; x < len(block); x++ {
+			for y := x + 1; y < len(block); y++ {
+				a, b := normalized[block[x]], normalized[block[y]]
+				score := titleWeight*textmatch.Similarity(a.title, b.title) + (1-titleWeight)*textmatch.Similarity(a.author, b.author)
+				if score >= threshold {
+					add(a.book, b.book, score, entity.DuplicateTitleAuthor)
+				}
+			}
+		}
+	}
+
+	sort.SliceStable(candidates, func(i, j int) bool {
+		if candidates[i].Score != candidates[j].Score {
+			return candidates[i].Score > candidates[j].Score
+		}
+		if candidates[i].Book.ID != candidates[j].Book.ID {
+			return candidates[i].Book.ID < candidates[j].Book.ID
+		}
+		return candidates[i].Other.ID < candidates[j].Other.ID
+	})
+	return candidates, nil
+}
+
+// MergeBooks moves the copies, loans and ledger of the duplicates to the survivor
+// and deletes the duplicates, all in one transaction.
+func (u *Books) MergeBooks(merge *entity.BookMerge, actor string) (*entity.Book, error) {
+	if merge.SurvivorID <= 0 || len(merge.DuplicateIDs) == 0 {
+		return nil, entity.ErrInvalidEntity
+	}
+	ids := map[int]bool{merge.SurvivorID: true}
+	for _, id := range merge.DuplicateIDs {
+		if id <= 0 || ids[id] {
+			return nil, entity.ErrInvalidEntity
+		}
+		ids[id] = true
+	}
+
+	err := u.repo.Merge(merge.SurvivorID, merge.DuplicateIDs, actor)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(merge.SurvivorID)
+}
@@ -0,0 +1,75 @@
+package book
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestFindDuplicates(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	books := []*entity.Book{
+		{ID: 5, Tittle: "Tobaco Road", Author: "Caldwell Erskine"},
+		{ID: 1, Tittle: "Tobacco Road", Author: "Erskine Caldwell"},
+		{ID: 2, ISBN: "0306406152", Tittle: "Journeyman", Author: "Erskine Caldwell"},
+		{ID: 3, ISBN: "9780306406157", Tittle: "Journeyman (reprint)", Author: "E. Caldwell"},
+		{ID: 4, Tittle: "The Odyssey", Author: "Homer"},
+		{ID: 6, Tittle: "God's Little Acre", Author: "Erskine Caldwell"},
+	}
+	m.EXPECT().GetAll().Return(books, nil)
+
+	candidatesGot, err := b.FindDuplicates(DefaultDuplicateThreshold)
+	assert.NoError(t, err)
+	assert.Len(t, candidatesGot, 2)
+
+	assert.Equal(t, 2, candidatesGot[0].Book.ID)
+	assert.Equal(t, 3, candidatesGot[0].Other.ID)
+	assert.Equal(t, entity.DuplicateISBN, candidatesGot[0].Reason)
+
+	assert.Equal(t, 1, candidatesGot[1].Book.ID)
+	assert.Equal(t, 5, candidatesGot[1].Other.ID)
+	assert.Equal(t, entity.DuplicateTitleAuthor, candidatesGot[1].Reason)
+	assert.Greater(t, candidatesGot[1].Score, DefaultDuplicateThreshold)
+
+	_, err = b.FindDuplicates(0)
+	assert.Equal(t, entity.ErrInvalidEntity, err)
+}
+
+func TestMergeBooks(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	survivor := &entity.Book{ID: 1, Tittle: "Tobacco Road", Quantity: 5, Available: 4, Version: 3}
+	m.EXPECT().Merge(1, []int{5, 7}, "librarian").Return(nil)
+	m.EXPECT().GetByID(1).Return(survivor, nil)
+
+	bookGot, err := b.MergeBooks(&entity.BookMerge{SurvivorID: 1, DuplicateIDs: []int{5, 7}}, "librarian")
+	assert.NoError(t, err)
+	assert.Equal(t, survivor, bookGot)
+
+	m.EXPECT().Merge(1, []int{8}, "librarian").Return(entity.ErrNotFound)
+	_, err = b.MergeBooks(&entity.BookMerge{SurvivorID: 1, DuplicateIDs: []int{8}}, "librarian")
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	invalid := []*entity.BookMerge{
+		{SurvivorID: 1},
+		{SurvivorID: 0, DuplicateIDs: []int{2}},
+		{SurvivorID: 1, DuplicateIDs: []int{1}},
+		{SurvivorID: 1, DuplicateIDs: []int{2, 2}},
+		{SurvivorID: 1, DuplicateIDs: []int{-2}},
+	}
+	for _, merge := range invalid {
+		_, err = b.MergeBooks(merge, "librarian")
+		assert.Equal(t, entity.ErrInvalidEntity, err)
+	}
+}
@@ -1,19 +1,50 @@
 package book
 
-import entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
 
 type Repository interface {
 	Create(b *entity.Book) error
 	GetByID(id int) (*entity.Book, error)
+	GetByISBN(isbn string) (*entity.Book, error)
+	GetByBarcode(barcode string) (*entity.Book, error)
 	GetAll() ([]*entity.Book, error)
+	GetBySeries(seriesID int) ([]*entity.Book, error)
+	GetByBranch(branch string) ([]*entity.Book, error)
+	GetBorrowers(id int) ([]int, error)
+	HasBorrowed(id, userID int) (bool, error)
 	Update(b *entity.Book) error
-	Delete(id int) error
+	CheckOut(id, userID int) error
+	CheckIn(id, userID int) error
+	GetMovements(id int) ([]*entity.Movement, error)
+	Reconcile() ([]*entity.StockMismatch, error)
+	Merge(survivorID int, duplicateIDs []int, actor string) error
+	Delete(id, version int, mode string) error
+	Restore(id int) error
+	PurgeDeleted(before time.Time) (int, error)
 }
 
 type UseCase interface {
 	CreateBook(b *entity.Book) error
 	GetByIDBook(id int) (*entity.Book, error)
+	GetByISBNBook(isbn string) (*entity.Book, error)
+	GetByBarcodeBook(barcode string) (*entity.Book, error)
 	GetAllBooks() ([]*entity.Book, error)
+	GetBySeriesBook(seriesID int) ([]*entity.Book, error)
+	GetByBranchBook(branch string) ([]*entity.Book, error)
+	HasBorrowedBook(id, userID int) (bool, error)
+	CheckCreateBook(b *entity.Book) error
 	UpdateBook(b *entity.Book) error
-	DeleteBook(id int) error
+	CheckUpdateBook(b *entity.Book) error
+	CheckOutBook(id, userID int) error
+	CheckInBook(id, userID int) error
+	GetMovementsBook(id int) ([]*entity.Movement, error)
+	ReconcileStock() ([]*entity.StockMismatch, error)
+	FindDuplicates(threshold float64) ([]*entity.DuplicateCandidate, error)
+	MergeBooks(merge *entity.BookMerge, actor string) (*entity.Book, error)
+	DeleteBook(id, version int, mode string) error
+	RestoreBook(id int) (*entity.Book, error)
+	PurgeDeletedBooks(retention time.Duration) (int, error)
 }
@@ -6,6 +6,7 @@ package bmock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	gomock "github.com/golang/mock/gomock"
@@ -34,6 +35,34 @@ func (m *MockRepository) EXPECT() *MockRepositoryMockRecorder {
 	return m.recorder
 }
 
+// CheckIn mocks base method.
+func (m *MockRepository) CheckIn(id, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckIn", id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckIn indicates an expected call of CheckIn.
+func (mr *MockRepositoryMockRecorder) CheckIn(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckIn", reflect.TypeOf((*MockRepository)(nil).CheckIn), id, userID)
+}
+
+// CheckOut mocks base method.
+func (m *MockRepository) CheckOut(id, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckOut", id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckOut indicates an expected call of CheckOut.
+func (mr *MockRepositoryMockRecorder) CheckOut(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOut", reflect.TypeOf((*MockRepository)(nil).CheckOut), id, userID)
+}
+
 // Create mocks base method.
 func (m *MockRepository) Create(b *entity.Book) error {
 	m.ctrl.T.Helper()
@@ -49,17 +78,17 @@ func (mr *MockRepositoryMockRecorder) Create(b interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockRepository) Delete(id int) error {
+func (m *MockRepository) Delete(id, version int, mode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", id, version, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Delete(id, version, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id, version, mode)
 }
 
 // GetAll mocks base method.
@@ -77,6 +106,51 @@ func (mr *MockRepositoryMockRecorder) GetAll() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRepository)(nil).GetAll))
 }
 
+// GetBorrowers mocks base method.
+func (m *MockRepository) GetBorrowers(id int) ([]int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBorrowers", id)
+	ret0, _ := ret[0].([]int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBorrowers indicates an expected call of GetBorrowers.
+func (mr *MockRepositoryMockRecorder) GetBorrowers(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBorrowers", reflect.TypeOf((*MockRepository)(nil).GetBorrowers), id)
+}
+
+// GetByBarcode mocks base method.
+func (m *MockRepository) GetByBarcode(barcode string) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByBarcode", barcode)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByBarcode indicates an expected call of GetByBarcode.
+func (mr *MockRepositoryMockRecorder) GetByBarcode(barcode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByBarcode", reflect.TypeOf((*MockRepository)(nil).GetByBarcode), barcode)
+}
+
+// GetByBranch mocks base method.
+func (m *MockRepository) GetByBranch(branch string) ([]*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByBranch", branch)
+	ret0, _ := ret[0].([]*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByBranch indicates an expected call of GetByBranch.
+func (mr *MockRepositoryMockRecorder) GetByBranch(branch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByBranch", reflect.TypeOf((*MockRepository)(nil).GetByBranch), branch)
+}
+
 // GetByID mocks base method.
 func (m *MockRepository) GetByID(id int) (*entity.Book, error) {
 	m.ctrl.T.Helper()
@@ -92,6 +166,124 @@ func (mr *MockRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRepository)(nil).GetByID), id)
 }
 
+// GetByISBN mocks base method.
+func (m *MockRepository) GetByISBN(isbn string) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByISBN", isbn)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByISBN indicates an expected call of GetByISBN.
+func (mr *MockRepositoryMockRecorder) GetByISBN(isbn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByISBN", reflect.TypeOf((*MockRepository)(nil).GetByISBN), isbn)
+}
+
+// GetBySeries mocks base method.
+func (m *MockRepository) GetBySeries(seriesID int) ([]*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySeries", seriesID)
+	ret0, _ := ret[0].([]*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySeries indicates an expected call of GetBySeries.
+func (mr *MockRepositoryMockRecorder) GetBySeries(seriesID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySeries", reflect.TypeOf((*MockRepository)(nil).GetBySeries), seriesID)
+}
+
+// GetMovements mocks base method.
+func (m *MockRepository) GetMovements(id int) ([]*entity.Movement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMovements", id)
+	ret0, _ := ret[0].([]*entity.Movement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMovements indicates an expected call of GetMovements.
+func (mr *MockRepositoryMockRecorder) GetMovements(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovements", reflect.TypeOf((*MockRepository)(nil).GetMovements), id)
+}
+
+// HasBorrowed mocks base method.
+func (m *MockRepository) HasBorrowed(id, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasBorrowed", id, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasBorrowed indicates an expected call of HasBorrowed.
+func (mr *MockRepositoryMockRecorder) HasBorrowed(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasBorrowed", reflect.TypeOf((*MockRepository)(nil).HasBorrowed), id, userID)
+}
+
+// Merge mocks base method.
+func (m *MockRepository) Merge(survivorID int, duplicateIDs []int, actor string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Merge", survivorID, duplicateIDs, actor)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Merge indicates an expected call of Merge.
+func (mr *MockRepositoryMockRecorder) Merge(survivorID, duplicateIDs, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Merge", reflect.TypeOf((*MockRepository)(nil).Merge), survivorID, duplicateIDs, actor)
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockRepository) PurgeDeleted(before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockRepositoryMockRecorder) PurgeDeleted(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockRepository)(nil).PurgeDeleted), before)
+}
+
+// Reconcile mocks base method.
+func (m *MockRepository) Reconcile() ([]*entity.StockMismatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Reconcile")
+	ret0, _ := ret[0].([]*entity.StockMismatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// Reconcile indicates an expected call of Reconcile.
+func (mr *MockRepositoryMockRecorder) Reconcile() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Reconcile", reflect.TypeOf((*MockRepository)(nil).Reconcile))
+}
+
+// Restore mocks base method.
+func (m *MockRepository) Restore(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRepositoryMockRecorder) Restore(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), id)
+}
+
 // Update mocks base method.
 func (m *MockRepository) Update(b *entity.Book) error {
 	m.ctrl.T.Helper()
@@ -129,6 +321,62 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 	return m.recorder
 }
 
+// CheckCreateBook mocks base method.
+func (m *MockUseCase) CheckCreateBook(b *entity.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckCreateBook", b)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckCreateBook indicates an expected call of CheckCreateBook.
+func (mr *MockUseCaseMockRecorder) CheckCreateBook(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckCreateBook", reflect.TypeOf((*MockUseCase)(nil).CheckCreateBook), b)
+}
+
+// CheckInBook mocks base method.
+func (m *MockUseCase) CheckInBook(id, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckInBook", id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckInBook indicates an expected call of CheckInBook.
+func (mr *MockUseCaseMockRecorder) CheckInBook(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckInBook", reflect.TypeOf((*MockUseCase)(nil).CheckInBook), id, userID)
+}
+
+// CheckOutBook mocks base method.
+func (m *MockUseCase) CheckOutBook(id, userID int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckOutBook", id, userID)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckOutBook indicates an expected call of CheckOutBook.
+func (mr *MockUseCaseMockRecorder) CheckOutBook(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckOutBook", reflect.TypeOf((*MockUseCase)(nil).CheckOutBook), id, userID)
+}
+
+// CheckUpdateBook mocks base method.
+func (m *MockUseCase) CheckUpdateBook(b *entity.Book) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "CheckUpdateBook", b)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// CheckUpdateBook indicates an expected call of CheckUpdateBook.
+func (mr *MockUseCaseMockRecorder) CheckUpdateBook(b interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CheckUpdateBook", reflect.TypeOf((*MockUseCase)(nil).CheckUpdateBook), b)
+}
+
 // CreateBook mocks base method.
 func (m *MockUseCase) CreateBook(b *entity.Book) error {
 	m.ctrl.T.Helper()
@@ -144,17 +392,32 @@ func (mr *MockUseCaseMockRecorder) CreateBook(b interface{}) *gomock.Call {
 }
 
 // DeleteBook mocks base method.
-func (m *MockUseCase) DeleteBook(id int) error {
+func (m *MockUseCase) DeleteBook(id, version int, mode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteBook", id)
+	ret := m.ctrl.Call(m, "DeleteBook", id, version, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteBook indicates an expected call of DeleteBook.
-func (mr *MockUseCaseMockRecorder) DeleteBook(id interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) DeleteBook(id, version, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockUseCase)(nil).DeleteBook), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteBook", reflect.TypeOf((*MockUseCase)(nil).DeleteBook), id, version, mode)
+}
+
+// FindDuplicates mocks base method.
+func (m *MockUseCase) FindDuplicates(threshold float64) ([]*entity.DuplicateCandidate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "FindDuplicates", threshold)
+	ret0, _ := ret[0].([]*entity.DuplicateCandidate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicates indicates an expected call of FindDuplicates.
+func (mr *MockUseCaseMockRecorder) FindDuplicates(threshold interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicates", reflect.TypeOf((*MockUseCase)(nil).FindDuplicates), threshold)
 }
 
 // GetAllBooks mocks base method.
@@ -172,6 +435,36 @@ func (mr *MockUseCaseMockRecorder) GetAllBooks() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllBooks", reflect.TypeOf((*MockUseCase)(nil).GetAllBooks))
 }
 
+// GetByBarcodeBook mocks base method.
+func (m *MockUseCase) GetByBarcodeBook(barcode string) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByBarcodeBook", barcode)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByBarcodeBook indicates an expected call of GetByBarcodeBook.
+func (mr *MockUseCaseMockRecorder) GetByBarcodeBook(barcode interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByBarcodeBook", reflect.TypeOf((*MockUseCase)(nil).GetByBarcodeBook), barcode)
+}
+
+// GetByBranchBook mocks base method.
+func (m *MockUseCase) GetByBranchBook(branch string) ([]*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByBranchBook", branch)
+	ret0, _ := ret[0].([]*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByBranchBook indicates an expected call of GetByBranchBook.
+func (mr *MockUseCaseMockRecorder) GetByBranchBook(branch interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByBranchBook", reflect.TypeOf((*MockUseCase)(nil).GetByBranchBook), branch)
+}
+
 // GetByIDBook mocks base method.
 func (m *MockUseCase) GetByIDBook(id int) (*entity.Book, error) {
 	m.ctrl.T.Helper()
@@ -187,6 +480,126 @@ func (mr *MockUseCaseMockRecorder) GetByIDBook(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDBook", reflect.TypeOf((*MockUseCase)(nil).GetByIDBook), id)
 }
 
+// GetByISBNBook mocks base method.
+func (m *MockUseCase) GetByISBNBook(isbn string) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByISBNBook", isbn)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetByISBNBook indicates an expected call of GetByISBNBook.
+func (mr *MockUseCaseMockRecorder) GetByISBNBook(isbn interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByISBNBook", reflect.TypeOf((*MockUseCase)(nil).GetByISBNBook), isbn)
+}
+
+// GetBySeriesBook mocks base method.
+func (m *MockUseCase) GetBySeriesBook(seriesID int) ([]*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetBySeriesBook", seriesID)
+	ret0, _ := ret[0].([]*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetBySeriesBook indicates an expected call of GetBySeriesBook.
+func (mr *MockUseCaseMockRecorder) GetBySeriesBook(seriesID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetBySeriesBook", reflect.TypeOf((*MockUseCase)(nil).GetBySeriesBook), seriesID)
+}
+
+// GetMovementsBook mocks base method.
+func (m *MockUseCase) GetMovementsBook(id int) ([]*entity.Movement, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetMovementsBook", id)
+	ret0, _ := ret[0].([]*entity.Movement)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetMovementsBook indicates an expected call of GetMovementsBook.
+func (mr *MockUseCaseMockRecorder) GetMovementsBook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetMovementsBook", reflect.TypeOf((*MockUseCase)(nil).GetMovementsBook), id)
+}
+
+// HasBorrowedBook mocks base method.
+func (m *MockUseCase) HasBorrowedBook(id, userID int) (bool, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HasBorrowedBook", id, userID)
+	ret0, _ := ret[0].(bool)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HasBorrowedBook indicates an expected call of HasBorrowedBook.
+func (mr *MockUseCaseMockRecorder) HasBorrowedBook(id, userID interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HasBorrowedBook", reflect.TypeOf((*MockUseCase)(nil).HasBorrowedBook), id, userID)
+}
+
+// MergeBooks mocks base method.
+func (m *MockUseCase) MergeBooks(merge *entity.BookMerge, actor string) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "MergeBooks", merge, actor)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// MergeBooks indicates an expected call of MergeBooks.
+func (mr *MockUseCaseMockRecorder) MergeBooks(merge, actor interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "MergeBooks", reflect.TypeOf((*MockUseCase)(nil).MergeBooks), merge, actor)
+}
+
+// PurgeDeletedBooks mocks base method.
+func (m *MockUseCase) PurgeDeletedBooks(retention time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedBooks", retention)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedBooks indicates an expected call of PurgeDeletedBooks.
+func (mr *MockUseCaseMockRecorder) PurgeDeletedBooks(retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedBooks", reflect.TypeOf((*MockUseCase)(nil).PurgeDeletedBooks), retention)
+}
+
+// ReconcileStock mocks base method.
+func (m *MockUseCase) ReconcileStock() ([]*entity.StockMismatch, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "ReconcileStock")
+	ret0, _ := ret[0].([]*entity.StockMismatch)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// ReconcileStock indicates an expected call of ReconcileStock.
+func (mr *MockUseCaseMockRecorder) ReconcileStock() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "ReconcileStock", reflect.TypeOf((*MockUseCase)(nil).ReconcileStock))
+}
+
+// RestoreBook mocks base method.
+func (m *MockUseCase) RestoreBook(id int) (*entity.Book, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreBook", id)
+	ret0, _ := ret[0].(*entity.Book)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreBook indicates an expected call of RestoreBook.
+func (mr *MockUseCaseMockRecorder) RestoreBook(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreBook", reflect.TypeOf((*MockUseCase)(nil).RestoreBook), id)
+}
+
 // UpdateBook mocks base method.
 func (m *MockUseCase) UpdateBook(b *entity.Book) error {
 	m.ctrl.T.Helper()
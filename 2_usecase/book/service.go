@@ -2,67 +2,263 @@ package book
 
 import (
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/isbn"
+	"strings"
 	"time"
 )
 
+const (
+	earliestPublicationYear = 1450
+	maxBranchLength         = 100
+	maxBarcodeLength        = 20
+)
+
 type Books struct {
-	repo Repository
+	repo           Repository
+	allowClientIDs bool
 }
 
 func NewService(repo Repository) *Books {
-	return &Books{repo: repo}
+	return &Books{repo: repo, allowClientIDs: true}
 }
 
-func (u *Books) CreateBook(book *entity.Book) error {
-	_, err := u.repo.GetByID(book.ID)
-	if err != entity.ErrNotFound {
-		return entity.ErrConflict
-	}
+// AllowClientIDs controls whether Create still accepts an ID picked by the client.
+// It is on by default while clients migrate to server-generated IDs.
+func (u *Books) AllowClientIDs(allow bool) {
+	u.allowClientIDs = allow
+}
 
-	err = ValidateInput(book)
+func (u *Books) CreateBook(book *entity.Book) error {
+	err := u.CheckCreateBook(book)
 	if err != nil {
 		return err
 	}
 
 	book.CreatedAt = time.Now()
+	book.Version = 1
+	book.Available = book.Quantity
+	if book.Movement != nil {
+		book.Movement.Reason = entity.MovementPurchase
+	}
 	return u.repo.Create(book)
 }
 
+// CheckCreateBook normalizes the book and runs the checks of CreateBook, duplicate
+// lookups included, without storing anything; dry runs of imports use it.
+func (u *Books) CheckCreateBook(book *entity.Book) error {
+	if book.ID != 0 {
+		if !u.allowClientIDs {
+			return entity.ErrClientID
+		}
+
+		_, err := u.repo.GetByID(book.ID)
+		if err != entity.ErrNotFound {
+			return entity.ErrConflict
+		}
+	}
+
+	book.ISBN = isbn.Normalize(book.ISBN)
+	book.Branch = strings.TrimSpace(book.Branch)
+	book.Barcode = strings.TrimSpace(book.Barcode)
+	err := ValidateInput(book)
+	if err != nil {
+		return err
+	}
+
+	return u.checkISBNTaken(book)
+}
+
 func (u *Books) GetByIDBook(id int) (*entity.Book, error) {
 	return u.repo.GetByID(id)
 }
 
+func (u *Books) GetByISBNBook(code string) (*entity.Book, error) {
+	return u.repo.GetByISBN(isbn.Normalize(code))
+}
+
+func (u *Books) GetByBarcodeBook(barcode string) (*entity.Book, error) {
+	return u.repo.GetByBarcode(strings.TrimSpace(barcode))
+}
+
 func (u *Books) GetAllBooks() ([]*entity.Book, error) {
 	return u.repo.GetAll()
 }
 
+func (u *Books) GetBySeriesBook(seriesID int) ([]*entity.Book, error) {
+	return u.repo.GetBySeries(seriesID)
+}
+
+func (u *Books) GetByBranchBook(branch string) ([]*entity.Book, error) {
+	return u.repo.GetByBranch(branch)
+}
+
+// UpdateBook changes the total number of copies; Available follows it and is otherwise
+// only moved by CheckOutBook and CheckInBook.
 func (u *Books) UpdateBook(book *entity.Book) error {
-	_, err := u.repo.GetByID(book.ID)
+	err := u.CheckUpdateBook(book)
+	if err != nil {
+		return err
+	}
+
+	book.UpdatedAt = time.Now()
+	return u.repo.Update(book)
+}
+
+// CheckUpdateBook normalizes the book and runs the checks of UpdateBook without
+// storing anything; dry runs of imports use it.
+func (u *Books) CheckUpdateBook(book *entity.Book) error {
+	current, err := u.repo.GetByID(book.ID)
 	if err != nil {
 		return err
 	}
 
+	book.ISBN = isbn.Normalize(book.ISBN)
+	book.Branch = strings.TrimSpace(book.Branch)
+	book.Barcode = strings.TrimSpace(book.Barcode)
 	err = ValidateInput(book)
 	if err != nil {
 		return err
 	}
 
-	book.UpdatedAt = time.Now()
-	return u.repo.Update(book)
+	err = u.checkISBNTaken(book)
+	if err != nil {
+		return err
+	}
+
+	if book.Quantity < current.Quantity-current.Available {
+		return entity.ErrBelowOnLoan
+	}
+
+	return validateMovement(book.Movement, book.Quantity-current.Quantity)
+}
+
+// HasBorrowedBook tells whether the user has ever had a copy of the book, returned or not.
+func (u *Books) HasBorrowedBook(id, userID int) (bool, error) {
+	return u.repo.HasBorrowed(id, userID)
+}
+
+func (u *Books) CheckOutBook(id, userID int) error {
+	return u.repo.CheckOut(id, userID)
+}
+
+func (u *Books) CheckInBook(id, userID int) error {
+	return u.repo.CheckIn(id, userID)
+}
+
+func (u *Books) GetMovementsBook(id int) ([]*entity.Movement, error) {
+	return u.repo.GetMovements(id)
+}
+
+// ReconcileStock reports the books whose stock no longer matches their movement ledger.
+func (u *Books) ReconcileStock() ([]*entity.StockMismatch, error) {
+	return u.repo.Reconcile()
+}
+
+// DeleteBook refuses to delete a book that is still lent out unless mode says
+// whether the borrowed copies come back to stock or are written off.
+func (u *Books) DeleteBook(id, version int, mode string) error {
+	if mode != entity.DeleteRestrict && mode != entity.DeleteForceReturn && mode != entity.DeleteWriteOff {
+		return entity.ErrInvalidEntity
+	}
+
+	// the repository checks the loans in the delete's transaction
+	return u.repo.Delete(id, version, mode)
+}
+
+func (u *Books) RestoreBook(id int) (*entity.Book, error) {
+	err := u.repo.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(id)
+}
+
+// PurgeDeletedBooks removes books that have been soft-deleted for longer than retention.
+func (u *Books) PurgeDeletedBooks(retention time.Duration) (int, error) {
+	return u.repo.PurgeDeleted(time.Now().Add(-retention))
 }
 
-func (u *Books) DeleteBook(id int) error {
-	_, err := u.repo.GetByID(id)
+func (u *Books) checkISBNTaken(book *entity.Book) error {
+	if book.ISBN == "" {
+		return nil
+	}
+
+	other, err := u.repo.GetByISBN(book.ISBN)
+	if err == entity.ErrNotFound {
+		return nil
+	}
 	if err != nil {
 		return err
 	}
+	if other.ID != book.ID {
+		return entity.ErrConflict
+	}
+	return nil
+}
+
+// validateMovement checks the reason given for changing the quantity by delta;
+// loans and returns only go through CheckOutBook and CheckInBook.
+func validateMovement(m *entity.Movement, delta int) error {
+	if m == nil {
+		return nil
+	}
 
-	return u.repo.Delete(id)
+	switch m.Reason {
+	case "", entity.MovementCorrection:
+	case entity.MovementPurchase:
+		if delta <= 0 {
+			return entity.ErrInvalidEntity
+		}
+	case entity.MovementLoss:
+		if delta >= 0 {
+			return entity.ErrInvalidEntity
+		}
+	default:
+		return entity.ErrInvalidEntity
+	}
+	return nil
 }
 
 func ValidateInput(b *entity.Book) error {
-	if b.ID <= 0 || b.Tittle == "" || b.Author == "" || b.Pages <= 0 || b.Quantity <= 0 {
+	if b.ID < 0 || b.Tittle == "" || b.Author == "" || b.Pages <= 0 || b.Quantity <= 0 {
+		return entity.ErrInvalidEntity
+	}
+	if b.ISBN != "" && !isbn.Valid(b.ISBN) {
+		return entity.ErrInvalidEntity
+	}
+
+	// the bibliographic fields below are optional so that older clients keep working
+	if b.PublicationYear != 0 && (b.PublicationYear < earliestPublicationYear || b.PublicationYear > time.Now().Year()+1) {
+		return entity.ErrInvalidEntity
+	}
+	if b.Language != "" && !isLanguageCode(b.Language) {
+		return entity.ErrInvalidEntity
+	}
+	switch b.Format {
+	case "", entity.FormatHardcover, entity.FormatPaperback, entity.FormatEbook, entity.FormatAudiobook:
+	default:
+		return entity.ErrInvalidEntity
+	}
+	// a volume number only means something within a series
+	if b.SeriesID < 0 || b.Volume < 0 || (b.Volume > 0 && b.SeriesID == 0) {
+		return entity.ErrInvalidEntity
+	}
+	if len(b.Branch) > maxBranchLength || len(b.Barcode) > maxBarcodeLength || strings.ContainsAny(b.Barcode, " \t\n") {
 		return entity.ErrInvalidEntity
 	}
 	return nil
 }
+
+// isLanguageCode accepts ISO 639-1 and 639-2 codes, e.g. "en" or "ukr"
+func isLanguageCode(s string) bool {
+	if len(s) < 2 || len(s) > 3 {
+		return false
+	}
+	for _, r := range s {
+		if r < 'a' || r > 'z' {
+			return false
+		}
+	}
+	return true
+}
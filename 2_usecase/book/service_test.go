@@ -7,6 +7,7 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 type bookTest struct {
@@ -39,9 +40,11 @@ func TestCreateBook_Success(t *testing.T) {
 	b := NewService(m)
 
 	b1 := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}
+	b2 := &entity.Book{ID: 2, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2, Publisher: "Charles Scribner's Sons", PublicationYear: 1932, Edition: "1st", Language: "en", Description: "Sharecroppers in Georgia", Format: entity.FormatHardcover}
 
 	tests := []bookTest{
 		{book: b1, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: nil}},
+		{book: b2, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: nil}},
 	}
 
 	for _, bt := range tests {
@@ -62,10 +65,18 @@ func TestCreateBook_Error(t *testing.T) {
 
 	b1 := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}
 	b2 := &entity.Book{ID: 1, Tittle: "", Author: "", Pages: 300, Quantity: 3}
+	b3 := &entity.Book{ID: 3, Tittle: "Journeyman", Author: "Erskine Caldwell", Pages: 180, Quantity: 1, PublicationYear: 1066}
+	b4 := &entity.Book{ID: 4, Tittle: "Journeyman", Author: "Erskine Caldwell", Pages: 180, Quantity: 1, Language: "English"}
+	b5 := &entity.Book{ID: 5, Tittle: "Journeyman", Author: "Erskine Caldwell", Pages: 180, Quantity: 1, Format: "scroll"}
+	b6 := &entity.Book{ID: 6, Tittle: "Journeyman", Author: "Erskine Caldwell", Pages: 180, Quantity: 1, Volume: 2}
 
 	tests := []bookTest{
 		{book: b1, want: wantBook{book: b1, errFromGet: nil, errFromCreate: nil, errFinal: entity.ErrConflict}, t: timesToCall{ttcCreate: 0}},
 		{book: b2, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcCreate: 0}},
+		{book: b3, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcCreate: 0}},
+		{book: b4, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcCreate: 0}},
+		{book: b5, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcCreate: 0}},
+		{book: b6, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcCreate: 0}},
 		{book: b1, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromCreate: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcCreate: 1}},
 	}
 
@@ -78,6 +89,33 @@ func TestCreateBook_Error(t *testing.T) {
 	}
 }
 
+func TestCreateBook_GeneratedID(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	b1 := &entity.Book{Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}
+	m.EXPECT().GetByID(gomock.Any()).Times(0)
+	m.EXPECT().Create(b1).DoAndReturn(func(book *entity.Book) error {
+		book.ID = 7
+		return nil
+	})
+	assert.NoError(t, b.CreateBook(b1))
+	assert.Equal(t, 7, b1.ID)
+	assert.Equal(t, 1, b1.Version)
+
+	b.AllowClientIDs(false)
+	b2 := &entity.Book{ID: 8, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2}
+	assert.Equal(t, entity.ErrClientID, b.CreateBook(b2))
+
+	b3 := &entity.Book{ID: -1, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2}
+	b.AllowClientIDs(true)
+	m.EXPECT().GetByID(-1).Return(nil, entity.ErrNotFound)
+	assert.Equal(t, entity.ErrInvalidEntity, b.CreateBook(b3))
+}
+
 func TestGetByIDBook_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -122,6 +160,73 @@ func TestGetByIDBook_Error(t *testing.T) {
 	}
 }
 
+func TestCreateBook_ISBN(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	b1 := &entity.Book{ID: 1, ISBN: "978-0-14-044913-6", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	b2 := &entity.Book{ID: 2, ISBN: "978-0-14-044913-7", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	b3 := &entity.Book{ID: 3, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+
+	m.EXPECT().GetByID(1).Return(nil, entity.ErrNotFound)
+	m.EXPECT().GetByISBN("9780140449136").Return(nil, entity.ErrNotFound)
+	m.EXPECT().Create(b1).Return(nil)
+	assert.NoError(t, b.CreateBook(b1))
+	assert.Equal(t, "9780140449136", b1.ISBN)
+
+	m.EXPECT().GetByID(2).Return(nil, entity.ErrNotFound)
+	assert.Equal(t, entity.ErrInvalidEntity, b.CreateBook(b2))
+
+	m.EXPECT().GetByID(3).Return(nil, entity.ErrNotFound)
+	m.EXPECT().GetByISBN("9780140449136").Return(&entity.Book{ID: 1}, nil)
+	assert.Equal(t, entity.ErrConflict, b.CreateBook(b3))
+}
+
+func TestCheckCreateBook(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	// the same lookups as CreateBook, and no Create
+	b1 := &entity.Book{ID: 1, ISBN: "978-0-14-044913-6", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	m.EXPECT().GetByID(1).Return(nil, entity.ErrNotFound)
+	m.EXPECT().GetByISBN("9780140449136").Return(nil, entity.ErrNotFound)
+	assert.NoError(t, b.CheckCreateBook(b1))
+	assert.Equal(t, "9780140449136", b1.ISBN)
+
+	b2 := &entity.Book{ID: 2, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	m.EXPECT().GetByID(2).Return(&entity.Book{ID: 2}, nil)
+	assert.Equal(t, entity.ErrConflict, b.CheckCreateBook(b2))
+
+	b.AllowClientIDs(false)
+	assert.Equal(t, entity.ErrClientID, b.CheckCreateBook(b2))
+
+	b3 := &entity.Book{ID: 3, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	m.EXPECT().GetByID(3).Return(&entity.Book{ID: 3, Quantity: 3, Available: 3}, nil)
+	m.EXPECT().GetByISBN("9780140449136").Return(&entity.Book{ID: 1}, nil)
+	assert.Equal(t, entity.ErrConflict, b.CheckUpdateBook(b3))
+}
+
+func TestGetByISBNBook(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	b1 := &entity.Book{ID: 1, ISBN: "080442957X"}
+	m.EXPECT().GetByISBN("080442957X").Return(b1, nil)
+
+	bookGot, errGot := b.GetByISBNBook("0-8044-2957-x")
+	assert.NoError(t, errGot)
+	assert.Equal(t, b1, bookGot)
+}
+
 func TestGetAllBooks_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -202,6 +307,13 @@ func TestUpdateBook_Error(t *testing.T) {
 		{book: b1, want: wantBook{book: b1, errFromGet: nil, errFromUpdate: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcUpdate: 1}},
 	}
 
+	// 4 of the 5 copies are on loan
+	onLoan := &entity.Book{ID: 3, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 5, Available: 1}
+	tests = append(tests,
+		bookTest{book: &entity.Book{ID: 3, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 3}, want: wantBook{book: onLoan, errFromGet: nil, errFinal: entity.ErrBelowOnLoan}, t: timesToCall{ttcUpdate: 0}},
+		bookTest{book: &entity.Book{ID: 3, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 4}, want: wantBook{book: onLoan, errFromGet: nil, errFromUpdate: nil, errFinal: nil}, t: timesToCall{ttcUpdate: 1}},
+	)
+
 	for _, bt := range tests {
 		m.EXPECT().GetByID(bt.book.ID).Return(bt.want.book, bt.want.errFromGet)
 		m.EXPECT().Update(bt.book).Return(bt.want.errFromUpdate).Times(bt.t.ttcUpdate)
@@ -212,6 +324,70 @@ func TestUpdateBook_Error(t *testing.T) {
 	}
 }
 
+func TestUpdateBook_Movement(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	current := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, Available: 5}
+	tests := []struct {
+		reason    string
+		quantity  int
+		ttcUpdate int
+		errFinal  error
+	}{
+		{reason: entity.MovementPurchase, quantity: 7, ttcUpdate: 1, errFinal: nil},
+		{reason: entity.MovementPurchase, quantity: 4, ttcUpdate: 0, errFinal: entity.ErrInvalidEntity},
+		{reason: entity.MovementLoss, quantity: 4, ttcUpdate: 1, errFinal: nil},
+		{reason: entity.MovementLoss, quantity: 5, ttcUpdate: 0, errFinal: entity.ErrInvalidEntity},
+		{reason: entity.MovementCorrection, quantity: 6, ttcUpdate: 1, errFinal: nil},
+		{reason: "", quantity: 5, ttcUpdate: 1, errFinal: nil},
+		{reason: entity.MovementLoan, quantity: 4, ttcUpdate: 0, errFinal: entity.ErrInvalidEntity},
+	}
+
+	for _, tt := range tests {
+		book := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: tt.quantity, Movement: &entity.Movement{Reason: tt.reason}}
+		m.EXPECT().GetByID(1).Return(current, nil)
+		m.EXPECT().Update(book).Return(nil).Times(tt.ttcUpdate)
+
+		errGot := b.UpdateBook(book)
+		assert.Equal(t, tt.errFinal, errGot, tt.reason)
+	}
+}
+
+func TestCreateBook_Movement(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	book := &entity.Book{Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, Movement: &entity.Movement{Reason: entity.MovementLoss, Actor: "librarian"}}
+	m.EXPECT().Create(book).Return(nil)
+
+	assert.NoError(t, b.CreateBook(book))
+	assert.Equal(t, entity.MovementPurchase, book.Movement.Reason)
+	assert.Equal(t, 5, book.Available)
+}
+
+func TestCheckOutBook(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	m.EXPECT().CheckOut(1, 7).Return(nil)
+	m.EXPECT().CheckOut(2, 7).Return(entity.ErrNoCopiesAvailable)
+	m.EXPECT().CheckIn(1, 7).Return(nil)
+
+	assert.NoError(t, b.CheckOutBook(1, 7))
+	assert.Equal(t, entity.ErrNoCopiesAvailable, b.CheckOutBook(2, 7))
+	assert.NoError(t, b.CheckInBook(1, 7))
+}
+
 func TestDeleteBook_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -219,17 +395,16 @@ func TestDeleteBook_Success(t *testing.T) {
 	m := bmock.NewMockRepository(controller)
 	b := NewService(m)
 
-	b1 := &entity.Book{ID: 1}
+	b1 := &entity.Book{ID: 1, Version: 3}
 
 	tests := []bookTest{
 		{book: b1, want: wantBook{book: b1, errFromGet: nil, errFromDelete: nil, errFinal: nil}},
 	}
 
 	for _, bt := range tests {
-		m.EXPECT().GetByID(bt.book.ID).Return(bt.want.book, bt.want.errFromGet)
-		m.EXPECT().Delete(bt.book.ID).Return(bt.want.errFromDelete)
+		m.EXPECT().Delete(bt.book.ID, bt.book.Version, entity.DeleteRestrict).Return(bt.want.errFromDelete)
 
-		errGot := b.DeleteBook(bt.book.ID)
+		errGot := b.DeleteBook(bt.book.ID, bt.book.Version, entity.DeleteRestrict)
 		assert.Equal(t, bt.want.errFinal, errGot)
 	}
 }
@@ -244,15 +419,77 @@ func TestDeleteBook_Error(t *testing.T) {
 	b1 := &entity.Book{ID: 1}
 
 	tests := []bookTest{
-		{book: b1, want: wantBook{book: nil, errFromGet: entity.ErrNotFound, errFromDelete: nil, errFinal: entity.ErrNotFound}, t: timesToCall{ttcDelete: 0}},
-		{book: b1, want: wantBook{book: b1, errFromGet: nil, errFromDelete: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcDelete: 1}},
+		{book: b1, want: wantBook{errFromDelete: entity.ErrNotFound, errFinal: entity.ErrNotFound}, t: timesToCall{ttcDelete: 1}},
+		{book: b1, want: wantBook{errFromDelete: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcDelete: 1}},
+		{book: b1, want: wantBook{errFromDelete: entity.ErrVersionConflict, errFinal: entity.ErrVersionConflict}, t: timesToCall{ttcDelete: 1}},
 	}
 
 	for _, bt := range tests {
-		m.EXPECT().GetByID(bt.book.ID).Return(bt.want.book, bt.want.errFromGet)
-		m.EXPECT().Delete(bt.book.ID).Return(bt.want.errFromDelete).Times(bt.t.ttcDelete)
+		m.EXPECT().Delete(bt.book.ID, bt.book.Version, entity.DeleteRestrict).Return(bt.want.errFromDelete).Times(bt.t.ttcDelete)
 
-		errGot := b.DeleteBook(bt.book.ID)
+		errGot := b.DeleteBook(bt.book.ID, bt.book.Version, entity.DeleteRestrict)
 		assert.Equal(t, bt.want.errFinal, errGot)
 	}
 }
+
+func TestDeleteBook_OutstandingLoans(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	m.EXPECT().Delete(1, 2, entity.DeleteRestrict).Return(&entity.OutstandingLoansError{UserIDs: []int{4, 7}})
+
+	err := b.DeleteBook(1, 2, entity.DeleteRestrict)
+	assert.ErrorIs(t, err, entity.ErrOutstandingLoans)
+	var loansErr *entity.OutstandingLoansError
+	assert.ErrorAs(t, err, &loansErr)
+	assert.Equal(t, []int{4, 7}, loansErr.UserIDs)
+
+	m.EXPECT().Delete(1, 2, entity.DeleteForceReturn).Return(nil)
+	assert.NoError(t, b.DeleteBook(1, 2, entity.DeleteForceReturn))
+
+	m.EXPECT().Delete(1, 2, entity.DeleteWriteOff).Return(nil)
+	assert.NoError(t, b.DeleteBook(1, 2, entity.DeleteWriteOff))
+
+	assert.Equal(t, entity.ErrInvalidEntity, b.DeleteBook(1, 2, "burn"))
+}
+
+func TestRestoreBook(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	b1 := &entity.Book{ID: 1, Version: 3}
+	m.EXPECT().Restore(1).Return(nil)
+	m.EXPECT().GetByID(1).Return(b1, nil)
+	got, err := b.RestoreBook(1)
+	assert.NoError(t, err)
+	assert.Equal(t, b1, got)
+
+	m.EXPECT().Restore(2).Return(entity.ErrNotFound)
+	got, err = b.RestoreBook(2)
+	assert.Nil(t, got)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestPurgeDeletedBooks(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockRepository(controller)
+	b := NewService(m)
+
+	var before time.Time
+	m.EXPECT().PurgeDeleted(gomock.Any()).DoAndReturn(func(cutoff time.Time) (int, error) {
+		before = cutoff
+		return 2, nil
+	})
+	n, err := b.PurgeDeletedBooks(24 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, n)
+	assert.WithinDuration(t, time.Now().Add(-24*time.Hour), before, time.Minute)
+}
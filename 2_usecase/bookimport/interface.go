@@ -0,0 +1,10 @@
+package bookimport
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"io"
+)
+
+type UseCase interface {
+	Import(r io.Reader, format string, dryRun bool) (*entity.ImportReport, error)
+}
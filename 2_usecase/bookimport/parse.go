@@ -0,0 +1,164 @@
+package bookimport
+
+import (
+	"bufio"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"io"
+	"strconv"
+	"strings"
+)
+
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+type record struct {
+	row  int
+	book *entity.Book
+	err  error
+}
+
+// parseCSV expects a header row; column names follow the JSON field names
+// of entity.Book and are matched case-insensitively ("title" is accepted too).
+func parseCSV(r io.Reader) ([]*record, error) {
+	cr := csv.NewReader(r)
+	cr.FieldsPerRecord = -1
+	cr.TrimLeadingSpace = true
+
+	header, err := cr.Read()
+	if err != nil {
+		return nil, fmt.Errorf("reading csv header: %w", err)
+	}
+	columns := make([]string, len(header))
+	for i, h := range header {
+		columns[i] = strings.ToLower(strings.ReplaceAll(strings.TrimSpace(h), "_", ""))
+	}
+
+	var records []*record
+	for row := 2; ; row++ {
+		fields, err := cr.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			if _, ok := err.(*csv.ParseError); ok {
+				records = append(records, &record{row: row, err: err})
+				continue
+			}
+			return nil, err
+		}
+
+		rec := &record{row: row, book: &entity.Book{}}
+		for i, value := range fields {
+			if i >= len(columns) {
+				break
+			}
+			err = setField(rec.book, columns[i], strings.TrimSpace(value))
+			if err != nil {
+				rec.err = err
+				break
+			}
+		}
+		records = append(records, rec)
+	}
+	return records, nil
+}
+
+func parseJSONL(r io.Reader) ([]*record, error) {
+	var records []*record
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for row := 1; scanner.Scan(); row++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		var b entity.Book
+		err := json.Unmarshal([]byte(line), &b)
+		if err != nil {
+			records = append(records, &record{row: row, err: err})
+			continue
+		}
+		records = append(records, &record{row: row, book: &b})
+	}
+	return records, scanner.Err()
+}
+
+// parseMARC reads binary MARC21; a record that cannot be decoded ends the
+// stream because its length can no longer be trusted.
+func parseMARC(r io.Reader) ([]*record, error) {
+	var records []*record
+	mr := marc.NewReader(r)
+	for row := 1; ; row++ {
+		rec, err := mr.Read()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			records = append(records, &record{row: row, err: err})
+			return records, nil
+		}
+		records = append(records, &record{row: row, book: catalog.BookFromRecord(rec)})
+	}
+}
+
+func parseMARCXML(r io.Reader) ([]*record, error) {
+	marcRecords, err := marc.ReadXML(r)
+	if err != nil {
+		return nil, err
+	}
+
+	records := make([]*record, 0, len(marcRecords))
+	for i, rec := range marcRecords {
+		records = append(records, &record{row: i + 1, book: catalog.BookFromRecord(rec)})
+	}
+	return records, nil
+}
+
+func setField(b *entity.Book, column, value string) error {
+	var err error
+	switch column {
+	case "id":
+		b.ID, err = atoi(value)
+	case "isbn":
+		b.ISBN = value
+	case "tittle", "title":
+		b.Tittle = value
+	case "author":
+		b.Author = value
+	case "pages":
+		b.Pages, err = atoi(value)
+	case "quantity":
+		b.Quantity, err = atoi(value)
+	case "publisher":
+		b.Publisher = value
+	case "publicationyear", "year":
+		b.PublicationYear, err = atoi(value)
+	case "edition":
+		b.Edition = value
+	case "language":
+		b.Language = value
+	case "description":
+		b.Description = value
+	case "format":
+		b.Format = value
+	}
+	if err != nil {
+		return fmt.Errorf("column %s: %w", column, err)
+	}
+	return nil
+}
+
+func atoi(s string) (int, error) {
+	if s == "" {
+		return 0, nil
+	}
+	return strconv.Atoi(s)
+}
@@ -0,0 +1,127 @@
+package bookimport
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/isbn"
+	"io"
+)
+
+// importActor signs the inventory movements of imported rows.
+const importActor = "import"
+
+type Importer struct {
+	book book.UseCase
+}
+
+func NewService(b book.UseCase) *Importer {
+	return &Importer{book: b}
+}
+
+func (i *Importer) Import(r io.Reader, format string, dryRun bool) (*entity.ImportReport, error) {
+	var records []*record
+	var err error
+	switch format {
+	case FormatCSV:
+		records, err = parseCSV(r)
+	case FormatJSONL:
+		records, err = parseJSONL(r)
+	case catalog.FormatMARC:
+		records, err = parseMARC(r)
+	case catalog.FormatMARCXML:
+		records, err = parseMARCXML(r)
+	default:
+		return nil, fmt.Errorf("unsupported import format %q: %w", format, entity.ErrInvalidEntity)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	report := &entity.ImportReport{DryRun: dryRun, Rows: []*entity.ImportRow{}}
+	for _, rec := range records {
+		row := i.importRecord(rec, dryRun)
+		switch row.Status {
+		case entity.ImportCreated:
+			report.Created++
+		case entity.ImportUpdated:
+			report.Updated++
+		default:
+			report.Rejected++
+		}
+		report.Rows = append(report.Rows, row)
+	}
+	return report, nil
+}
+
+func (i *Importer) importRecord(rec *record, dryRun bool) *entity.ImportRow {
+	row := &entity.ImportRow{Row: rec.row, Status: entity.ImportRejected}
+	if rec.err != nil {
+		row.Error = rec.err.Error()
+		return row
+	}
+
+	b := rec.book
+	b.ISBN = isbn.Normalize(b.ISBN)
+	row.ID, row.ISBN = b.ID, b.ISBN
+
+	existing, err := i.findExisting(b)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	if existing != nil {
+		b.ID = existing.ID
+		b.CreatedAt = existing.CreatedAt
+		b.Version = existing.Version
+		row.ID = b.ID
+	}
+
+	err = book.ValidateInput(b)
+	if err != nil {
+		row.Error = err.Error()
+		return row
+	}
+	b.Movement = &entity.Movement{Actor: importActor, Reference: fmt.Sprintf("import row %d", rec.row)}
+
+	// a dry run makes the same checks, ISBN and client ID conflicts included, and only skips the write
+	switch {
+	case existing == nil && dryRun:
+		row.Status = entity.ImportCreated
+		err = i.book.CheckCreateBook(b)
+	case existing == nil:
+		row.Status = entity.ImportCreated
+		err = i.book.CreateBook(b)
+	case dryRun:
+		row.Status = entity.ImportUpdated
+		err = i.book.CheckUpdateBook(b)
+	default:
+		row.Status = entity.ImportUpdated
+		err = i.book.UpdateBook(b)
+	}
+	if err != nil {
+		row.Status = entity.ImportRejected
+		row.Error = err.Error()
+	}
+	row.ID = b.ID
+	return row
+}
+
+// findExisting matches a row to a stored book by ID first and by ISBN second.
+func (i *Importer) findExisting(b *entity.Book) (*entity.Book, error) {
+	if b.ID > 0 {
+		existing, err := i.book.GetByIDBook(b.ID)
+		if err != entity.ErrNotFound {
+			return existing, err
+		}
+	}
+
+	if b.ISBN != "" {
+		existing, err := i.book.GetByISBNBook(b.ISBN)
+		if err != entity.ErrNotFound {
+			return existing, err
+		}
+	}
+	return nil, nil
+}
@@ -0,0 +1,131 @@
+package bookimport
+
+import (
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+const csvInput = `id,isbn,title,author,pages,quantity,publication_year
+1,,God's Little Acre,Erskine Caldwell,224,5,1933
+,978-0-14-044913-6,The Odyssey,Homer,541,3,
+3,,,Nobody,10,1,
+4,,Journeyman,Erskine Caldwell,many,1,
+`
+
+func TestImport_CSV(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	i := NewService(m)
+
+	existing := &entity.Book{ID: 7, ISBN: "9780140449136", Tittle: "Odyssey", Author: "Homer", Pages: 500, Quantity: 1}
+
+	m.EXPECT().GetByIDBook(1).Return(nil, entity.ErrNotFound)
+	m.EXPECT().CreateBook(&entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, PublicationYear: 1933, Movement: &entity.Movement{Actor: "import", Reference: "import row 2"}}).Return(nil)
+	m.EXPECT().GetByISBNBook("9780140449136").Return(existing, nil)
+	m.EXPECT().UpdateBook(&entity.Book{ID: 7, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3, Movement: &entity.Movement{Actor: "import", Reference: "import row 3"}}).Return(nil)
+	m.EXPECT().GetByIDBook(3).Return(nil, entity.ErrNotFound)
+
+	reportGot, errGot := i.Import(strings.NewReader(csvInput), FormatCSV, false)
+	assert.NoError(t, errGot)
+
+	assert.Equal(t, 1, reportGot.Created)
+	assert.Equal(t, 1, reportGot.Updated)
+	assert.Equal(t, 2, reportGot.Rejected)
+	assert.Equal(t, &entity.ImportRow{Row: 2, ID: 1, Status: entity.ImportCreated}, reportGot.Rows[0])
+	assert.Equal(t, &entity.ImportRow{Row: 3, ID: 7, ISBN: "9780140449136", Status: entity.ImportUpdated}, reportGot.Rows[1])
+	assert.Equal(t, &entity.ImportRow{Row: 4, ID: 3, Status: entity.ImportRejected, Error: entity.ErrInvalidEntity.Error()}, reportGot.Rows[2])
+	assert.Equal(t, entity.ImportRejected, reportGot.Rows[3].Status)
+	assert.Contains(t, reportGot.Rows[3].Error, "pages")
+}
+
+func TestImport_JSONLDryRun(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	i := NewService(m)
+
+	input := `{"ID":1,"Tittle":"God's Little Acre","Author":"Erskine Caldwell","Pages":224,"Quantity":5}
+
+{"ID":2,"Tittle":"Tobacco Road","Author":"Erskine Caldwell","Pages":241,"Quantity":2}
+{not json}
+{"ID":5,"ISBN":"9780140449136","Tittle":"Journeyman","Author":"Erskine Caldwell","Pages":235,"Quantity":1}
+`
+	m.EXPECT().GetByIDBook(1).Return(nil, entity.ErrNotFound)
+	m.EXPECT().CheckCreateBook(gomock.Any()).Return(nil)
+	m.EXPECT().GetByIDBook(2).Return(&entity.Book{ID: 2}, nil)
+	m.EXPECT().CheckUpdateBook(gomock.Any()).Return(nil)
+	// the ISBN belongs to another book, which only the write would have found out before
+	m.EXPECT().GetByIDBook(5).Return(&entity.Book{ID: 5}, nil)
+	m.EXPECT().CheckUpdateBook(gomock.Any()).Return(entity.ErrConflict)
+
+	reportGot, errGot := i.Import(strings.NewReader(input), FormatJSONL, true)
+	assert.NoError(t, errGot)
+
+	assert.True(t, reportGot.DryRun)
+	assert.Equal(t, []*entity.ImportRow{
+		{Row: 1, ID: 1, Status: entity.ImportCreated},
+		{Row: 3, ID: 2, Status: entity.ImportUpdated},
+		{Row: 4, Status: entity.ImportRejected, Error: reportGot.Rows[2].Error},
+		{Row: 5, ID: 5, ISBN: "9780140449136", Status: entity.ImportRejected, Error: entity.ErrConflict.Error()},
+	}, reportGot.Rows)
+	assert.NotEmpty(t, reportGot.Rows[2].Error)
+}
+
+func TestImport_Error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	i := NewService(m)
+
+	_, errGot := i.Import(strings.NewReader(""), "xlsx", false)
+	assert.True(t, errors.Is(errGot, entity.ErrInvalidEntity))
+
+	_, errGot = i.Import(strings.NewReader(""), FormatCSV, false)
+	assert.Error(t, errGot)
+
+	someDBError := errors.New("some database error")
+	m.EXPECT().GetByIDBook(1).Return(nil, entity.ErrNotFound)
+	m.EXPECT().CreateBook(gomock.Any()).Return(someDBError)
+
+	reportGot, errGot := i.Import(strings.NewReader(`{"ID":1,"Tittle":"A","Author":"B","Pages":1,"Quantity":1}`), FormatJSONL, false)
+	assert.NoError(t, errGot)
+	assert.Equal(t, 1, reportGot.Rejected)
+	assert.Equal(t, someDBError.Error(), reportGot.Rows[0].Error)
+}
+
+func TestImport_MARCXML(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	i := NewService(m)
+
+	input := `<?xml version="1.0" encoding="UTF-8"?>
+<collection xmlns="http://www.loc.gov/MARC21/slim">
+  <record>
+    <leader>00000nam a2200000 i 4500</leader>
+    <datafield tag="020" ind1=" " ind2=" "><subfield code="a">9780140449136</subfield></datafield>
+    <datafield tag="100" ind1="0" ind2=" "><subfield code="a">Homer.</subfield></datafield>
+    <datafield tag="245" ind1="1" ind2="4"><subfield code="a">The Odyssey /</subfield></datafield>
+    <datafield tag="300" ind1=" " ind2=" "><subfield code="a">541 pages</subfield></datafield>
+  </record>
+</collection>`
+
+	existing := &entity.Book{ID: 7, ISBN: "9780140449136"}
+	m.EXPECT().GetByISBNBook("9780140449136").Return(existing, nil)
+	m.EXPECT().UpdateBook(&entity.Book{ID: 7, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 1, Movement: &entity.Movement{Actor: "import", Reference: "import row 1"}}).Return(nil)
+
+	reportGot, errGot := i.Import(strings.NewReader(input), catalog.FormatMARCXML, false)
+	assert.NoError(t, errGot)
+	assert.Equal(t, []*entity.ImportRow{{Row: 1, ID: 7, ISBN: "9780140449136", Status: entity.ImportUpdated}}, reportGot.Rows)
+}
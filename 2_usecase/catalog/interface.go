@@ -0,0 +1,7 @@
+package catalog
+
+import "io"
+
+type UseCase interface {
+	Export(w io.Writer, format string, ids []int) error
+}
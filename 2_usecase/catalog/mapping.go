@@ -0,0 +1,172 @@
+package catalog
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/isbn"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+// MARC21 has no bibliographic field for the number of copies we hold, so the
+// catalog keeps it in the local 999 $q.
+const quantityTag = "999"
+
+const exportLeader = "00000nam a2200000 i 4500"
+
+// controlNumberIdentifier goes into 003 on export; a 001 is only read back as a
+// book id when the record carries it, other libraries' control numbers mean nothing here.
+const controlNumberIdentifier = "CRUD3"
+
+var marcLanguages = map[string]string{
+	"en": "eng", "uk": "ukr", "fr": "fre", "de": "ger", "es": "spa", "it": "ita", "pl": "pol", "ru": "rus",
+}
+
+// BookFromRecord maps the fields the catalog cares about onto a book:
+// 001 id (see controlNumberIdentifier), 020 ISBN, 100/110/700 author, 245 title, 250 edition, 264/260 publisher
+// and year, 300 pages, 041/008 language, 520 description and 999 quantity.
+func BookFromRecord(rec *marc.Record) *entity.Book {
+	b := &entity.Book{Quantity: 1}
+
+	if rec.Control("003") == controlNumberIdentifier {
+		b.ID, _ = strconv.Atoi(strings.TrimSpace(rec.Control("001")))
+	}
+
+	if fields := strings.Fields(rec.Subfield('a', "020")); len(fields) > 0 {
+		b.ISBN = isbn.Normalize(fields[0])
+	}
+
+	b.Author = trimISBD(rec.Subfield('a', "100", "110", "700"))
+	b.Tittle = trimISBD(rec.Subfield('a', "245"))
+	if subtitle := trimISBD(rec.Subfield('b', "245")); subtitle != "" {
+		b.Tittle += ": " + subtitle
+	}
+	b.Edition = trimISBD(rec.Subfield('a', "250"))
+	b.Publisher = trimISBD(rec.Subfield('b', "264", "260"))
+	b.Description = rec.Subfield('a', "520")
+	b.Pages = firstNumber(rec.Subfield('a', "300"), 1)
+
+	b.PublicationYear = firstNumber(rec.Subfield('c', "264", "260"), 4)
+	fixed := rec.Control("008")
+	if b.PublicationYear == 0 && len(fixed) >= 11 {
+		b.PublicationYear, _ = strconv.Atoi(fixed[7:11])
+	}
+
+	b.Language = strings.ToLower(rec.Subfield('a', "041"))
+	if b.Language == "" && len(fixed) >= 38 && strings.TrimSpace(fixed[35:38]) != "" && fixed[35:38] != "|||" {
+		b.Language = fixed[35:38]
+	}
+
+	b.Format = formatOf(rec)
+
+	if q := rec.Subfield('q', quantityTag); q != "" {
+		b.Quantity, _ = strconv.Atoi(q)
+	}
+	return b
+}
+
+func RecordFromBook(b *entity.Book) *marc.Record {
+	leader := []byte(exportLeader)
+	if b.Format == entity.FormatAudiobook {
+		leader[6] = 'i'
+	}
+	rec := &marc.Record{Leader: string(leader)}
+
+	rec.AddControl("001", strconv.Itoa(b.ID))
+	rec.AddControl("003", controlNumberIdentifier)
+	if !b.UpdatedAt.IsZero() {
+		rec.AddControl("005", b.UpdatedAt.UTC().Format("20060102150405")+".0")
+	}
+	if b.Format == entity.FormatEbook {
+		rec.AddControl("007", "cr")
+	}
+	rec.AddControl("008", fixedField(b))
+
+	rec.AddData("020", ' ', ' ', "a", b.ISBN, "q", qualifierOf(b.Format))
+	rec.AddData("100", '1', ' ', "a", b.Author)
+	rec.AddData("245", '1', '0', "a", b.Tittle)
+	rec.AddData("250", ' ', ' ', "a", b.Edition)
+	year := ""
+	if b.PublicationYear != 0 {
+		year = strconv.Itoa(b.PublicationYear)
+	}
+	rec.AddData("264", ' ', '1', "b", b.Publisher, "c", year)
+	rec.AddData("300", ' ', ' ', "a", fmt.Sprintf("%d pages", b.Pages))
+	rec.AddData("520", ' ', ' ', "a", b.Description)
+	rec.AddData(quantityTag, ' ', ' ', "q", strconv.Itoa(b.Quantity))
+	return rec
+}
+
+// fixedField builds the 40 character 008 with the dates and language we know about.
+func fixedField(b *entity.Book) string {
+	entered := "      "
+	if !b.CreatedAt.IsZero() {
+		entered = b.CreatedAt.UTC().Format("060102")
+	}
+	year := "    "
+	if b.PublicationYear != 0 {
+		year = fmt.Sprintf("%04d", b.PublicationYear)
+	}
+	lang := b.Language
+	if code, ok := marcLanguages[lang]; ok {
+		lang = code
+	}
+	if len(lang) != 3 {
+		lang = "   "
+	}
+	return entered + "s" + year + "    " + "xx " + strings.Repeat(" ", 17) + lang + " d"
+}
+
+func formatOf(rec *marc.Record) string {
+	if len(rec.Leader) > 6 && rec.Leader[6] == 'i' {
+		return entity.FormatAudiobook
+	}
+	if strings.HasPrefix(rec.Control("007"), "cr") || strings.Contains(rec.Subfield('a', "300"), "online resource") {
+		return entity.FormatEbook
+	}
+
+	qualifier := strings.ToLower(rec.Subfield('q', "020") + " " + rec.Subfield('a', "020"))
+	switch {
+	case strings.Contains(qualifier, "pbk") || strings.Contains(qualifier, "paperback"):
+		return entity.FormatPaperback
+	case strings.Contains(qualifier, "hbk") || strings.Contains(qualifier, "hardcover") || strings.Contains(qualifier, "hardback"):
+		return entity.FormatHardcover
+	}
+	return ""
+}
+
+func qualifierOf(format string) string {
+	switch format {
+	case entity.FormatPaperback:
+		return "paperback"
+	case entity.FormatHardcover:
+		return "hardcover"
+	}
+	return ""
+}
+
+// trimISBD drops the trailing punctuation cataloguers put between subfields.
+func trimISBD(s string) string {
+	return strings.TrimRight(strings.TrimSpace(s), " /:;,.=")
+}
+
+// firstNumber returns the first run of at least minDigits digits in s.
+func firstNumber(s string, minDigits int) int {
+	start := -1
+	for i, r := range s + " " {
+		if unicode.IsDigit(r) {
+			if start < 0 {
+				start = i
+			}
+			continue
+		}
+		if start >= 0 && i-start >= minDigits {
+			n, _ := strconv.Atoi(s[start:i])
+			return n
+		}
+		start = -1
+	}
+	return 0
+}
@@ -0,0 +1,48 @@
+package catalog
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestBookFromRecord(t *testing.T) {
+	rec := &marc.Record{Leader: "01234cam a2200289 i 4500"}
+	rec.AddControl("001", "17")
+	rec.AddControl("008", "030513s2003    nyu           000 1 eng d")
+	rec.AddData("020", ' ', ' ', "a", "0140449132 (pbk.)")
+	rec.AddData("100", '0', ' ', "a", "Homer.")
+	rec.AddData("245", '1', '4', "a", "The Odyssey /", "c", "Homer ; translated by E.V. Rieu.")
+	rec.AddData("250", ' ', ' ', "a", "Rev. ed.")
+	rec.AddData("264", ' ', '1', "a", "London :", "b", "Penguin Books,", "c", "[2003]")
+	rec.AddData("300", ' ', ' ', "a", "lxii, 541 pages ;", "c", "20 cm")
+	rec.AddData("520", ' ', ' ', "a", "Odysseus sails home.")
+
+	want := &entity.Book{ISBN: "0140449132", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 1, Publisher: "Penguin Books", PublicationYear: 2003, Edition: "Rev. ed", Language: "eng", Description: "Odysseus sails home.", Format: entity.FormatPaperback}
+	assert.Equal(t, want, BookFromRecord(rec))
+
+	// older records carry the imprint in 260 and only the fixed field knows the year
+	old := &marc.Record{Leader: "00000nim a2200000 a 4500"}
+	old.AddControl("008", "870101s1932    xx            000 0 eng d")
+	old.AddData("110", '2', ' ', "a", "Caedmon Records.")
+	old.AddData("245", '1', '0', "a", "Tobacco road :", "b", "a novel.")
+	old.AddData("260", ' ', ' ', "b", "Scribner,")
+	old.AddData("999", ' ', ' ', "q", "4")
+
+	want = &entity.Book{Tittle: "Tobacco road: a novel", Author: "Caedmon Records", Quantity: 4, Publisher: "Scribner", PublicationYear: 1932, Language: "eng", Format: entity.FormatAudiobook}
+	assert.Equal(t, want, BookFromRecord(old))
+}
+
+func TestRecordFromBook_RoundTrip(t *testing.T) {
+	b := &entity.Book{ID: 3, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 6, Publisher: "Penguin Books", PublicationYear: 2003, Edition: "Rev. ed", Language: "en", Description: "Odysseus sails home.", Format: entity.FormatHardcover, CreatedAt: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}
+
+	rec := RecordFromBook(b)
+	assert.Equal(t, "230301s2003    xx                  eng d", rec.Control("008"))
+	assert.Len(t, rec.Control("008"), 40)
+
+	got := BookFromRecord(rec)
+	b.Language, b.CreatedAt = "eng", time.Time{}
+	assert.Equal(t, b, got)
+}
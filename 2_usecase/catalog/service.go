@@ -0,0 +1,64 @@
+package catalog
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"io"
+)
+
+const (
+	FormatMARC    = "marc"
+	FormatMARCXML = "marcxml"
+)
+
+type Catalog struct {
+	book book.UseCase
+}
+
+func NewService(b book.UseCase) *Catalog {
+	return &Catalog{book: b}
+}
+
+func (c *Catalog) Export(w io.Writer, format string, ids []int) error {
+	if format != FormatMARC && format != FormatMARCXML {
+		return fmt.Errorf("unsupported export format %q: %w", format, entity.ErrInvalidEntity)
+	}
+
+	var books []*entity.Book
+	if len(ids) == 0 {
+		var err error
+		books, err = c.book.GetAllBooks()
+		if err != nil {
+			return err
+		}
+	}
+	for _, id := range ids {
+		b, err := c.book.GetByIDBook(id)
+		if err != nil {
+			return err
+		}
+		books = append(books, b)
+	}
+
+	records := make([]*marc.Record, 0, len(books))
+	for _, b := range books {
+		records = append(records, RecordFromBook(b))
+	}
+
+	if format == FormatMARCXML {
+		return marc.WriteXML(w, records)
+	}
+	for _, rec := range records {
+		raw, err := marc.Marshal(rec)
+		if err != nil {
+			return fmt.Errorf("book %s: %w", rec.Control("001"), err)
+		}
+		_, err = w.Write(raw)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
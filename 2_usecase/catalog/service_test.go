@@ -0,0 +1,63 @@
+package catalog
+
+import (
+	"bytes"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestExport_Success(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	c := NewService(m)
+
+	books := []*entity.Book{{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}, {ID: 2, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2}}
+
+	m.EXPECT().GetAllBooks().Return(books, nil)
+	var buf bytes.Buffer
+	err := c.Export(&buf, FormatMARCXML, nil)
+	assert.NoError(t, err)
+
+	records, err := marc.ReadXML(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, records, 2)
+	assert.Equal(t, "Tobacco Road", records[1].Subfield('a', "245"))
+
+	m.EXPECT().GetByIDBook(2).Return(books[1], nil)
+	buf.Reset()
+	err = c.Export(&buf, FormatMARC, []int{2})
+	assert.NoError(t, err)
+
+	rec, err := marc.NewReader(&buf).Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "2", rec.Control("001"))
+}
+
+func TestExport_Error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	c := NewService(m)
+
+	var buf bytes.Buffer
+	err := c.Export(&buf, "pdf", nil)
+	assert.True(t, errors.Is(err, entity.ErrInvalidEntity))
+
+	m.EXPECT().GetByIDBook(9).Return(nil, entity.ErrNotFound)
+	err = c.Export(&buf, FormatMARCXML, []int{9})
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	someDBError := errors.New("some database error")
+	m.EXPECT().GetAllBooks().Return(nil, someDBError)
+	err = c.Export(&buf, FormatMARCXML, nil)
+	assert.Equal(t, someDBError, err)
+	assert.Zero(t, buf.Len())
+}
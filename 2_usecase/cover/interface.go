@@ -0,0 +1,17 @@
+package cover
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type BlobStore interface {
+	Put(key string, data []byte) error
+	Get(key string) ([]byte, time.Time, error)
+	Delete(key string) error
+}
+
+type UseCase interface {
+	UploadCover(bookID int, data []byte) error
+	GetCover(bookID int, size string) (*entity.Cover, error)
+}
@@ -0,0 +1,101 @@
+package cover
+
+import (
+	"bytes"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/imaging"
+	"image"
+	"image/jpeg"
+	"image/png"
+)
+
+const (
+	MaxSize      = 5 << 20
+	MaxDimension = 6000
+)
+
+var thumbnailWidths = map[string]int{
+	entity.CoverSizeMedium: 480,
+	entity.CoverSizeSmall:  160,
+}
+
+type Covers struct {
+	store BlobStore
+	book  book.UseCase
+}
+
+func NewService(store BlobStore, b book.UseCase) *Covers {
+	return &Covers{store: store, book: b}
+}
+
+func (c *Covers) UploadCover(bookID int, data []byte) error {
+	_, err := c.book.GetByIDBook(bookID)
+	if err != nil {
+		return err
+	}
+
+	if len(data) > MaxSize {
+		return entity.ErrImageTooLarge
+	}
+
+	// check the header first so that we never decode a huge bitmap
+	cfg, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil || (format != "jpeg" && format != "png") {
+		return entity.ErrUnsupportedImage
+	}
+	if cfg.Width > MaxDimension || cfg.Height > MaxDimension {
+		return entity.ErrImageTooLarge
+	}
+
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return entity.ErrUnsupportedImage
+	}
+
+	for size, width := range thumbnailWidths {
+		var buf bytes.Buffer
+		thumb := imaging.Thumbnail(img, width)
+		if format == "png" {
+			err = png.Encode(&buf, thumb)
+		} else {
+			err = jpeg.Encode(&buf, thumb, &jpeg.Options{Quality: 85})
+		}
+		if err != nil {
+			return err
+		}
+
+		err = c.store.Put(coverKey(bookID, size), buf.Bytes())
+		if err != nil {
+			return err
+		}
+	}
+
+	return c.store.Put(coverKey(bookID, entity.CoverSizeOriginal), data)
+}
+
+func (c *Covers) GetCover(bookID int, size string) (*entity.Cover, error) {
+	if size == "" {
+		size = entity.CoverSizeOriginal
+	}
+	if _, ok := thumbnailWidths[size]; !ok && size != entity.CoverSizeOriginal {
+		return nil, entity.ErrInvalidEntity
+	}
+
+	data, updatedAt, err := c.store.Get(coverKey(bookID, size))
+	if err != nil {
+		return nil, err
+	}
+
+	_, format, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, err
+	}
+
+	return &entity.Cover{BookID: bookID, Size: size, ContentType: "image/" + format, Data: data, UpdatedAt: updatedAt}, nil
+}
+
+func coverKey(bookID int, size string) string {
+	return fmt.Sprintf("covers/%d/%s", bookID, size)
+}
@@ -0,0 +1,112 @@
+package cover
+
+import (
+	"bytes"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	cmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/cover/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/jpeg"
+	"image/png"
+	"testing"
+	"time"
+)
+
+type coverTest struct {
+	data       []byte
+	errGetBook error
+	ttcPut     int
+	want       error
+}
+
+func testImage(t *testing.T, format string, width, height int) []byte {
+	var buf bytes.Buffer
+	img := image.NewRGBA(image.Rect(0, 0, width, height))
+	var err error
+	if format == "png" {
+		err = png.Encode(&buf, img)
+	} else {
+		err = jpeg.Encode(&buf, img, nil)
+	}
+	assert.NoError(t, err)
+	return buf.Bytes()
+}
+
+func TestUploadCover_Success(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := cmock.NewMockBlobStore(controller)
+	books := bmock.NewMockUseCase(controller)
+	c := NewService(store, books)
+
+	tests := []coverTest{
+		{data: testImage(t, "png", 800, 1200)},
+		{data: testImage(t, "jpeg", 100, 150)},
+	}
+
+	for _, ct := range tests {
+		books.EXPECT().GetByIDBook(1).Return(&entity.Book{ID: 1}, nil)
+		store.EXPECT().Put("covers/1/small", gomock.Any()).Return(nil)
+		store.EXPECT().Put("covers/1/medium", gomock.Any()).Return(nil)
+		store.EXPECT().Put("covers/1/original", ct.data).Return(nil)
+
+		errGot := c.UploadCover(1, ct.data)
+		assert.NoError(t, errGot)
+	}
+}
+
+func TestUploadCover_Error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := cmock.NewMockBlobStore(controller)
+	books := bmock.NewMockUseCase(controller)
+	c := NewService(store, books)
+
+	someStoreError := errors.New("some storage error")
+	tests := []coverTest{
+		{data: testImage(t, "png", 10, 10), errGetBook: entity.ErrNotFound, want: entity.ErrNotFound},
+		{data: make([]byte, MaxSize+1), want: entity.ErrImageTooLarge},
+		{data: []byte("GIF89a definitely not a cover"), want: entity.ErrUnsupportedImage},
+		{data: testImage(t, "png", MaxDimension+1, 1), want: entity.ErrImageTooLarge},
+		{data: testImage(t, "png", 10, 10), ttcPut: 1, want: someStoreError},
+	}
+
+	for _, ct := range tests {
+		books.EXPECT().GetByIDBook(1).Return(&entity.Book{ID: 1}, ct.errGetBook)
+		store.EXPECT().Put(gomock.Any(), gomock.Any()).Return(someStoreError).Times(ct.ttcPut)
+
+		errGot := c.UploadCover(1, ct.data)
+		assert.Equal(t, ct.want, errGot)
+	}
+}
+
+func TestGetCover(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	store := cmock.NewMockBlobStore(controller)
+	books := bmock.NewMockUseCase(controller)
+	c := NewService(store, books)
+
+	data := testImage(t, "png", 10, 10)
+	updatedAt := time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)
+
+	store.EXPECT().Get("covers/1/original").Return(data, updatedAt, nil)
+	coverGot, errGot := c.GetCover(1, "")
+	assert.NoError(t, errGot)
+	assert.Equal(t, &entity.Cover{BookID: 1, Size: entity.CoverSizeOriginal, ContentType: "image/png", Data: data, UpdatedAt: updatedAt}, coverGot)
+
+	store.EXPECT().Get("covers/1/small").Return(nil, time.Time{}, entity.ErrNotFound)
+	coverGot, errGot = c.GetCover(1, entity.CoverSizeSmall)
+	assert.Nil(t, coverGot)
+	assert.Equal(t, entity.ErrNotFound, errGot)
+
+	coverGot, errGot = c.GetCover(1, "huge")
+	assert.Nil(t, coverGot)
+	assert.Equal(t, entity.ErrInvalidEntity, errGot)
+}
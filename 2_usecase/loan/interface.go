@@ -1,6 +1,12 @@
 package loan
 
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+)
+
+// UseCase lends books on behalf of actor, the signed-in user: members only for
+// themselves, staff for anybody.
 type UseCase interface {
-	Borrow(userID, bookID int) error
-	Return(userID, bookID int) error
+	Borrow(actor *entity.User, userID, bookID int) error
+	Return(actor *entity.User, userID, bookID int) error
 }
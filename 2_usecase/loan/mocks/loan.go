@@ -7,6 +7,7 @@ package lmock
 import (
 	reflect "reflect"
 
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	gomock "github.com/golang/mock/gomock"
 )
 
@@ -34,29 +35,29 @@ func (m *MockUseCase) EXPECT() *MockUseCaseMockRecorder {
 }
 
 // Borrow mocks base method.
-func (m *MockUseCase) Borrow(userID, bookID int) error {
+func (m *MockUseCase) Borrow(actor *entity.User, userID, bookID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Borrow", userID, bookID)
+	ret := m.ctrl.Call(m, "Borrow", actor, userID, bookID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Borrow indicates an expected call of Borrow.
-func (mr *MockUseCaseMockRecorder) Borrow(userID, bookID interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Borrow(actor, userID, bookID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Borrow", reflect.TypeOf((*MockUseCase)(nil).Borrow), userID, bookID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Borrow", reflect.TypeOf((*MockUseCase)(nil).Borrow), actor, userID, bookID)
 }
 
 // Return mocks base method.
-func (m *MockUseCase) Return(userID, bookID int) error {
+func (m *MockUseCase) Return(actor *entity.User, userID, bookID int) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Return", userID, bookID)
+	ret := m.ctrl.Call(m, "Return", actor, userID, bookID)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Return indicates an expected call of Return.
-func (mr *MockUseCaseMockRecorder) Return(userID, bookID interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) Return(actor, userID, bookID interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Return", reflect.TypeOf((*MockUseCase)(nil).Return), userID, bookID)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Return", reflect.TypeOf((*MockUseCase)(nil).Return), actor, userID, bookID)
 }
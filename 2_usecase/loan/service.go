@@ -1,7 +1,6 @@
 package loan
 
 import (
-	"errors"
 	"fmt"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
@@ -17,7 +16,11 @@ func NewLoan(u user.UseCase, b book.UseCase) *Loan {
 	return &Loan{user: u, book: b}
 }
 
-func (l *Loan) Borrow(userID, bookID int) error {
+func (l *Loan) Borrow(actor *entity.User, userID, bookID int) error {
+	if actor == nil || !actor.CanActFor(userID) {
+		return entity.ErrForbidden
+	}
+
 	u, err := l.user.GetByIDUser(userID)
 	if err != nil {
 		if err == entity.ErrNotFound {
@@ -25,51 +28,37 @@ func (l *Loan) Borrow(userID, bookID int) error {
 		}
 		return err
 	}
-
-	b, err := l.book.GetByIDBook(bookID)
-	if err != nil {
-		if err == entity.ErrNotFound {
-			return fmt.Errorf("book %w", entity.ErrNotFound)
-		}
-		return err
-	}
-
-	if b.Quantity <= 0 {
-		return errors.New("not enough books")
+	if !u.IsVerified() {
+		return entity.ErrNotVerified
 	}
 
+	// the user's books say whether they already have it; the loan itself is
+	// recorded along with the copy leaving stock
 	err = u.AddBook(bookID)
 	if err != nil {
 		return err
 	}
 
-	err = l.user.UpdateUser(u)
-	if err != nil {
-		return err
-	}
-
-	b.Quantity--
-	err = l.book.UpdateBook(b)
+	err = l.book.CheckOutBook(bookID, userID)
 	if err != nil {
+		if err == entity.ErrNotFound {
+			return fmt.Errorf("book %w", entity.ErrNotFound)
+		}
 		return err
 	}
 
 	return nil
 }
 
-func (l *Loan) Return(userID, bookID int) error {
-	u, err := l.user.GetByIDUser(userID)
-	if err != nil {
-		if err == entity.ErrNotFound {
-			return fmt.Errorf("user %w", entity.ErrNotFound)
-		}
-		return err
+func (l *Loan) Return(actor *entity.User, userID, bookID int) error {
+	if actor == nil || !actor.CanActFor(userID) {
+		return entity.ErrForbidden
 	}
 
-	b, err := l.book.GetByIDBook(bookID)
+	u, err := l.user.GetByIDUser(userID)
 	if err != nil {
 		if err == entity.ErrNotFound {
-			return fmt.Errorf("book %w", entity.ErrNotFound)
+			return fmt.Errorf("user %w", entity.ErrNotFound)
 		}
 		return err
 	}
@@ -79,14 +68,11 @@ func (l *Loan) Return(userID, bookID int) error {
 		return err
 	}
 
-	err = l.user.UpdateUser(u)
-	if err != nil {
-		return err
-	}
-
-	b.Quantity++
-	err = l.book.UpdateBook(b)
+	err = l.book.CheckInBook(bookID, userID)
 	if err != nil {
+		if err == entity.ErrNotFound {
+			return fmt.Errorf("book %w", entity.ErrNotFound)
+		}
 		return err
 	}
 
@@ -9,34 +9,35 @@ import (
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
+	"time"
 )
 
 type loanTest struct {
-	user          *entity.User
-	book          *entity.Book
-	errGetUser    error
-	errGetBook    error
-	errUpdateUser error
-	errUpdateBook error
-	times         timesToCall
-	want          testWant
+	user        *entity.User
+	bookID      int
+	errGetUser  error
+	errCheckOut error
+	errCheckIn  error
+	times       timesToCall
+	want        testWant
 }
 
 type testWant struct {
 	user     *entity.User
-	book     *entity.Book
 	errFinal error
 }
 
 type timesToCall struct {
-	ttcGetUser    int
-	ttcGetBook    int
-	ttcUpdateUser int
-	ttcUpdateBook int
+	ttcCheckOut int
+	ttcCheckIn  int
 }
 
 var errUseCase = errors.New("some usecase error")
 
+var librarian = &entity.User{ID: 100, Role: entity.RoleLibrarian}
+
+var verifiedAt = time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+
 func TestBorrow_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -45,26 +46,18 @@ func TestBorrow_Success(t *testing.T) {
 	m2 := bmock.NewMockUseCase(controller)
 	l := NewLoan(m1, m2)
 
-	u1 := &entity.User{ID: 1}
-	b1 := &entity.Book{ID: 3, Quantity: 5}
-	u1Want := &entity.User{ID: 1, Books: []int{3}}
-	b1Want := &entity.Book{ID: 3, Quantity: 4}
-
 	tests := []loanTest{
-		{user: u1, book: b1, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, want: testWant{user: u1Want, book: b1Want, errFinal: nil}},
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, want: testWant{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt, Books: []int{3}}, errFinal: nil}},
 	}
 
 	for _, lt := range tests {
 		m1.EXPECT().GetByIDUser(lt.user.ID).Return(lt.user, lt.errGetUser)
-		m2.EXPECT().GetByIDBook(lt.book.ID).Return(lt.book, lt.errGetBook)
-		m1.EXPECT().UpdateUser(lt.user).Return(lt.errUpdateUser)
-		m2.EXPECT().UpdateBook(lt.book).Return(lt.errUpdateBook)
+		m2.EXPECT().CheckOutBook(lt.bookID, lt.user.ID).Return(lt.errCheckOut)
 
-		errGot := l.Borrow(lt.user.ID, lt.book.ID)
+		errGot := l.Borrow(librarian, lt.user.ID, lt.bookID)
 
 		assert.Equal(t, lt.want.errFinal, errGot)
 		assert.Equal(t, lt.want.user, lt.user)
-		assert.Equal(t, lt.want.book, lt.book)
 	}
 
 }
@@ -78,28 +71,41 @@ func TestBorrow_Error(t *testing.T) {
 	l := NewLoan(m1, m2)
 
 	tests := []loanTest{
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: errUseCase, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 0, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: errUseCase, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: nil, errUpdateUser: errUseCase, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 1, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: errUseCase, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 1, ttcUpdateBook: 1}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: entity.ErrNotFound, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 0, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: fmt.Errorf("user %w", entity.ErrNotFound)}},
-		{user: &entity.User{ID: 1}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: entity.ErrNotFound, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: fmt.Errorf("book %w", entity.ErrNotFound)}},
-		{user: &entity.User{ID: 2}, book: &entity.Book{ID: 4, Quantity: 0}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errors.New("not enough books")}},
-		{user: &entity.User{ID: 3, Books: []int{5}}, book: &entity.Book{ID: 5, Quantity: 14}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errors.New("book already borrowed")}},
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, errGetUser: errUseCase, times: timesToCall{ttcCheckOut: 0}, want: testWant{errFinal: errUseCase}},
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, errGetUser: entity.ErrNotFound, times: timesToCall{ttcCheckOut: 0}, want: testWant{errFinal: fmt.Errorf("user %w", entity.ErrNotFound)}},
+		{user: &entity.User{ID: 3, EmailVerifiedAt: &verifiedAt, Books: []int{5}}, bookID: 5, times: timesToCall{ttcCheckOut: 0}, want: testWant{errFinal: errors.New("book already borrowed")}},
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, errCheckOut: errUseCase, times: timesToCall{ttcCheckOut: 1}, want: testWant{errFinal: errUseCase}},
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, errCheckOut: entity.ErrNotFound, times: timesToCall{ttcCheckOut: 1}, want: testWant{errFinal: fmt.Errorf("book %w", entity.ErrNotFound)}},
+		{user: &entity.User{ID: 2, EmailVerifiedAt: &verifiedAt}, bookID: 4, errCheckOut: entity.ErrNoCopiesAvailable, times: timesToCall{ttcCheckOut: 1}, want: testWant{errFinal: entity.ErrNoCopiesAvailable}},
+		// the user was deleted after being looked up
+		{user: &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}, bookID: 3, errCheckOut: fmt.Errorf("user %w", entity.ErrNotFound), times: timesToCall{ttcCheckOut: 1}, want: testWant{errFinal: fmt.Errorf("user %w", entity.ErrNotFound)}},
 	}
 
-	for i, lt := range tests {
-		fmt.Println("****", i, "****")
+	for _, lt := range tests {
 		m1.EXPECT().GetByIDUser(lt.user.ID).Return(lt.user, lt.errGetUser)
-		m2.EXPECT().GetByIDBook(lt.book.ID).Return(lt.book, lt.errGetBook).Times(lt.times.ttcGetBook)
-		m1.EXPECT().UpdateUser(lt.user).Return(lt.errUpdateUser).Times(lt.times.ttcUpdateUser)
-		m2.EXPECT().UpdateBook(lt.book).Return(lt.errUpdateBook).Times(lt.times.ttcUpdateBook)
+		m2.EXPECT().CheckOutBook(lt.bookID, lt.user.ID).Return(lt.errCheckOut).Times(lt.times.ttcCheckOut)
 
-		errGot := l.Borrow(lt.user.ID, lt.book.ID)
+		errGot := l.Borrow(librarian, lt.user.ID, lt.bookID)
 		assert.Equal(t, lt.want.errFinal, errGot)
 	}
 }
 
+func TestBorrow_NotVerified(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m1 := umock.NewMockUseCase(controller)
+	m2 := bmock.NewMockUseCase(controller)
+	l := NewLoan(m1, m2)
+
+	u := &entity.User{ID: 1}
+	m1.EXPECT().GetByIDUser(1).Return(u, nil)
+
+	errGot := l.Borrow(librarian, 1, 3)
+	assert.Equal(t, entity.ErrNotVerified, errGot)
+	assert.Empty(t, u.Books)
+}
+
 func TestReturn_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -109,19 +115,16 @@ func TestReturn_Success(t *testing.T) {
 	l := NewLoan(m1, m2)
 
 	tests := []loanTest{
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, want: testWant{user: &entity.User{ID: 1, Books: []int{}}, book: &entity.Book{ID: 3, Quantity: 6}, errFinal: nil}},
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, want: testWant{user: &entity.User{ID: 1, Books: []int{}}, errFinal: nil}},
 	}
 
 	for _, lt := range tests {
 		m1.EXPECT().GetByIDUser(lt.user.ID).Return(lt.user, lt.errGetUser)
-		m2.EXPECT().GetByIDBook(lt.book.ID).Return(lt.book, lt.errGetBook)
-		m1.EXPECT().UpdateUser(lt.user).Return(lt.errUpdateUser)
-		m2.EXPECT().UpdateBook(lt.book).Return(lt.errUpdateBook)
+		m2.EXPECT().CheckInBook(lt.bookID, lt.user.ID).Return(lt.errCheckIn)
 
-		errGot := l.Return(lt.user.ID, lt.book.ID)
+		errGot := l.Return(librarian, lt.user.ID, lt.bookID)
 		assert.Equal(t, lt.want.errFinal, errGot)
 		assert.Equal(t, lt.want.user, lt.user)
-		assert.Equal(t, lt.want.book, lt.book)
 	}
 }
 
@@ -134,23 +137,40 @@ func TestReturn_Error(t *testing.T) {
 	l := NewLoan(m1, m2)
 
 	tests := []loanTest{
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: errUseCase, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 0, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: errUseCase, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: nil, errUpdateUser: errUseCase, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 1, ttcUpdateBook: 0}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: errUseCase, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 1, ttcUpdateBook: 1}, want: testWant{errFinal: errUseCase}},
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: entity.ErrNotFound, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 0, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: fmt.Errorf("user %w", entity.ErrNotFound)}},
-		{user: &entity.User{ID: 1, Books: []int{3}}, book: &entity.Book{ID: 3, Quantity: 5}, errGetUser: nil, errGetBook: entity.ErrNotFound, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: fmt.Errorf("book %w", entity.ErrNotFound)}},
-		{user: &entity.User{ID: 3, Books: []int{}}, book: &entity.Book{ID: 5, Quantity: 14}, errGetUser: nil, errGetBook: nil, errUpdateUser: nil, errUpdateBook: nil, times: timesToCall{ttcGetBook: 1, ttcUpdateUser: 0, ttcUpdateBook: 0}, want: testWant{errFinal: errors.New("book was never borrowed")}},
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, errGetUser: errUseCase, times: timesToCall{ttcCheckIn: 0}, want: testWant{errFinal: errUseCase}},
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, errGetUser: entity.ErrNotFound, times: timesToCall{ttcCheckIn: 0}, want: testWant{errFinal: fmt.Errorf("user %w", entity.ErrNotFound)}},
+		{user: &entity.User{ID: 3, Books: []int{}}, bookID: 5, times: timesToCall{ttcCheckIn: 0}, want: testWant{errFinal: errors.New("book was never borrowed")}},
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, errCheckIn: errUseCase, times: timesToCall{ttcCheckIn: 1}, want: testWant{errFinal: errUseCase}},
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, errCheckIn: entity.ErrNotFound, times: timesToCall{ttcCheckIn: 1}, want: testWant{errFinal: fmt.Errorf("book %w", entity.ErrNotFound)}},
+		// returned by another request meanwhile
+		{user: &entity.User{ID: 1, Books: []int{3}}, bookID: 3, errCheckIn: entity.ErrNotBorrowed, times: timesToCall{ttcCheckIn: 1}, want: testWant{errFinal: entity.ErrNotBorrowed}},
 	}
 
-	for i, lt := range tests {
-		fmt.Println("****", i, "****")
+	for _, lt := range tests {
 		m1.EXPECT().GetByIDUser(lt.user.ID).Return(lt.user, lt.errGetUser)
-		m2.EXPECT().GetByIDBook(lt.book.ID).Return(lt.book, lt.errGetBook).Times(lt.times.ttcGetBook)
-		m1.EXPECT().UpdateUser(lt.user).Return(lt.errUpdateUser).Times(lt.times.ttcUpdateUser)
-		m2.EXPECT().UpdateBook(lt.book).Return(lt.errUpdateBook).Times(lt.times.ttcUpdateBook)
+		m2.EXPECT().CheckInBook(lt.bookID, lt.user.ID).Return(lt.errCheckIn).Times(lt.times.ttcCheckIn)
 
-		errGot := l.Return(lt.user.ID, lt.book.ID)
+		errGot := l.Return(librarian, lt.user.ID, lt.bookID)
 		assert.Equal(t, lt.want.errFinal, errGot)
 	}
 }
+
+func TestBorrow_Forbidden(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m1 := umock.NewMockUseCase(controller)
+	m2 := bmock.NewMockUseCase(controller)
+	l := NewLoan(m1, m2)
+
+	member := &entity.User{ID: 1, Role: entity.RoleMember}
+	assert.Equal(t, entity.ErrForbidden, l.Borrow(member, 2, 1))
+	assert.Equal(t, entity.ErrForbidden, l.Return(member, 2, 1))
+	assert.Equal(t, entity.ErrForbidden, l.Borrow(nil, 1, 1))
+
+	// members borrow for themselves
+	u := &entity.User{ID: 1, EmailVerifiedAt: &verifiedAt}
+	m1.EXPECT().GetByIDUser(1).Return(u, nil)
+	m2.EXPECT().CheckOutBook(5, 1).Return(nil)
+	assert.NoError(t, l.Borrow(member, 1, 5))
+}
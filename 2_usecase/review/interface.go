@@ -0,0 +1,24 @@
+package review
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type Repository interface {
+	Create(r *entity.Review) error
+	GetByID(id int) (*entity.Review, error)
+	GetByBook(bookID int, status string) ([]*entity.Review, error)
+	GetAll(status string) ([]*entity.Review, error)
+	SetStatus(id int, status, moderator string, at time.Time) error
+	Delete(id int) error
+}
+
+type UseCase interface {
+	CreateReview(r *entity.Review) error
+	GetByIDReview(id int) (*entity.Review, error)
+	GetByBookReviews(bookID int) ([]*entity.Review, error)
+	GetAllReviews(status string) ([]*entity.Review, error)
+	ModerateReview(id int, status, moderator string) (*entity.Review, error)
+	DeleteReview(id int) error
+}
@@ -0,0 +1,92 @@
+package review
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"strings"
+	"time"
+)
+
+const maxReviewLength = 5000
+
+type Reviews struct {
+	repo Repository
+	book book.UseCase
+}
+
+func NewService(repo Repository, b book.UseCase) *Reviews {
+	return &Reviews{repo: repo, book: b}
+}
+
+// CreateReview publishes a rating, and optionally a text, from a user who has borrowed the book.
+func (s *Reviews) CreateReview(r *entity.Review) error {
+	r.Text = strings.TrimSpace(r.Text)
+	if r.UserID <= 0 || r.Rating < 1 || r.Rating > 5 || len([]rune(r.Text)) > maxReviewLength {
+		return entity.ErrInvalidEntity
+	}
+
+	_, err := s.book.GetByIDBook(r.BookID)
+	if err != nil {
+		return err
+	}
+
+	borrowed, err := s.book.HasBorrowedBook(r.BookID, r.UserID)
+	if err != nil {
+		return err
+	}
+	if !borrowed {
+		return entity.ErrNotBorrowed
+	}
+
+	r.ID = 0
+	r.Status = entity.ReviewPublished
+	r.ModeratedBy = ""
+	r.CreatedAt = time.Now()
+	r.UpdatedAt = r.CreatedAt
+	return s.repo.Create(r)
+}
+
+func (s *Reviews) GetByIDReview(id int) (*entity.Review, error) {
+	return s.repo.GetByID(id)
+}
+
+// GetByBookReviews lists what patrons see: the published reviews, newest first.
+func (s *Reviews) GetByBookReviews(bookID int) ([]*entity.Review, error) {
+	_, err := s.book.GetByIDBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByBook(bookID, entity.ReviewPublished)
+}
+
+// GetAllReviews is the moderation queue; an empty status lists every review.
+func (s *Reviews) GetAllReviews(status string) ([]*entity.Review, error) {
+	if status != "" && !validStatus(status) {
+		return nil, entity.ErrInvalidEntity
+	}
+
+	return s.repo.GetAll(status)
+}
+
+// ModerateReview hides a review from the book's page or publishes it again.
+func (s *Reviews) ModerateReview(id int, status, moderator string) (*entity.Review, error) {
+	if !validStatus(status) {
+		return nil, entity.ErrInvalidEntity
+	}
+
+	err := s.repo.SetStatus(id, status, moderator, time.Now())
+	if err != nil {
+		return nil, err
+	}
+
+	return s.repo.GetByID(id)
+}
+
+func (s *Reviews) DeleteReview(id int) error {
+	return s.repo.Delete(id)
+}
+
+func validStatus(status string) bool {
+	return status == entity.ReviewPublished || status == entity.ReviewHidden
+}
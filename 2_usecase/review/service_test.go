@@ -0,0 +1,96 @@
+package review
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	rmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/review/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestCreateReview(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	rv := &entity.Review{ID: 9, BookID: 1, UserID: 2, Rating: 4, Text: " Slow start ", Status: entity.ReviewHidden}
+	b.EXPECT().GetByIDBook(1).Return(&entity.Book{ID: 1}, nil)
+	b.EXPECT().HasBorrowedBook(1, 2).Return(true, nil)
+	m.EXPECT().Create(rv).Return(nil)
+	assert.NoError(t, s.CreateReview(rv))
+	assert.Equal(t, 0, rv.ID)
+	assert.Equal(t, "Slow start", rv.Text)
+	assert.Equal(t, entity.ReviewPublished, rv.Status)
+	assert.False(t, rv.CreatedAt.IsZero())
+
+	b.EXPECT().GetByIDBook(1).Return(&entity.Book{ID: 1}, nil)
+	b.EXPECT().HasBorrowedBook(1, 3).Return(false, nil)
+	assert.Equal(t, entity.ErrNotBorrowed, s.CreateReview(&entity.Review{BookID: 1, UserID: 3, Rating: 5}))
+
+	b.EXPECT().GetByIDBook(7).Return(nil, entity.ErrNotFound)
+	assert.Equal(t, entity.ErrNotFound, s.CreateReview(&entity.Review{BookID: 7, UserID: 2, Rating: 5}))
+
+	invalid := []*entity.Review{
+		{BookID: 1, UserID: 2, Rating: 0},
+		{BookID: 1, UserID: 2, Rating: 6},
+		{BookID: 1, Rating: 3},
+		{BookID: 1, UserID: 2, Rating: 3, Text: strings.Repeat("a", maxReviewLength+1)},
+	}
+	for _, rv := range invalid {
+		assert.Equal(t, entity.ErrInvalidEntity, s.CreateReview(rv))
+	}
+}
+
+func TestGetByBookReviews(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	reviews := []*entity.Review{{ID: 2, BookID: 1, Rating: 5}, {ID: 1, BookID: 1, Rating: 3}}
+	b.EXPECT().GetByIDBook(1).Return(&entity.Book{ID: 1}, nil)
+	m.EXPECT().GetByBook(1, entity.ReviewPublished).Return(reviews, nil)
+	reviewsGot, err := s.GetByBookReviews(1)
+	assert.NoError(t, err)
+	assert.Equal(t, reviews, reviewsGot)
+
+	b.EXPECT().GetByIDBook(2).Return(nil, entity.ErrNotFound)
+	_, err = s.GetByBookReviews(2)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestModerateReview(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	hidden := &entity.Review{ID: 1, Status: entity.ReviewHidden, ModeratedBy: "librarian"}
+	m.EXPECT().SetStatus(1, entity.ReviewHidden, "librarian", gomock.Any()).Return(nil)
+	m.EXPECT().GetByID(1).Return(hidden, nil)
+	reviewGot, err := s.ModerateReview(1, entity.ReviewHidden, "librarian")
+	assert.NoError(t, err)
+	assert.Equal(t, hidden, reviewGot)
+
+	m.EXPECT().SetStatus(2, entity.ReviewPublished, "librarian", gomock.Any()).Return(entity.ErrNotFound)
+	_, err = s.ModerateReview(2, entity.ReviewPublished, "librarian")
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	_, err = s.ModerateReview(1, "deleted", "librarian")
+	assert.Equal(t, entity.ErrInvalidEntity, err)
+
+	_, err = s.GetAllReviews("deleted")
+	assert.Equal(t, entity.ErrInvalidEntity, err)
+	m.EXPECT().GetAll("").Return([]*entity.Review{}, nil)
+	_, err = s.GetAllReviews("")
+	assert.NoError(t, err)
+}
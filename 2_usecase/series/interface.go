@@ -0,0 +1,19 @@
+package series
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+)
+
+type Repository interface {
+	Create(s *entity.Series) error
+	GetByID(id int) (*entity.Series, error)
+	GetAll() ([]*entity.Series, error)
+}
+
+type UseCase interface {
+	CreateSeries(s *entity.Series) error
+	GetByIDSeries(id int) (*entity.Series, error)
+	GetAllSeries() ([]*entity.Series, error)
+	GetBooksSeries(id int) ([]*entity.Book, error)
+	NextInSeries(bookID int) (*entity.Book, error)
+}
@@ -0,0 +1,69 @@
+package series
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"strings"
+	"time"
+)
+
+type Series struct {
+	repo Repository
+	book book.UseCase
+}
+
+func NewService(repo Repository, b book.UseCase) *Series {
+	return &Series{repo: repo, book: b}
+}
+
+func (s *Series) CreateSeries(series *entity.Series) error {
+	series.Name = strings.TrimSpace(series.Name)
+	if series.Name == "" {
+		return entity.ErrInvalidEntity
+	}
+
+	series.ID = 0
+	series.CreatedAt = time.Now()
+	return s.repo.Create(series)
+}
+
+func (s *Series) GetByIDSeries(id int) (*entity.Series, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *Series) GetAllSeries() ([]*entity.Series, error) {
+	return s.repo.GetAll()
+}
+
+// GetBooksSeries lists the books of a series by volume, unnumbered ones last.
+func (s *Series) GetBooksSeries(id int) ([]*entity.Book, error) {
+	_, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.book.GetBySeriesBook(id)
+}
+
+// NextInSeries finds the lowest volume after the book's own. The book comes with
+// its Quantity and Available copies, which is what patrons ask about.
+func (s *Series) NextInSeries(bookID int) (*entity.Book, error) {
+	b, err := s.book.GetByIDBook(bookID)
+	if err != nil {
+		return nil, err
+	}
+	if b.SeriesID == 0 || b.Volume == 0 {
+		return nil, entity.ErrNotInSeries
+	}
+
+	books, err := s.book.GetBySeriesBook(b.SeriesID)
+	if err != nil {
+		return nil, err
+	}
+	for _, next := range books {
+		if next.Volume > b.Volume {
+			return next, nil
+		}
+	}
+	return nil, entity.ErrNotFound
+}
@@ -0,0 +1,87 @@
+package series
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	sermock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/series/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestCreateSeries(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	series := &entity.Series{ID: 4, Name: " The Expanse "}
+	m.EXPECT().Create(series).Return(nil)
+	assert.NoError(t, s.CreateSeries(series))
+	assert.Equal(t, "The Expanse", series.Name)
+	assert.Equal(t, 0, series.ID)
+	assert.False(t, series.CreatedAt.IsZero())
+
+	assert.Equal(t, entity.ErrInvalidEntity, s.CreateSeries(&entity.Series{Name: ""}))
+}
+
+func TestGetBooksSeries(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	books := []*entity.Book{{ID: 3, SeriesID: 1, Volume: 1}, {ID: 2, SeriesID: 1, Volume: 2}}
+	m.EXPECT().GetByID(1).Return(&entity.Series{ID: 1, Name: "The Expanse"}, nil)
+	b.EXPECT().GetBySeriesBook(1).Return(books, nil)
+	booksGot, err := s.GetBooksSeries(1)
+	assert.NoError(t, err)
+	assert.Equal(t, books, booksGot)
+
+	m.EXPECT().GetByID(2).Return(nil, entity.ErrNotFound)
+	_, err = s.GetBooksSeries(2)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestNextInSeries(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	books := []*entity.Book{
+		{ID: 3, SeriesID: 1, Volume: 1},
+		{ID: 5, SeriesID: 1, Volume: 3, Quantity: 2, Available: 0},
+		{ID: 2, SeriesID: 1, Volume: 4},
+		{ID: 9, SeriesID: 1},
+	}
+	b.EXPECT().GetBySeriesBook(1).Return(books, nil).Times(2)
+
+	tests := []struct {
+		book     *entity.Book
+		want     *entity.Book
+		errFinal error
+	}{
+		{book: books[0], want: books[1], errFinal: nil},
+		{book: books[2], want: nil, errFinal: entity.ErrNotFound},
+		{book: books[3], want: nil, errFinal: entity.ErrNotInSeries},
+		{book: &entity.Book{ID: 7}, want: nil, errFinal: entity.ErrNotInSeries},
+	}
+	for _, tt := range tests {
+		b.EXPECT().GetByIDBook(tt.book.ID).Return(tt.book, nil)
+
+		nextGot, err := s.NextInSeries(tt.book.ID)
+		assert.Equal(t, tt.errFinal, err)
+		assert.Equal(t, tt.want, nextGot)
+	}
+
+	b.EXPECT().GetByIDBook(8).Return(nil, entity.ErrNotFound)
+	_, err := s.NextInSeries(8)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
@@ -0,0 +1,21 @@
+package stocktake
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+)
+
+type Repository interface {
+	Create(s *entity.Stocktake) error
+	GetByID(id int) (*entity.Stocktake, error)
+	AddCounts(id int, counts []*entity.StocktakeCount) error
+	GetCounts(id int) ([]*entity.StocktakeCount, error)
+	Close(id int, corrections []*entity.StockCorrection) error
+}
+
+type UseCase interface {
+	OpenStocktake(s *entity.Stocktake) error
+	GetStocktake(id int) (*entity.Stocktake, error)
+	SubmitCounts(id int, sub *entity.StocktakeSubmission) error
+	Report(id int) (*entity.StocktakeReport, error)
+	CloseStocktake(id int, apply bool, actor string) (*entity.StocktakeReport, error)
+}
@@ -0,0 +1,230 @@
+package stocktake
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/isbn"
+	"sort"
+	"strings"
+	"time"
+)
+
+const maxBarcodeLength = 20
+
+type Stocktakes struct {
+	repo Repository
+	book book.UseCase
+}
+
+func NewService(repo Repository, b book.UseCase) *Stocktakes {
+	return &Stocktakes{repo: repo, book: b}
+}
+
+func (s *Stocktakes) OpenStocktake(st *entity.Stocktake) error {
+	st.Branch = strings.TrimSpace(st.Branch)
+	if st.Branch == "" {
+		return entity.ErrInvalidEntity
+	}
+
+	st.ID = 0
+	st.Status = entity.StocktakeOpen
+	st.OpenedAt = time.Now()
+	st.ClosedAt = nil
+	return s.repo.Create(st)
+}
+
+func (s *Stocktakes) GetStocktake(id int) (*entity.Stocktake, error) {
+	return s.repo.GetByID(id)
+}
+
+func (s *Stocktakes) SubmitCounts(id int, sub *entity.StocktakeSubmission) error {
+	st, err := s.repo.GetByID(id)
+	if err != nil {
+		return err
+	}
+	if st.Status != entity.StocktakeOpen {
+		return entity.ErrStocktakeClosed
+	}
+
+	var counts []*entity.StocktakeCount
+	for _, barcode := range sub.Barcodes {
+		code := strings.TrimSpace(barcode)
+		if code == "" || len(code) > maxBarcodeLength {
+			return entity.ErrInvalidEntity
+		}
+
+		b, err := s.scanned(code)
+		if err == entity.ErrNotFound {
+			counts = append(counts, &entity.StocktakeCount{Barcode: code, Counted: 1})
+			continue
+		}
+		if err != nil {
+			return err
+		}
+		counts = append(counts, &entity.StocktakeCount{BookID: b.ID, Counted: 1})
+	}
+	for _, c := range sub.Counts {
+		if c.BookID <= 0 || c.Counted < 0 {
+			return entity.ErrInvalidEntity
+		}
+		counts = append(counts, &entity.StocktakeCount{BookID: c.BookID, Counted: c.Counted})
+	}
+	if len(counts) == 0 {
+		return entity.ErrInvalidEntity
+	}
+
+	return s.repo.AddCounts(id, counts)
+}
+
+// scanned finds the book of a barcode read off a copy: the library label, or the
+// ISBN printed on a copy that was never labelled.
+func (s *Stocktakes) scanned(code string) (*entity.Book, error) {
+	b, err := s.book.GetByBarcodeBook(code)
+	if err != entity.ErrNotFound {
+		return b, err
+	}
+	if !isbn.Valid(isbn.Normalize(code)) {
+		return nil, entity.ErrNotFound
+	}
+	return s.book.GetByISBNBook(code)
+}
+
+// Report compares the counts so far with the stock; it works on open sessions too.
+func (s *Stocktakes) Report(id int) (*entity.StocktakeReport, error) {
+	st, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+
+	report, _, err := s.report(st)
+	return report, err
+}
+
+// CloseStocktake closes the session and, when apply is set, writes the counted
+// copies of the branch's books to the inventory ledger. The repository does both
+// in one transaction, so a failure leaves the stock and the session as they were;
+// a book changed since the report fails the close with ErrVersionConflict.
+func (s *Stocktakes) CloseStocktake(id int, apply bool, actor string) (*entity.StocktakeReport, error) {
+	st, err := s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	if st.Status != entity.StocktakeOpen {
+		return nil, entity.ErrStocktakeClosed
+	}
+
+	report, books, err := s.report(st)
+	if err != nil {
+		return nil, err
+	}
+
+	var corrections []*entity.StockCorrection
+	if apply {
+		corrections = correct(st, report, books, actor)
+	}
+	err = s.repo.Close(id, corrections)
+	if err != nil {
+		return nil, err
+	}
+	corrected := make(map[int]bool)
+	for _, c := range corrections {
+		corrected[c.Movement.BookID] = true
+	}
+	for _, d := range report.Discrepancies {
+		d.Corrected = d.BookID != 0 && corrected[d.BookID]
+	}
+
+	report.Stocktake, err = s.repo.GetByID(id)
+	if err != nil {
+		return nil, err
+	}
+	return report, nil
+}
+
+// correct lists the ledger entries that bring the branch's books to the counted
+// copies. Books of other branches and unknown barcodes are only reported, and a
+// book whose last copy is gone is left alone, as a book can't have zero copies.
+func correct(st *entity.Stocktake, report *entity.StocktakeReport, books []*entity.Book, actor string) []*entity.StockCorrection {
+	branch := make(map[int]*entity.Book)
+	for _, b := range books {
+		branch[b.ID] = b
+	}
+
+	reference := fmt.Sprintf("stocktake %d", st.ID)
+	var corrections []*entity.StockCorrection
+	for _, d := range report.Discrepancies {
+		b, ok := branch[d.BookID]
+		if !ok {
+			continue
+		}
+
+		delta := d.Counted - b.Available
+		if delta == 0 || b.Quantity+delta <= 0 {
+			continue
+		}
+		reason := entity.MovementCorrection
+		if delta < 0 {
+			reason = entity.MovementLoss
+		}
+		corrections = append(corrections, &entity.StockCorrection{
+			Version:  b.Version,
+			Movement: &entity.Movement{BookID: b.ID, Delta: delta, AvailableDelta: delta, Reason: reason, Actor: actor, Reference: reference},
+		})
+	}
+	return corrections
+}
+
+// report also returns the branch's books it compared the counts with.
+func (s *Stocktakes) report(st *entity.Stocktake) (*entity.StocktakeReport, []*entity.Book, error) {
+	counts, err := s.repo.GetCounts(st.ID)
+	if err != nil {
+		return nil, nil, err
+	}
+	books, err := s.book.GetByBranchBook(st.Branch)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	counted := make(map[int]int)
+	var unknown []*entity.StocktakeCount
+	for _, c := range counts {
+		if c.BookID == 0 {
+			unknown = append(unknown, c)
+			continue
+		}
+		counted[c.BookID] += c.Counted
+	}
+
+	report := &entity.StocktakeReport{Stocktake: st, Discrepancies: []*entity.Discrepancy{}}
+	for _, b := range books {
+		got, ok := counted[b.ID]
+		delete(counted, b.ID)
+		if got == b.Available {
+			continue
+		}
+
+		kind := entity.DiscrepancyMiscounted
+		switch {
+		case !ok || got == 0:
+			kind = entity.DiscrepancyMissing
+		case b.Available == 0:
+			kind = entity.DiscrepancyUnexpected
+		}
+		report.Discrepancies = append(report.Discrepancies, &entity.Discrepancy{BookID: b.ID, Kind: kind, Expected: b.Available, Counted: got})
+	}
+
+	// counts for books of other branches, gone or never existed
+	var strays []int
+	for id := range counted {
+		strays = append(strays, id)
+	}
+	sort.Ints(strays)
+	for _, id := range strays {
+		report.Discrepancies = append(report.Discrepancies, &entity.Discrepancy{BookID: id, Kind: entity.DiscrepancyUnexpected, Counted: counted[id]})
+	}
+	for _, c := range unknown {
+		report.Discrepancies = append(report.Discrepancies, &entity.Discrepancy{Barcode: c.Barcode, Kind: entity.DiscrepancyUnexpected, Counted: c.Counted})
+	}
+	return report, books, nil
+}
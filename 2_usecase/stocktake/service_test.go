@@ -0,0 +1,178 @@
+package stocktake
+
+import (
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	smock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/stocktake/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestOpenStocktake(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	st := &entity.Stocktake{ID: 9, Branch: " main "}
+	m.EXPECT().Create(st).Return(nil)
+	assert.NoError(t, s.OpenStocktake(st))
+	assert.Equal(t, "main", st.Branch)
+	assert.Equal(t, entity.StocktakeOpen, st.Status)
+	assert.Equal(t, 0, st.ID)
+
+	assert.Equal(t, entity.ErrInvalidEntity, s.OpenStocktake(&entity.Stocktake{Branch: " "}))
+
+	m.EXPECT().Create(gomock.Any()).Return(entity.ErrConflict)
+	assert.Equal(t, entity.ErrConflict, s.OpenStocktake(&entity.Stocktake{Branch: "main"}))
+}
+
+func TestSubmitCounts(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	open := &entity.Stocktake{ID: 1, Branch: "main", Status: entity.StocktakeOpen}
+	closed := &entity.Stocktake{ID: 2, Branch: "main", Status: entity.StocktakeClosed}
+
+	m.EXPECT().GetByID(1).Return(open, nil).Times(4)
+	b.EXPECT().GetByBarcodeBook("31000000123").Return(&entity.Book{ID: 5}, nil)
+	b.EXPECT().GetByBarcodeBook("978-0-14-044913-6").Return(nil, entity.ErrNotFound)
+	b.EXPECT().GetByISBNBook("978-0-14-044913-6").Return(&entity.Book{ID: 7}, nil)
+	b.EXPECT().GetByBarcodeBook("9780306406157").Return(nil, entity.ErrNotFound)
+	b.EXPECT().GetByISBNBook("9780306406157").Return(nil, entity.ErrNotFound)
+	b.EXPECT().GetByBarcodeBook("31000000999").Return(nil, entity.ErrNotFound)
+	m.EXPECT().AddCounts(1, []*entity.StocktakeCount{
+		{BookID: 5, Counted: 1},
+		{BookID: 7, Counted: 1},
+		{Barcode: "9780306406157", Counted: 1},
+		{Barcode: "31000000999", Counted: 1},
+		{BookID: 3, Counted: 4},
+	}).Return(nil)
+	err := s.SubmitCounts(1, &entity.StocktakeSubmission{Barcodes: []string{" 31000000123", "978-0-14-044913-6", "9780306406157", "31000000999"}, Counts: []*entity.StocktakeCount{{BookID: 3, Counted: 4}}})
+	assert.NoError(t, err)
+
+	assert.Equal(t, entity.ErrInvalidEntity, s.SubmitCounts(1, &entity.StocktakeSubmission{Barcodes: []string{" "}}))
+
+	assert.Equal(t, entity.ErrInvalidEntity, s.SubmitCounts(1, &entity.StocktakeSubmission{Counts: []*entity.StocktakeCount{{BookID: 3, Counted: -1}}}))
+	assert.Equal(t, entity.ErrInvalidEntity, s.SubmitCounts(1, &entity.StocktakeSubmission{}))
+
+	m.EXPECT().GetByID(2).Return(closed, nil)
+	assert.Equal(t, entity.ErrStocktakeClosed, s.SubmitCounts(2, &entity.StocktakeSubmission{Barcodes: []string{"9780140449136"}}))
+
+	m.EXPECT().GetByID(3).Return(nil, entity.ErrNotFound)
+	assert.Equal(t, entity.ErrNotFound, s.SubmitCounts(3, &entity.StocktakeSubmission{Barcodes: []string{"9780140449136"}}))
+}
+
+func TestReport(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	st := &entity.Stocktake{ID: 1, Branch: "main", Status: entity.StocktakeOpen}
+	m.EXPECT().GetByID(1).Return(st, nil)
+	m.EXPECT().GetCounts(1).Return([]*entity.StocktakeCount{
+		{Barcode: "9780306406157", Counted: 2},
+		{BookID: 1, Counted: 5},
+		{BookID: 2, Counted: 2},
+		{BookID: 4, Counted: 1},
+		{BookID: 9, Counted: 1},
+	}, nil)
+	b.EXPECT().GetByBranchBook("main").Return([]*entity.Book{
+		{ID: 1, Quantity: 5, Available: 5, Branch: "main"},
+		{ID: 2, Quantity: 5, Available: 3, Branch: "main"},
+		{ID: 3, Quantity: 1, Available: 1, Branch: "main"},
+		{ID: 4, Quantity: 2, Available: 0, Branch: "main"},
+	}, nil)
+
+	reportGot, err := s.Report(1)
+	assert.NoError(t, err)
+	assert.Equal(t, &entity.StocktakeReport{Stocktake: st, Discrepancies: []*entity.Discrepancy{
+		{BookID: 2, Kind: entity.DiscrepancyMiscounted, Expected: 3, Counted: 2},
+		{BookID: 3, Kind: entity.DiscrepancyMissing, Expected: 1, Counted: 0},
+		{BookID: 4, Kind: entity.DiscrepancyUnexpected, Expected: 0, Counted: 1},
+		{BookID: 9, Kind: entity.DiscrepancyUnexpected, Counted: 1},
+		{Barcode: "9780306406157", Kind: entity.DiscrepancyUnexpected, Counted: 2},
+	}}, reportGot)
+}
+
+func TestCloseStocktake(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	st := &entity.Stocktake{ID: 1, Branch: "main", Status: entity.StocktakeOpen}
+	closed := &entity.Stocktake{ID: 1, Branch: "main", Status: entity.StocktakeClosed}
+	// the last copy of 3 is gone, so it has to be deleted instead; 9 is shelved at another branch
+	corrections := []*entity.StockCorrection{
+		{Version: 4, Movement: &entity.Movement{BookID: 2, Delta: -1, AvailableDelta: -1, Reason: entity.MovementLoss, Actor: "librarian", Reference: "stocktake 1"}},
+		{Version: 2, Movement: &entity.Movement{BookID: 4, Delta: 1, AvailableDelta: 1, Reason: entity.MovementCorrection, Actor: "librarian", Reference: "stocktake 1"}},
+	}
+	gomock.InOrder(
+		m.EXPECT().GetByID(1).Return(st, nil),
+		m.EXPECT().GetCounts(1).Return([]*entity.StocktakeCount{{BookID: 2, Counted: 2}, {BookID: 4, Counted: 1}, {BookID: 9, Counted: 1}}, nil),
+		b.EXPECT().GetByBranchBook("main").Return([]*entity.Book{{ID: 2, Quantity: 5, Available: 3, Branch: "main", Version: 4}, {ID: 3, Quantity: 1, Available: 1, Branch: "main", Version: 1}, {ID: 4, Quantity: 2, Available: 0, Branch: "main", Version: 2}}, nil),
+		m.EXPECT().Close(1, corrections).Return(nil),
+		m.EXPECT().GetByID(1).Return(closed, nil),
+	)
+
+	reportGot, err := s.CloseStocktake(1, true, "librarian")
+	assert.NoError(t, err)
+	assert.Equal(t, closed, reportGot.Stocktake)
+	assert.Equal(t, []*entity.Discrepancy{
+		{BookID: 2, Kind: entity.DiscrepancyMiscounted, Expected: 3, Counted: 2, Corrected: true},
+		{BookID: 3, Kind: entity.DiscrepancyMissing, Expected: 1, Counted: 0, Corrected: false},
+		{BookID: 4, Kind: entity.DiscrepancyUnexpected, Expected: 0, Counted: 1, Corrected: true},
+		{BookID: 9, Kind: entity.DiscrepancyUnexpected, Counted: 1, Corrected: false},
+	}, reportGot.Discrepancies)
+}
+
+func TestCloseStocktake_Error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockRepository(controller)
+	b := bmock.NewMockUseCase(controller)
+	s := NewService(m, b)
+
+	m.EXPECT().GetByID(1).Return(&entity.Stocktake{ID: 1, Status: entity.StocktakeClosed}, nil)
+	_, err := s.CloseStocktake(1, false, "librarian")
+	assert.Equal(t, entity.ErrStocktakeClosed, err)
+
+	// without apply the session closes without corrections
+	m.EXPECT().GetByID(3).Return(&entity.Stocktake{ID: 3, Status: entity.StocktakeOpen}, nil)
+	m.EXPECT().GetCounts(3).Return(nil, nil)
+	b.EXPECT().GetByBranchBook("").Return(nil, nil)
+	m.EXPECT().Close(3, nil).Return(entity.ErrStocktakeClosed)
+	_, err = s.CloseStocktake(3, false, "librarian")
+	assert.Equal(t, entity.ErrStocktakeClosed, err)
+
+	someDBError := errors.New("some database error")
+	m.EXPECT().GetByID(2).Return(&entity.Stocktake{ID: 2, Status: entity.StocktakeOpen}, nil)
+	m.EXPECT().GetCounts(2).Return([]*entity.StocktakeCount{{BookID: 2, Counted: 2}}, nil)
+	b.EXPECT().GetByBranchBook("").Return([]*entity.Book{{ID: 2, Quantity: 5, Available: 3}}, nil)
+	m.EXPECT().Close(2, gomock.Any()).Return(someDBError)
+	_, err = s.CloseStocktake(2, true, "librarian")
+	assert.ErrorIs(t, err, someDBError)
+
+	// a book changed after the report
+	m.EXPECT().GetByID(4).Return(&entity.Stocktake{ID: 4, Branch: "main", Status: entity.StocktakeOpen}, nil)
+	m.EXPECT().GetCounts(4).Return([]*entity.StocktakeCount{{BookID: 2, Counted: 2}}, nil)
+	b.EXPECT().GetByBranchBook("main").Return([]*entity.Book{{ID: 2, Quantity: 5, Available: 3, Version: 1}}, nil)
+	m.EXPECT().Close(4, gomock.Len(1)).Return(entity.ErrVersionConflict)
+	_, err = s.CloseStocktake(4, true, "librarian")
+	assert.Equal(t, entity.ErrVersionConflict, err)
+}
@@ -3,6 +3,7 @@ package custom_mocks
 import (
 	"errors"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
 )
 
 type FakeUser struct {
@@ -30,14 +31,38 @@ func (f *FakeUser) GetByIDUser(id int) (*entity.User, error) {
 	return nil, nil
 }
 
-func (f *FakeUser) GetAllUsers() ([]*entity.User, error) {
-	return nil, nil
+func (f *FakeUser) GetByEmailUser(email string) (*entity.User, error) {
+	return nil, entity.ErrNotFound
 }
 
 func (f *FakeUser) UpdateUser(e *entity.User) error {
 	return nil
 }
 
-func (f *FakeUser) DeleteUser(id int) error {
+func (f *FakeUser) SetRoleUser(id int, role string) (*entity.User, error) {
+	return nil, nil
+}
+
+func (f *FakeUser) DeleteUser(id, version int, mode string) error {
 	return nil
 }
+
+func (f *FakeUser) RestoreUser(id int) (*entity.User, error) {
+	return nil, nil
+}
+
+func (f *FakeUser) PurgeDeletedUsers(retention time.Duration) (int, error) {
+	return 0, nil
+}
+
+func (f *FakeUser) HashLegacyPasswords() (int, error) {
+	return 0, nil
+}
+
+func (f *FakeUser) SearchUsers(filter entity.UserFilter) ([]*entity.User, int, error) {
+	return nil, 0, nil
+}
+
+func (f *FakeUser) FindDuplicateEmails() ([]*entity.EmailDuplicate, error) {
+	return nil, nil
+}
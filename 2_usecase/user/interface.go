@@ -1,19 +1,40 @@
 package user
 
-import entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
 
 type Repository interface {
 	Create(user *entity.User) error
 	GetByID(id int) (*entity.User, error)
-	GetAll() ([]*entity.User, error)
+	GetByEmail(email string) (*entity.User, error)
+	Search(f entity.UserFilter) ([]*entity.User, int, error)
 	Update(e *entity.User) error
-	Delete(id int) error
+	SetRole(id int, role string) error
+	Delete(id, version int, mode string) error
+	Restore(id int) error
+	PurgeDeleted(before time.Time) (int, error)
+	GetPasswordHashes() (map[int]string, error)
+	SetPasswordHash(id int, hash string) error
+	GetDuplicateEmails() ([]*entity.EmailDuplicate, error)
+}
+
+// Verifier mails a user a link to confirm their email address.
+type Verifier interface {
+	SendVerification(u *entity.User) error
 }
 
 type UseCase interface {
 	CreateUser(user *entity.User) error
 	GetByIDUser(id int) (*entity.User, error)
-	GetAllUsers() ([]*entity.User, error)
+	GetByEmailUser(email string) (*entity.User, error)
+	SearchUsers(f entity.UserFilter) ([]*entity.User, int, error)
 	UpdateUser(e *entity.User) error
-	DeleteUser(id int) error
+	SetRoleUser(id int, role string) (*entity.User, error)
+	DeleteUser(id, version int, mode string) error
+	RestoreUser(id int) (*entity.User, error)
+	PurgeDeletedUsers(retention time.Duration) (int, error)
+	HashLegacyPasswords() (int, error)
+	FindDuplicateEmails() ([]*entity.EmailDuplicate, error)
 }
@@ -6,6 +6,7 @@ package umock
 
 import (
 	reflect "reflect"
+	time "time"
 
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	gomock "github.com/golang/mock/gomock"
@@ -49,32 +50,32 @@ func (mr *MockRepositoryMockRecorder) Create(user interface{}) *gomock.Call {
 }
 
 // Delete mocks base method.
-func (m *MockRepository) Delete(id int) error {
+func (m *MockRepository) Delete(id, version int, mode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "Delete", id)
+	ret := m.ctrl.Call(m, "Delete", id, version, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // Delete indicates an expected call of Delete.
-func (mr *MockRepositoryMockRecorder) Delete(id interface{}) *gomock.Call {
+func (mr *MockRepositoryMockRecorder) Delete(id, version, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Delete", reflect.TypeOf((*MockRepository)(nil).Delete), id, version, mode)
 }
 
-// GetAll mocks base method.
-func (m *MockRepository) GetAll() ([]*entity.User, error) {
+// GetByEmail mocks base method.
+func (m *MockRepository) GetByEmail(email string) (*entity.User, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAll")
-	ret0, _ := ret[0].([]*entity.User)
+	ret := m.ctrl.Call(m, "GetByEmail", email)
+	ret0, _ := ret[0].(*entity.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAll indicates an expected call of GetAll.
-func (mr *MockRepositoryMockRecorder) GetAll() *gomock.Call {
+// GetByEmail indicates an expected call of GetByEmail.
+func (mr *MockRepositoryMockRecorder) GetByEmail(email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAll", reflect.TypeOf((*MockRepository)(nil).GetAll))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmail", reflect.TypeOf((*MockRepository)(nil).GetByEmail), email)
 }
 
 // GetByID mocks base method.
@@ -92,6 +93,109 @@ func (mr *MockRepositoryMockRecorder) GetByID(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByID", reflect.TypeOf((*MockRepository)(nil).GetByID), id)
 }
 
+// GetDuplicateEmails mocks base method.
+func (m *MockRepository) GetDuplicateEmails() ([]*entity.EmailDuplicate, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetDuplicateEmails")
+	ret0, _ := ret[0].([]*entity.EmailDuplicate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetDuplicateEmails indicates an expected call of GetDuplicateEmails.
+func (mr *MockRepositoryMockRecorder) GetDuplicateEmails() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetDuplicateEmails", reflect.TypeOf((*MockRepository)(nil).GetDuplicateEmails))
+}
+
+// GetPasswordHashes mocks base method.
+func (m *MockRepository) GetPasswordHashes() (map[int]string, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetPasswordHashes")
+	ret0, _ := ret[0].(map[int]string)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetPasswordHashes indicates an expected call of GetPasswordHashes.
+func (mr *MockRepositoryMockRecorder) GetPasswordHashes() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetPasswordHashes", reflect.TypeOf((*MockRepository)(nil).GetPasswordHashes))
+}
+
+// PurgeDeleted mocks base method.
+func (m *MockRepository) PurgeDeleted(before time.Time) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeleted", before)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeleted indicates an expected call of PurgeDeleted.
+func (mr *MockRepositoryMockRecorder) PurgeDeleted(before interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeleted", reflect.TypeOf((*MockRepository)(nil).PurgeDeleted), before)
+}
+
+// Restore mocks base method.
+func (m *MockRepository) Restore(id int) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Restore", id)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// Restore indicates an expected call of Restore.
+func (mr *MockRepositoryMockRecorder) Restore(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Restore", reflect.TypeOf((*MockRepository)(nil).Restore), id)
+}
+
+// Search mocks base method.
+func (m *MockRepository) Search(f entity.UserFilter) ([]*entity.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "Search", f)
+	ret0, _ := ret[0].([]*entity.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// Search indicates an expected call of Search.
+func (mr *MockRepositoryMockRecorder) Search(f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Search", reflect.TypeOf((*MockRepository)(nil).Search), f)
+}
+
+// SetPasswordHash mocks base method.
+func (m *MockRepository) SetPasswordHash(id int, hash string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetPasswordHash", id, hash)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetPasswordHash indicates an expected call of SetPasswordHash.
+func (mr *MockRepositoryMockRecorder) SetPasswordHash(id, hash interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetPasswordHash", reflect.TypeOf((*MockRepository)(nil).SetPasswordHash), id, hash)
+}
+
+// SetRole mocks base method.
+func (m *MockRepository) SetRole(id int, role string) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRole", id, role)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SetRole indicates an expected call of SetRole.
+func (mr *MockRepositoryMockRecorder) SetRole(id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRole", reflect.TypeOf((*MockRepository)(nil).SetRole), id, role)
+}
+
 // Update mocks base method.
 func (m *MockRepository) Update(e *entity.User) error {
 	m.ctrl.T.Helper()
@@ -106,6 +210,43 @@ func (mr *MockRepositoryMockRecorder) Update(e interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "Update", reflect.TypeOf((*MockRepository)(nil).Update), e)
 }
 
+// MockVerifier is a mock of Verifier interface.
+type MockVerifier struct {
+	ctrl     *gomock.Controller
+	recorder *MockVerifierMockRecorder
+}
+
+// MockVerifierMockRecorder is the mock recorder for MockVerifier.
+type MockVerifierMockRecorder struct {
+	mock *MockVerifier
+}
+
+// NewMockVerifier creates a new mock instance.
+func NewMockVerifier(ctrl *gomock.Controller) *MockVerifier {
+	mock := &MockVerifier{ctrl: ctrl}
+	mock.recorder = &MockVerifierMockRecorder{mock}
+	return mock
+}
+
+// EXPECT returns an object that allows the caller to indicate expected use.
+func (m *MockVerifier) EXPECT() *MockVerifierMockRecorder {
+	return m.recorder
+}
+
+// SendVerification mocks base method.
+func (m *MockVerifier) SendVerification(u *entity.User) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendVerification", u)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendVerification indicates an expected call of SendVerification.
+func (mr *MockVerifierMockRecorder) SendVerification(u interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendVerification", reflect.TypeOf((*MockVerifier)(nil).SendVerification), u)
+}
+
 // MockUseCase is a mock of UseCase interface.
 type MockUseCase struct {
 	ctrl     *gomock.Controller
@@ -144,32 +285,47 @@ func (mr *MockUseCaseMockRecorder) CreateUser(user interface{}) *gomock.Call {
 }
 
 // DeleteUser mocks base method.
-func (m *MockUseCase) DeleteUser(id int) error {
+func (m *MockUseCase) DeleteUser(id, version int, mode string) error {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "DeleteUser", id)
+	ret := m.ctrl.Call(m, "DeleteUser", id, version, mode)
 	ret0, _ := ret[0].(error)
 	return ret0
 }
 
 // DeleteUser indicates an expected call of DeleteUser.
-func (mr *MockUseCaseMockRecorder) DeleteUser(id interface{}) *gomock.Call {
+func (mr *MockUseCaseMockRecorder) DeleteUser(id, version, mode interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUseCase)(nil).DeleteUser), id)
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "DeleteUser", reflect.TypeOf((*MockUseCase)(nil).DeleteUser), id, version, mode)
 }
 
-// GetAllUsers mocks base method.
-func (m *MockUseCase) GetAllUsers() ([]*entity.User, error) {
+// FindDuplicateEmails mocks base method.
+func (m *MockUseCase) FindDuplicateEmails() ([]*entity.EmailDuplicate, error) {
 	m.ctrl.T.Helper()
-	ret := m.ctrl.Call(m, "GetAllUsers")
-	ret0, _ := ret[0].([]*entity.User)
+	ret := m.ctrl.Call(m, "FindDuplicateEmails")
+	ret0, _ := ret[0].([]*entity.EmailDuplicate)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// FindDuplicateEmails indicates an expected call of FindDuplicateEmails.
+func (mr *MockUseCaseMockRecorder) FindDuplicateEmails() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "FindDuplicateEmails", reflect.TypeOf((*MockUseCase)(nil).FindDuplicateEmails))
+}
+
+// GetByEmailUser mocks base method.
+func (m *MockUseCase) GetByEmailUser(email string) (*entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetByEmailUser", email)
+	ret0, _ := ret[0].(*entity.User)
 	ret1, _ := ret[1].(error)
 	return ret0, ret1
 }
 
-// GetAllUsers indicates an expected call of GetAllUsers.
-func (mr *MockUseCaseMockRecorder) GetAllUsers() *gomock.Call {
+// GetByEmailUser indicates an expected call of GetByEmailUser.
+func (mr *MockUseCaseMockRecorder) GetByEmailUser(email interface{}) *gomock.Call {
 	mr.mock.ctrl.T.Helper()
-	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllUsers", reflect.TypeOf((*MockUseCase)(nil).GetAllUsers))
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByEmailUser", reflect.TypeOf((*MockUseCase)(nil).GetByEmailUser), email)
 }
 
 // GetByIDUser mocks base method.
@@ -187,6 +343,82 @@ func (mr *MockUseCaseMockRecorder) GetByIDUser(id interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetByIDUser", reflect.TypeOf((*MockUseCase)(nil).GetByIDUser), id)
 }
 
+// HashLegacyPasswords mocks base method.
+func (m *MockUseCase) HashLegacyPasswords() (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "HashLegacyPasswords")
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// HashLegacyPasswords indicates an expected call of HashLegacyPasswords.
+func (mr *MockUseCaseMockRecorder) HashLegacyPasswords() *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "HashLegacyPasswords", reflect.TypeOf((*MockUseCase)(nil).HashLegacyPasswords))
+}
+
+// PurgeDeletedUsers mocks base method.
+func (m *MockUseCase) PurgeDeletedUsers(retention time.Duration) (int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "PurgeDeletedUsers", retention)
+	ret0, _ := ret[0].(int)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// PurgeDeletedUsers indicates an expected call of PurgeDeletedUsers.
+func (mr *MockUseCaseMockRecorder) PurgeDeletedUsers(retention interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "PurgeDeletedUsers", reflect.TypeOf((*MockUseCase)(nil).PurgeDeletedUsers), retention)
+}
+
+// RestoreUser mocks base method.
+func (m *MockUseCase) RestoreUser(id int) (*entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "RestoreUser", id)
+	ret0, _ := ret[0].(*entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// RestoreUser indicates an expected call of RestoreUser.
+func (mr *MockUseCaseMockRecorder) RestoreUser(id interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "RestoreUser", reflect.TypeOf((*MockUseCase)(nil).RestoreUser), id)
+}
+
+// SearchUsers mocks base method.
+func (m *MockUseCase) SearchUsers(f entity.UserFilter) ([]*entity.User, int, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SearchUsers", f)
+	ret0, _ := ret[0].([]*entity.User)
+	ret1, _ := ret[1].(int)
+	ret2, _ := ret[2].(error)
+	return ret0, ret1, ret2
+}
+
+// SearchUsers indicates an expected call of SearchUsers.
+func (mr *MockUseCaseMockRecorder) SearchUsers(f interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SearchUsers", reflect.TypeOf((*MockUseCase)(nil).SearchUsers), f)
+}
+
+// SetRoleUser mocks base method.
+func (m *MockUseCase) SetRoleUser(id int, role string) (*entity.User, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SetRoleUser", id, role)
+	ret0, _ := ret[0].(*entity.User)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// SetRoleUser indicates an expected call of SetRoleUser.
+func (mr *MockUseCaseMockRecorder) SetRoleUser(id, role interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SetRoleUser", reflect.TypeOf((*MockUseCase)(nil).SetRoleUser), id, role)
+}
+
 // UpdateUser mocks base method.
 func (m *MockUseCase) UpdateUser(e *entity.User) error {
 	m.ctrl.T.Helper()
@@ -1,68 +1,322 @@
 package user
 
 import (
+	"fmt"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/email"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/phone"
+	"log"
+	"strings"
 	"time"
 )
 
+const (
+	DefaultPerPage = 20
+	MaxPerPage     = 100
+)
+
+var sortable = map[string]bool{"id": true, "first_name": true, "last_name": true, "email": true, "created_at": true}
+
 type Users struct {
-	repo Repository
+	repo           Repository
+	allowClientIDs bool
+	verifier       Verifier
 }
 
 func NewService(repo Repository) *Users {
-	return &Users{repo: repo}
+	return &Users{repo: repo, allowClientIDs: true}
+}
+
+// AllowClientIDs controls whether Create still accepts an ID picked by the client.
+// It is on by default while clients migrate to server-generated IDs.
+func (u *Users) AllowClientIDs(allow bool) {
+	u.allowClientIDs = allow
+}
+
+// SetVerifier makes new users, and users who change their email, get a link to
+// confirm the address. Without one nobody is ever asked to.
+func (u *Users) SetVerifier(v Verifier) {
+	u.verifier = v
 }
 
 func (u *Users) CreateUser(e *entity.User) error {
-	_, err := u.repo.GetByID(e.ID)
-	if err != entity.ErrNotFound {
-		return entity.ErrConflict
+	if e.ID != 0 {
+		if !u.allowClientIDs {
+			return entity.ErrClientID
+		}
+
+		_, err := u.repo.GetByID(e.ID)
+		if err != entity.ErrNotFound {
+			return entity.ErrConflict
+		}
+	}
+
+	err := validate(e, true, "")
+	if err != nil {
+		return err
 	}
 
-	err = ValidateInput(e)
+	err = setPassword(e)
 	if err != nil {
 		return err
 	}
 
+	// everybody signs up as a member; only SetRoleUser hands out other roles
+	e.Role = entity.RoleMember
+	e.EmailVerifiedAt = nil
 	e.CreatedAt = time.Now()
-	return u.repo.Create(e)
+	e.Version = 1
+	err = u.repo.Create(e)
+	if err != nil {
+		return err
+	}
+
+	u.sendVerification(e)
+	return nil
 }
 
 func (u *Users) GetByIDUser(id int) (*entity.User, error) {
 	return u.repo.GetByID(id)
 }
 
-func (u *Users) GetAllUsers() ([]*entity.User, error) {
-	return u.repo.GetAll()
+func (u *Users) GetByEmailUser(email string) (*entity.User, error) {
+	return u.repo.GetByEmail(strings.TrimSpace(email))
+}
+
+// WithDefaults fills in what a search leaves out: the first page of DefaultPerPage
+// users, ordered by id.
+func WithDefaults(f entity.UserFilter) entity.UserFilter {
+	if f.Sort == "" {
+		f.Sort = "id"
+	}
+	if f.Page == 0 {
+		f.Page = 1
+	}
+	if f.PerPage == 0 {
+		f.PerPage = DefaultPerPage
+	}
+	return f
+}
+
+// SearchUsers returns a page of the users that match f and how many match in all.
+func (u *Users) SearchUsers(f entity.UserFilter) ([]*entity.User, int, error) {
+	f = WithDefaults(f)
+	f.Query = strings.TrimSpace(f.Query)
+	f.Location = strings.TrimSpace(f.Location)
+
+	var v entity.ValidationError
+	if !sortable[strings.TrimPrefix(f.Sort, "-")] {
+		v.Add("sort", "must be one of id, first_name, last_name, email or created_at, optionally prefixed with -")
+	}
+	if f.Page < 1 {
+		v.Add("page", "must be at least 1")
+	}
+	if f.PerPage < 1 || f.PerPage > MaxPerPage {
+		v.Add("per_page", fmt.Sprintf("must be 1 to %d", MaxPerPage))
+	}
+	err := v.OrNil()
+	if err != nil {
+		return nil, 0, err
+	}
+
+	return u.repo.Search(f)
 }
 
 func (u *Users) UpdateUser(e *entity.User) error {
-	_, err := u.repo.GetByID(e.ID)
+	current, err := u.repo.GetByID(e.ID)
 	if err != nil {
 		return err
 	}
+	e.Role = current.Role
 
-	err = ValidateInput(e)
+	// a number saved before numbers were normalized is left as it is, so those
+	// users can still be updated; a new number has to be valid
+	err = validate(e, false, current.CellPhoneNumber)
+	if err != nil {
+		return err
+	}
+
+	// a new address has to be confirmed again
+	emailChanged := e.Email != strings.ToLower(strings.TrimSpace(current.Email))
+	e.EmailVerifiedAt = current.EmailVerifiedAt
+	if emailChanged {
+		e.EmailVerifiedAt = nil
+	}
+
+	err = setPassword(e)
 	if err != nil {
 		return err
 	}
 
 	e.UpdatedAt = time.Now()
-	return u.repo.Update(e)
+	err = u.repo.Update(e)
+	if err != nil {
+		return err
+	}
+
+	if emailChanged {
+		u.sendVerification(e)
+	}
+	return nil
 }
 
-func (u *Users) DeleteUser(id int) error {
-	_, err := u.repo.GetByID(id)
+// sendVerification only logs a failure: the account is saved either way and the
+// user can ask for the link again.
+func (u *Users) sendVerification(e *entity.User) {
+	if u.verifier == nil {
+		return
+	}
+
+	err := u.verifier.SendVerification(e)
 	if err != nil {
-		return err
+		log.Printf("sending the verification link to user %d: %v", e.ID, err)
+	}
+}
+
+// SetRoleUser makes the user a member, librarian or admin.
+func (u *Users) SetRoleUser(id int, role string) (*entity.User, error) {
+	if role != entity.RoleMember && role != entity.RoleLibrarian && role != entity.RoleAdmin {
+		return nil, entity.ErrInvalidEntity
+	}
+
+	err := u.repo.SetRole(id, role)
+	if err != nil {
+		return nil, err
 	}
 
-	return u.repo.Delete(id)
+	return u.repo.GetByID(id)
 }
 
-func ValidateInput(user *entity.User) error {
-	if user.ID <= 0 || user.FirstName == "" || user.LastName == "" || user.DOB.IsZero() || user.Location == "" || user.CellPhoneNumber == "" || user.Email == "" || user.Password == "" {
+// DeleteUser refuses to delete a user who still has books unless mode says
+// whether those copies come back to stock or are written off.
+func (u *Users) DeleteUser(id, version int, mode string) error {
+	if mode != entity.DeleteRestrict && mode != entity.DeleteForceReturn && mode != entity.DeleteWriteOff {
 		return entity.ErrInvalidEntity
 	}
+
+	// the repository checks the loans in the delete's transaction
+	return u.repo.Delete(id, version, mode)
+}
+
+func (u *Users) RestoreUser(id int) (*entity.User, error) {
+	err := u.repo.Restore(id)
+	if err != nil {
+		return nil, err
+	}
+
+	return u.repo.GetByID(id)
+}
+
+// PurgeDeletedUsers removes users that have been soft-deleted for longer than retention.
+func (u *Users) PurgeDeletedUsers(retention time.Duration) (int, error) {
+	return u.repo.PurgeDeleted(time.Now().Add(-retention))
+}
+
+// HashLegacyPasswords hashes the passwords that older versions stored in plaintext,
+// deleted users included, and says how many it hashed. Running it again is harmless.
+func (u *Users) HashLegacyPasswords() (int, error) {
+	stored, err := u.repo.GetPasswordHashes()
+	if err != nil {
+		return 0, err
+	}
+
+	hashed := 0
+	for id, plain := range stored {
+		if plain == "" || password.IsHash(plain) {
+			continue
+		}
+
+		hash, err := password.Hash(plain)
+		if err != nil {
+			return hashed, err
+		}
+		err = u.repo.SetPasswordHash(id, hash)
+		if err != nil {
+			return hashed, err
+		}
+		hashed++
+	}
+	return hashed, nil
+}
+
+// FindDuplicateEmails lists the addresses shared by live users, compared case-insensitively.
+func (u *Users) FindDuplicateEmails() ([]*entity.EmailDuplicate, error) {
+	return u.repo.GetDuplicateEmails()
+}
+
+// setPassword replaces the plaintext Password of a request with its hash. Without
+// one the stored hash is kept, so an update doesn't have to resend the password.
+func setPassword(e *entity.User) error {
+	if e.Password == "" {
+		e.PasswordHash = ""
+		return nil
+	}
+
+	hash, err := password.Hash(e.Password)
+	if err != nil {
+		return err
+	}
+	e.PasswordHash = hash
+	e.Password = ""
+	return nil
+}
+
+// ValidateInput checks an update request and normalizes the email and phone number
+// in place. The password is optional and, when given, has to pass the strength policy.
+func ValidateInput(user *entity.User) error {
+	return validate(user, false, "")
+}
+
+// validate reports every field at fault at once in an *entity.ValidationError; a
+// weak password only comes up once the rest is right. A phone number equal to
+// storedPhone is taken as it is.
+func validate(user *entity.User, passwordRequired bool, storedPhone string) error {
+	var v entity.ValidationError
+	if user.ID < 0 {
+		v.Add("id", "must not be negative")
+	}
+	if strings.TrimSpace(user.FirstName) == "" {
+		v.Add("first_name", "is required")
+	}
+	if strings.TrimSpace(user.LastName) == "" {
+		v.Add("last_name", "is required")
+	}
+	if user.DOB.IsZero() {
+		v.Add("dob", "is required")
+	} else if user.DOB.After(time.Now()) {
+		v.Add("dob", "is in the future")
+	}
+	if strings.TrimSpace(user.Location) == "" {
+		v.Add("location", "is required")
+	}
+
+	addr, err := email.Normalize(user.Email)
+	if err != nil {
+		v.Add("email", err.Error())
+	} else {
+		user.Email = addr
+	}
+
+	if storedPhone == "" || user.CellPhoneNumber != storedPhone {
+		number, err := phone.Normalize(user.CellPhoneNumber, user.Location)
+		if err != nil {
+			v.Add("cellphone_number", err.Error())
+		} else {
+			user.CellPhoneNumber = number
+		}
+	}
+
+	if passwordRequired && user.Password == "" {
+		v.Add("password", "is required")
+	}
+	err = v.OrNil()
+	if err != nil {
+		return err
+	}
+
+	if user.Password != "" && !password.Strong(user.Password) {
+		return entity.ErrWeakPassword
+	}
 	return nil
 }
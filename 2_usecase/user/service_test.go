@@ -4,6 +4,7 @@ import (
 	"errors"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/password"
 	"github.com/golang/mock/gomock"
 	"github.com/stretchr/testify/assert"
 	"testing"
@@ -17,10 +18,8 @@ type userTest struct {
 }
 type wantUser struct {
 	user          *entity.User
-	users         []*entity.User
 	errFromCreate error
 	errFromGet    error
-	errFromGetAll error
 	errFromUpdate error
 	errFromDelete error
 	errFinal      error
@@ -39,7 +38,7 @@ func TestCreateUser_Success(t *testing.T) {
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345"}
+	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
 
 	tests := []userTest{
 		{user: u1, want: wantUser{user: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: nil}},
@@ -63,12 +62,12 @@ func TestCreateUser_Error(t *testing.T) {
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345"}
-	u2 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "", Email: "", Password: ""}
+	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
+	u2 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "", Email: "", Password: ""}
 
 	tests := []userTest{
 		{user: u1, want: wantUser{user: u1, errFromGet: nil, errFromCreate: nil, errFinal: entity.ErrConflict}},
-		{user: u2, want: wantUser{user: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: entity.ErrInvalidEntity}},
+		{user: u2, want: wantUser{user: nil, errFromGet: entity.ErrNotFound, errFromCreate: nil, errFinal: &entity.ValidationError{Fields: map[string]string{"cellphone_number": "is required", "email": "is required", "password": "is required"}}}},
 	}
 
 	for _, ut := range tests {
@@ -80,6 +79,28 @@ func TestCreateUser_Error(t *testing.T) {
 	}
 }
 
+func TestCreateUser_GeneratedID(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	u1 := &entity.User{FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
+	m.EXPECT().GetByID(gomock.Any()).Times(0)
+	m.EXPECT().Create(u1).DoAndReturn(func(user *entity.User) error {
+		user.ID = 3
+		return nil
+	})
+	assert.NoError(t, u.CreateUser(u1))
+	assert.Equal(t, 3, u1.ID)
+	assert.Equal(t, 1, u1.Version)
+
+	u.AllowClientIDs(false)
+	u2 := &entity.User{ID: 4, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115486", Email: "sergey@gmail.com", Password: "qwerty12345"}
+	assert.Equal(t, entity.ErrClientID, u.CreateUser(u2))
+}
+
 func TestGetByIDUser_Success(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -125,46 +146,90 @@ func TestGetByIDUser_Error(t *testing.T) {
 	}
 }
 
-func TestGetAllUsers_Success(t *testing.T) {
+func TestUserVerification(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	m := umock.NewMockRepository(controller)
+	v := umock.NewMockVerifier(controller)
 	u := NewService(m)
+	u.SetVerifier(v)
+
+	verifiedAt := time.Date(2024, time.March, 1, 10, 0, 0, 0, time.UTC)
+	u1 := &entity.User{FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345", EmailVerifiedAt: &verifiedAt}
+	m.EXPECT().Create(u1).Return(nil)
+	v.EXPECT().SendVerification(u1).Return(errors.New("mail server is down"))
+	// the account is created even when the link can't be sent
+	assert.NoError(t, u.CreateUser(u1))
+	assert.Nil(t, u1.EmailVerifiedAt)
+
+	stored := &entity.User{ID: 1, Email: "Taras6317492@gmail.com", Role: entity.RoleMember, EmailVerifiedAt: &verifiedAt}
+	same := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com"}
+	m.EXPECT().GetByID(1).Return(stored, nil)
+	m.EXPECT().Update(same).Return(nil)
+	assert.NoError(t, u.UpdateUser(same))
+	assert.Equal(t, &verifiedAt, same.EmailVerifiedAt)
+
+	changed := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras@gmail.com"}
+	m.EXPECT().GetByID(1).Return(stored, nil)
+	m.EXPECT().Update(changed).Return(nil)
+	v.EXPECT().SendVerification(changed).Return(nil)
+	assert.NoError(t, u.UpdateUser(changed))
+	assert.Nil(t, changed.EmailVerifiedAt)
+}
 
-	users := []*entity.User{{ID: 1}, {ID: 2}, {ID: 3}}
-
-	tests := []userTest{
-		{want: wantUser{users: users, errFinal: nil}},
-	}
+func TestUpdateUser_LegacyPhone(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
 
-	for _, ut := range tests {
-		m.EXPECT().GetAll().Return(ut.want.users, ut.want.errFinal)
-		usersGot, errGot := u.GetAllUsers()
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
 
-		assert.Equal(t, ut.want.users, usersGot)
-		assert.Equal(t, ut.want.errFinal, errGot)
-	}
+	// saved before numbers were normalized, and not a number Normalize takes
+	stored := &entity.User{ID: 1, Email: "taras6317492@gmail.com", Location: "Kyiv, Ukraine", CellPhoneNumber: "093-311-54-85 (home)", Role: entity.RoleMember}
+	kept := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "093-311-54-85 (home)", Email: "taras6317492@gmail.com"}
+	m.EXPECT().GetByID(1).Return(stored, nil).Times(3)
+	m.EXPECT().Update(kept).Return(nil)
+	assert.NoError(t, u.UpdateUser(kept))
+	assert.Equal(t, "093-311-54-85 (home)", kept.CellPhoneNumber)
+
+	// a number that changes is normalized
+	changed := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115486", Email: "taras6317492@gmail.com"}
+	m.EXPECT().Update(changed).Return(nil)
+	assert.NoError(t, u.UpdateUser(changed))
+	assert.Equal(t, "+380933115486", changed.CellPhoneNumber)
+
+	bad := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "12", Email: "taras6317492@gmail.com"}
+	var v *entity.ValidationError
+	assert.ErrorAs(t, u.UpdateUser(bad), &v)
+	assert.Contains(t, v.Fields, "cellphone_number")
 }
 
-func TestGetAllUsers_Error(t *testing.T) {
+func TestSearchUsers(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
 
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	tests := []userTest{
-		{want: wantUser{users: nil, errFinal: errors.New("some database error")}},
-	}
-
-	for _, ut := range tests {
-		m.EXPECT().GetAll().Return(ut.want.users, ut.want.errFinal)
-		usersGot, errGot := u.GetAllUsers()
-
-		assert.Equal(t, ut.want.users, usersGot)
-		assert.Equal(t, ut.want.errFinal, errGot)
-	}
+	users := []*entity.User{{ID: 1}}
+	m.EXPECT().Search(entity.UserFilter{Query: "taras", Sort: "id", Page: 1, PerPage: DefaultPerPage}).Return(users, 1, nil)
+	got, total, err := u.SearchUsers(entity.UserFilter{Query: "  taras "})
+	assert.NoError(t, err)
+	assert.Equal(t, users, got)
+	assert.Equal(t, 1, total)
+
+	m.EXPECT().Search(entity.UserFilter{Sort: "-last_name", Page: 3, PerPage: MaxPerPage}).Return(nil, 0, nil)
+	_, _, err = u.SearchUsers(entity.UserFilter{Sort: "-last_name", Page: 3, PerPage: MaxPerPage})
+	assert.NoError(t, err)
+
+	_, _, err = u.SearchUsers(entity.UserFilter{Sort: "password", Page: -1, PerPage: MaxPerPage + 1})
+	var v *entity.ValidationError
+	assert.ErrorAs(t, err, &v)
+	assert.Len(t, v.Fields, 3)
+	assert.Contains(t, v.Fields, "sort")
+	assert.Contains(t, v.Fields, "page")
+	assert.Contains(t, v.Fields, "per_page")
 }
 
 func TestUpdateUser_Success(t *testing.T) {
@@ -174,7 +239,7 @@ func TestUpdateUser_Success(t *testing.T) {
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345"}
+	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
 
 	tests := []userTest{
 		{user: u1, want: wantUser{user: u1, errFromGet: nil, errFromUpdate: nil, errFinal: nil}},
@@ -196,12 +261,12 @@ func TestUpdateUser_Error(t *testing.T) {
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345"}
-	u2 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Kyiv", CellPhoneNumber: "", Email: "", Password: ""}
+	u1 := &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
+	u2 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "", Email: "", Password: ""}
 
 	tests := []userTest{
 		{user: u1, want: wantUser{user: nil, errFromGet: entity.ErrNotFound, errFinal: entity.ErrNotFound}, t: timesToCall{ttcUpdate: 0}},
-		{user: u2, want: wantUser{user: u2, errFromGet: nil, errFinal: entity.ErrInvalidEntity}, t: timesToCall{ttcUpdate: 0}},
+		{user: u2, want: wantUser{user: u2, errFromGet: nil, errFinal: &entity.ValidationError{Fields: map[string]string{"cellphone_number": "is required", "email": "is required"}}}, t: timesToCall{ttcUpdate: 0}},
 		{user: u1, want: wantUser{user: u1, errFromGet: nil, errFromUpdate: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcUpdate: 1}},
 	}
 
@@ -222,17 +287,16 @@ func TestDeleteUser_Success(t *testing.T) {
 	m := umock.NewMockRepository(controller)
 	u := NewService(m)
 
-	u1 := &entity.User{ID: 1}
+	u1 := &entity.User{ID: 1, Version: 3}
 
 	tests := []userTest{
 		{user: u1, want: wantUser{user: u1, errFromGet: nil, errFromDelete: nil, errFinal: nil}},
 	}
 
 	for _, ut := range tests {
-		m.EXPECT().GetByID(ut.user.ID).Return(ut.want.user, ut.want.errFromGet)
-		m.EXPECT().Delete(ut.user.ID).Return(ut.want.errFromDelete)
+		m.EXPECT().Delete(ut.user.ID, ut.user.Version, entity.DeleteRestrict).Return(ut.want.errFromDelete)
 
-		errGot := u.DeleteUser(ut.user.ID)
+		errGot := u.DeleteUser(ut.user.ID, ut.user.Version, entity.DeleteRestrict)
 		assert.Equal(t, ut.want.errFinal, errGot)
 	}
 
@@ -248,15 +312,175 @@ func TestDeleteUser_Error(t *testing.T) {
 	u1 := &entity.User{ID: 1}
 
 	tests := []userTest{
-		{user: u1, want: wantUser{user: nil, errFromGet: entity.ErrNotFound, errFinal: entity.ErrNotFound}, t: timesToCall{ttcDelete: 0}},
-		{user: u1, want: wantUser{user: u1, errFromGet: nil, errFromDelete: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcDelete: 1}},
+		{user: u1, want: wantUser{errFromDelete: entity.ErrNotFound, errFinal: entity.ErrNotFound}, t: timesToCall{ttcDelete: 1}},
+		{user: u1, want: wantUser{errFromDelete: errors.New("some database error"), errFinal: errors.New("some database error")}, t: timesToCall{ttcDelete: 1}},
+		{user: u1, want: wantUser{errFromDelete: entity.ErrVersionConflict, errFinal: entity.ErrVersionConflict}, t: timesToCall{ttcDelete: 1}},
 	}
 
 	for _, ut := range tests {
-		m.EXPECT().GetByID(ut.user.ID).Return(ut.want.user, ut.want.errFromGet)
-		m.EXPECT().Delete(ut.user.ID).Return(ut.want.errFromDelete).Times(ut.t.ttcDelete)
+		m.EXPECT().Delete(ut.user.ID, ut.user.Version, entity.DeleteRestrict).Return(ut.want.errFromDelete).Times(ut.t.ttcDelete)
 
-		errGot := u.DeleteUser(ut.user.ID)
+		errGot := u.DeleteUser(ut.user.ID, ut.user.Version, entity.DeleteRestrict)
 		assert.Equal(t, ut.want.errFinal, errGot)
 	}
 }
+
+func TestDeleteUser_OutstandingLoans(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	m.EXPECT().Delete(1, 2, entity.DeleteRestrict).Return(&entity.OutstandingLoansError{BookIDs: []int{3, 5}})
+
+	err := u.DeleteUser(1, 2, entity.DeleteRestrict)
+	assert.ErrorIs(t, err, entity.ErrOutstandingLoans)
+	var loansErr *entity.OutstandingLoansError
+	assert.ErrorAs(t, err, &loansErr)
+	assert.Equal(t, []int{3, 5}, loansErr.BookIDs)
+
+	m.EXPECT().Delete(1, 2, entity.DeleteForceReturn).Return(nil)
+	assert.NoError(t, u.DeleteUser(1, 2, entity.DeleteForceReturn))
+
+	m.EXPECT().Delete(1, 2, entity.DeleteWriteOff).Return(nil)
+	assert.NoError(t, u.DeleteUser(1, 2, entity.DeleteWriteOff))
+
+	assert.Equal(t, entity.ErrInvalidEntity, u.DeleteUser(1, 2, "burn"))
+}
+
+func TestRestoreUser(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	u1 := &entity.User{ID: 1, Version: 3, Books: []int{4}}
+	m.EXPECT().Restore(1).Return(nil)
+	m.EXPECT().GetByID(1).Return(u1, nil)
+	got, err := u.RestoreUser(1)
+	assert.NoError(t, err)
+	assert.Equal(t, u1, got)
+
+	m.EXPECT().Restore(2).Return(entity.ErrNotFound)
+	got, err = u.RestoreUser(2)
+	assert.Nil(t, got)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	var before time.Time
+	m.EXPECT().PurgeDeleted(gomock.Any()).DoAndReturn(func(cutoff time.Time) (int, error) {
+		before = cutoff
+		return 1, nil
+	})
+	n, err := u.PurgeDeletedUsers(48 * time.Hour)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.WithinDuration(t, time.Now().Add(-48*time.Hour), before, time.Minute)
+}
+
+func TestUserPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	u1 := &entity.User{FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "qwerty12345"}
+	m.EXPECT().Create(u1).Return(nil)
+	assert.NoError(t, u.CreateUser(u1))
+	assert.Empty(t, u1.Password)
+	assert.True(t, password.Verify(u1.PasswordHash, "qwerty12345"))
+
+	// the stored hash is kept when an update doesn't send a password
+	u1.ID = 1
+	m.EXPECT().GetByID(1).Return(u1, nil)
+	m.EXPECT().Update(u1).Return(nil)
+	assert.NoError(t, u.UpdateUser(u1))
+	assert.Empty(t, u1.PasswordHash)
+
+	weak := &entity.User{FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Kyiv, Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345"}
+	assert.Equal(t, entity.ErrWeakPassword, u.CreateUser(weak))
+	weak.Password = ""
+	assert.ErrorIs(t, u.CreateUser(weak), entity.ErrInvalidEntity)
+}
+
+func TestHashLegacyPasswords(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	hash, err := password.Hash("qwerty12345")
+	assert.NoError(t, err)
+	m.EXPECT().GetPasswordHashes().Return(map[int]string{1: "12345", 2: hash, 3: ""}, nil)
+	m.EXPECT().SetPasswordHash(1, gomock.Any()).DoAndReturn(func(id int, h string) error {
+		assert.True(t, password.Verify(h, "12345"))
+		return nil
+	})
+
+	hashed, err := u.HashLegacyPasswords()
+	assert.NoError(t, err)
+	assert.Equal(t, 1, hashed)
+}
+
+func TestSetRoleUser(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockRepository(controller)
+	u := NewService(m)
+
+	u1 := &entity.User{ID: 1, Role: entity.RoleLibrarian, Version: 2}
+	m.EXPECT().SetRole(1, entity.RoleLibrarian).Return(nil)
+	m.EXPECT().GetByID(1).Return(u1, nil)
+	got, err := u.SetRoleUser(1, entity.RoleLibrarian)
+	assert.NoError(t, err)
+	assert.Equal(t, u1, got)
+
+	got, err = u.SetRoleUser(1, "owner")
+	assert.Nil(t, got)
+	assert.Equal(t, entity.ErrInvalidEntity, err)
+
+	m.EXPECT().SetRole(2, entity.RoleAdmin).Return(entity.ErrNotFound)
+	_, err = u.SetRoleUser(2, entity.RoleAdmin)
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	// members can't promote themselves by sending a role with their profile
+	m.EXPECT().GetByID(1).Return(&entity.User{ID: 1, Role: entity.RoleMember, Version: 2}, nil)
+	m.EXPECT().Update(gomock.Any()).DoAndReturn(func(e *entity.User) error {
+		assert.Equal(t, entity.RoleMember, e.Role)
+		return nil
+	})
+	err = u.UpdateUser(&entity.User{ID: 1, FirstName: "Peter", LastName: "Parker", DOB: time.Date(1990, 1, 1, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554422", Email: "peter@gmail.com", Role: entity.RoleAdmin, Version: 2})
+	assert.NoError(t, err)
+}
+
+func TestValidateInput(t *testing.T) {
+	user := &entity.User{FirstName: "Peter", LastName: "Anderson", DOB: time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC), Location: "Toronto, Canada", CellPhoneNumber: "(647) 915-0167", Email: " Peter@Gmail.com"}
+	assert.NoError(t, ValidateInput(user))
+	assert.Equal(t, "peter@gmail.com", user.Email)
+	assert.Equal(t, "+16479150167", user.CellPhoneNumber)
+
+	bad := &entity.User{FirstName: " ", LastName: "Anderson", DOB: time.Now().Add(48 * time.Hour), Location: "Kyiv", CellPhoneNumber: "0933115485", Email: "Peter <peter@gmail.com>"}
+	err := ValidateInput(bad)
+	assert.ErrorIs(t, err, entity.ErrInvalidEntity)
+	var v *entity.ValidationError
+	assert.True(t, errors.As(err, &v))
+	assert.Equal(t, map[string]string{
+		"first_name":       "is required",
+		"dob":              "is in the future",
+		"email":            "is not a valid address",
+		"cellphone_number": "needs a country code, e.g. +380, or a country we know in location",
+	}, v.Fields)
+	assert.Equal(t, "Peter <peter@gmail.com>", bad.Email)
+}
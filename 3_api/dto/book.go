@@ -0,0 +1,165 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+// BookRequest is what clients send to create or update a book. Available, the
+// rating and the version are kept by the server.
+type BookRequest struct {
+	ID              int    `json:"id,omitempty"`
+	ISBN            string `json:"isbn"`
+	Title           string `json:"title"`
+	Author          string `json:"author"`
+	Pages           int    `json:"pages"`
+	Quantity        int    `json:"quantity"`
+	Publisher       string `json:"publisher"`
+	PublicationYear int    `json:"publication_year"`
+	Edition         string `json:"edition"`
+	Language        string `json:"language"`
+	Description     string `json:"description"`
+	Format          string `json:"format"`
+	SeriesID        int    `json:"series_id"`
+	Volume          int    `json:"volume"`
+	Branch          string `json:"branch"`
+	Barcode         string `json:"barcode"`
+}
+
+type BookResponse struct {
+	ID              int       `json:"id"`
+	ISBN            string    `json:"isbn"`
+	Title           string    `json:"title"`
+	Author          string    `json:"author"`
+	Pages           int       `json:"pages"`
+	Quantity        int       `json:"quantity"`
+	Available       int       `json:"available"`
+	Publisher       string    `json:"publisher"`
+	PublicationYear int       `json:"publication_year"`
+	Edition         string    `json:"edition"`
+	Language        string    `json:"language"`
+	Description     string    `json:"description"`
+	Format          string    `json:"format"`
+	SeriesID        int       `json:"series_id"`
+	Volume          int       `json:"volume"`
+	Branch          string    `json:"branch"`
+	Barcode         string    `json:"barcode"`
+	Rating          float64   `json:"rating"`
+	RatingCount     int       `json:"rating_count"`
+	Version         int       `json:"version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+type MovementResponse struct {
+	ID             int       `json:"id"`
+	BookID         int       `json:"book_id"`
+	Delta          int       `json:"delta"`
+	AvailableDelta int       `json:"available_delta"`
+	Reason         string    `json:"reason"`
+	Actor          string    `json:"actor"`
+	Reference      string    `json:"reference"`
+	CreatedAt      time.Time `json:"created_at"`
+}
+
+type DuplicateResponse struct {
+	Book   *BookResponse `json:"book"`
+	Other  *BookResponse `json:"other"`
+	Score  float64       `json:"score"`
+	Reason string        `json:"reason"`
+}
+
+type MergeRequest struct {
+	SurvivorID   int   `json:"survivor_id"`
+	DuplicateIDs []int `json:"duplicate_ids"`
+}
+
+func (b *BookRequest) ToEntity() *entity.Book {
+	return &entity.Book{
+		ID:              b.ID,
+		ISBN:            b.ISBN,
+		Tittle:          b.Title,
+		Author:          b.Author,
+		Pages:           b.Pages,
+		Quantity:        b.Quantity,
+		Publisher:       b.Publisher,
+		PublicationYear: b.PublicationYear,
+		Edition:         b.Edition,
+		Language:        b.Language,
+		Description:     b.Description,
+		Format:          b.Format,
+		SeriesID:        b.SeriesID,
+		Volume:          b.Volume,
+		Branch:          b.Branch,
+		Barcode:         b.Barcode,
+	}
+}
+
+func NewBookResponse(b *entity.Book) *BookResponse {
+	return &BookResponse{
+		ID:              b.ID,
+		ISBN:            b.ISBN,
+		Title:           b.Tittle,
+		Author:          b.Author,
+		Pages:           b.Pages,
+		Quantity:        b.Quantity,
+		Available:       b.Available,
+		Publisher:       b.Publisher,
+		PublicationYear: b.PublicationYear,
+		Edition:         b.Edition,
+		Language:        b.Language,
+		Description:     b.Description,
+		Format:          b.Format,
+		SeriesID:        b.SeriesID,
+		Volume:          b.Volume,
+		Branch:          b.Branch,
+		Barcode:         b.Barcode,
+		Rating:          b.Rating,
+		RatingCount:     b.RatingCount,
+		Version:         b.Version,
+		CreatedAt:       b.CreatedAt,
+		UpdatedAt:       b.UpdatedAt,
+	}
+}
+
+func NewBookResponses(books []*entity.Book) []*BookResponse {
+	res := make([]*BookResponse, 0, len(books))
+	for _, b := range books {
+		res = append(res, NewBookResponse(b))
+	}
+	return res
+}
+
+func NewMovementResponses(movements []*entity.Movement) []*MovementResponse {
+	res := make([]*MovementResponse, 0, len(movements))
+	for _, m := range movements {
+		res = append(res, &MovementResponse{
+			ID:             m.ID,
+			BookID:         m.BookID,
+			Delta:          m.Delta,
+			AvailableDelta: m.AvailableDelta,
+			Reason:         m.Reason,
+			Actor:          m.Actor,
+			Reference:      m.Reference,
+			CreatedAt:      m.CreatedAt,
+		})
+	}
+	return res
+}
+
+func NewDuplicateResponses(candidates []*entity.DuplicateCandidate) []*DuplicateResponse {
+	res := make([]*DuplicateResponse, 0, len(candidates))
+	for _, c := range candidates {
+		res = append(res, &DuplicateResponse{
+			Book:   NewBookResponse(c.Book),
+			Other:  NewBookResponse(c.Other),
+			Score:  c.Score,
+			Reason: c.Reason,
+		})
+	}
+	return res
+}
+
+func (m *MergeRequest) ToEntity() *entity.BookMerge {
+	return &entity.BookMerge{SurvivorID: m.SurvivorID, DuplicateIDs: m.DuplicateIDs}
+}
@@ -0,0 +1,25 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestBookRequest_ToEntity(t *testing.T) {
+	req := &BookRequest{ID: 2, ISBN: "9780140183436", Title: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2, PublicationYear: 1932, Format: entity.FormatHardcover, SeriesID: 1, Volume: 2}
+
+	want := &entity.Book{ID: 2, ISBN: "9780140183436", Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2, PublicationYear: 1932, Format: entity.FormatHardcover, SeriesID: 1, Volume: 2}
+	assert.Equal(t, want, req.ToEntity())
+}
+
+func TestNewBookResponse(t *testing.T) {
+	b := &entity.Book{ID: 2, Tittle: "Tobacco Road", Quantity: 2, Available: 1, Rating: 4.5, RatingCount: 2, Version: 3, Movement: &entity.Movement{Reason: entity.MovementPurchase}}
+
+	got := NewBookResponse(b)
+	assert.Equal(t, &BookResponse{ID: 2, Title: "Tobacco Road", Quantity: 2, Available: 1, Rating: 4.5, RatingCount: 2, Version: 3}, got)
+
+	dups := NewDuplicateResponses([]*entity.DuplicateCandidate{{Book: b, Other: &entity.Book{ID: 3, Tittle: "Tobacco road"}, Score: 0.9, Reason: entity.DuplicateTitleAuthor}})
+	assert.Equal(t, "Tobacco road", dups[0].Other.Title)
+	assert.Equal(t, []int{4}, (&MergeRequest{SurvivorID: 2, DuplicateIDs: []int{4}}).ToEntity().DuplicateIDs)
+}
@@ -0,0 +1,27 @@
+package dto
+
+import entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+
+type ImportRowResponse struct {
+	Row    int    `json:"row"`
+	ID     int    `json:"id"`
+	ISBN   string `json:"isbn"`
+	Status string `json:"status"`
+	Error  string `json:"error,omitempty"`
+}
+
+type ImportReportResponse struct {
+	DryRun   bool                 `json:"dry_run"`
+	Created  int                  `json:"created"`
+	Updated  int                  `json:"updated"`
+	Rejected int                  `json:"rejected"`
+	Rows     []*ImportRowResponse `json:"rows"`
+}
+
+func NewImportReportResponse(r *entity.ImportReport) *ImportReportResponse {
+	res := &ImportReportResponse{DryRun: r.DryRun, Created: r.Created, Updated: r.Updated, Rejected: r.Rejected, Rows: make([]*ImportRowResponse, 0, len(r.Rows))}
+	for _, row := range r.Rows {
+		res.Rows = append(res.Rows, &ImportRowResponse{Row: row.Row, ID: row.ID, ISBN: row.ISBN, Status: row.Status, Error: row.Error})
+	}
+	return res
+}
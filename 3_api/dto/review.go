@@ -0,0 +1,52 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+// ReviewRequest is what a patron sends; the book comes from the path and the
+// author from the session.
+type ReviewRequest struct {
+	UserID int    `json:"user_id,omitempty"`
+	Rating int    `json:"rating"`
+	Text   string `json:"text"`
+}
+
+type ReviewResponse struct {
+	ID          int       `json:"id"`
+	BookID      int       `json:"book_id"`
+	UserID      int       `json:"user_id"`
+	Rating      int       `json:"rating"`
+	Text        string    `json:"text"`
+	Status      string    `json:"status"`
+	ModeratedBy string    `json:"moderated_by"`
+	CreatedAt   time.Time `json:"created_at"`
+	UpdatedAt   time.Time `json:"updated_at"`
+}
+
+func (rv *ReviewRequest) ToEntity() *entity.Review {
+	return &entity.Review{UserID: rv.UserID, Rating: rv.Rating, Text: rv.Text}
+}
+
+func NewReviewResponse(rv *entity.Review) *ReviewResponse {
+	return &ReviewResponse{
+		ID:          rv.ID,
+		BookID:      rv.BookID,
+		UserID:      rv.UserID,
+		Rating:      rv.Rating,
+		Text:        rv.Text,
+		Status:      rv.Status,
+		ModeratedBy: rv.ModeratedBy,
+		CreatedAt:   rv.CreatedAt,
+		UpdatedAt:   rv.UpdatedAt,
+	}
+}
+
+func NewReviewResponses(reviews []*entity.Review) []*ReviewResponse {
+	res := make([]*ReviewResponse, 0, len(reviews))
+	for _, rv := range reviews {
+		res = append(res, NewReviewResponse(rv))
+	}
+	return res
+}
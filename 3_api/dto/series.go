@@ -0,0 +1,34 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type SeriesRequest struct {
+	Name        string `json:"name"`
+	Description string `json:"description"`
+}
+
+type SeriesResponse struct {
+	ID          int       `json:"id"`
+	Name        string    `json:"name"`
+	Description string    `json:"description"`
+	CreatedAt   time.Time `json:"created_at"`
+}
+
+func (s *SeriesRequest) ToEntity() *entity.Series {
+	return &entity.Series{Name: s.Name, Description: s.Description}
+}
+
+func NewSeriesResponse(s *entity.Series) *SeriesResponse {
+	return &SeriesResponse{ID: s.ID, Name: s.Name, Description: s.Description, CreatedAt: s.CreatedAt}
+}
+
+func NewSeriesResponses(series []*entity.Series) []*SeriesResponse {
+	res := make([]*SeriesResponse, 0, len(series))
+	for _, s := range series {
+		res = append(res, NewSeriesResponse(s))
+	}
+	return res
+}
@@ -0,0 +1,77 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type StocktakeRequest struct {
+	Branch string `json:"branch"`
+}
+
+type StocktakeResponse struct {
+	ID       int        `json:"id"`
+	Branch   string     `json:"branch"`
+	Status   string     `json:"status"`
+	OpenedAt time.Time  `json:"opened_at"`
+	ClosedAt *time.Time `json:"closed_at"`
+}
+
+type StocktakeCountRequest struct {
+	BookID  int `json:"book_id"`
+	Counted int `json:"counted"`
+}
+
+// StocktakeSubmissionRequest is one batch from a scanner, see entity.StocktakeSubmission.
+type StocktakeSubmissionRequest struct {
+	Barcodes []string                 `json:"barcodes"`
+	Counts   []*StocktakeCountRequest `json:"counts"`
+}
+
+type DiscrepancyResponse struct {
+	BookID    int    `json:"book_id"`
+	Barcode   string `json:"barcode"`
+	Kind      string `json:"kind"`
+	Expected  int    `json:"expected"`
+	Counted   int    `json:"counted"`
+	Corrected bool   `json:"corrected"`
+}
+
+type StocktakeReportResponse struct {
+	Stocktake     *StocktakeResponse     `json:"stocktake"`
+	Discrepancies []*DiscrepancyResponse `json:"discrepancies"`
+}
+
+func (s *StocktakeRequest) ToEntity() *entity.Stocktake {
+	return &entity.Stocktake{Branch: s.Branch}
+}
+
+func (s *StocktakeSubmissionRequest) ToEntity() *entity.StocktakeSubmission {
+	sub := &entity.StocktakeSubmission{Barcodes: s.Barcodes}
+	for _, c := range s.Counts {
+		if c == nil {
+			continue
+		}
+		sub.Counts = append(sub.Counts, &entity.StocktakeCount{BookID: c.BookID, Counted: c.Counted})
+	}
+	return sub
+}
+
+func NewStocktakeResponse(s *entity.Stocktake) *StocktakeResponse {
+	return &StocktakeResponse{ID: s.ID, Branch: s.Branch, Status: s.Status, OpenedAt: s.OpenedAt, ClosedAt: s.ClosedAt}
+}
+
+func NewStocktakeReportResponse(r *entity.StocktakeReport) *StocktakeReportResponse {
+	res := &StocktakeReportResponse{Stocktake: NewStocktakeResponse(r.Stocktake), Discrepancies: make([]*DiscrepancyResponse, 0, len(r.Discrepancies))}
+	for _, d := range r.Discrepancies {
+		res.Discrepancies = append(res.Discrepancies, &DiscrepancyResponse{
+			BookID:    d.BookID,
+			Barcode:   d.Barcode,
+			Kind:      d.Kind,
+			Expected:  d.Expected,
+			Counted:   d.Counted,
+			Corrected: d.Corrected,
+		})
+	}
+	return res
+}
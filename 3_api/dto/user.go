@@ -0,0 +1,81 @@
+// Package dto holds the request and response bodies of the v2 API and their
+// mapping to and from the entities, so that storage fields and entity renames
+// don't leak into the wire format.
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+// UserRequest is what clients send to create or update a user. The role, version
+// and timestamps are never taken from the body.
+type UserRequest struct {
+	ID              int       `json:"id,omitempty"`
+	FirstName       string    `json:"first_name"`
+	LastName        string    `json:"last_name"`
+	DOB             time.Time `json:"dob"`
+	Location        string    `json:"location"`
+	CellPhoneNumber string    `json:"cellphone_number"`
+	Email           string    `json:"email"`
+	Password        string    `json:"password,omitempty"`
+}
+
+type UserResponse struct {
+	ID              int       `json:"id"`
+	FirstName       string    `json:"first_name"`
+	LastName        string    `json:"last_name"`
+	DOB             time.Time `json:"dob"`
+	Location        string    `json:"location"`
+	CellPhoneNumber string    `json:"cellphone_number"`
+	Email           string    `json:"email"`
+	Role            string    `json:"role"`
+	EmailVerified   bool      `json:"email_verified"`
+	BorrowedBookIDs []int     `json:"borrowed_book_ids"`
+	Version         int       `json:"version"`
+	CreatedAt       time.Time `json:"created_at"`
+	UpdatedAt       time.Time `json:"updated_at"`
+}
+
+func (u *UserRequest) ToEntity() *entity.User {
+	return &entity.User{
+		ID:              u.ID,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		DOB:             u.DOB,
+		Location:        u.Location,
+		CellPhoneNumber: u.CellPhoneNumber,
+		Email:           u.Email,
+		Password:        u.Password,
+	}
+}
+
+func NewUserResponse(u *entity.User) *UserResponse {
+	books := u.Books
+	if books == nil {
+		books = []int{}
+	}
+	return &UserResponse{
+		ID:              u.ID,
+		FirstName:       u.FirstName,
+		LastName:        u.LastName,
+		DOB:             u.DOB,
+		Location:        u.Location,
+		CellPhoneNumber: u.CellPhoneNumber,
+		Email:           u.Email,
+		Role:            u.Role,
+		EmailVerified:   u.IsVerified(),
+		BorrowedBookIDs: books,
+		Version:         u.Version,
+		CreatedAt:       u.CreatedAt,
+		UpdatedAt:       u.UpdatedAt,
+	}
+}
+
+func NewUserResponses(users []*entity.User) []*UserResponse {
+	res := make([]*UserResponse, 0, len(users))
+	for _, u := range users {
+		res = append(res, NewUserResponse(u))
+	}
+	return res
+}
@@ -0,0 +1,28 @@
+package dto
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestUserRequest_ToEntity(t *testing.T) {
+	dob := time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC)
+	req := &UserRequest{ID: 1, FirstName: "Peter", LastName: "Anderson", DOB: dob, Location: "Canada", CellPhoneNumber: "+16479150167", Email: "Peter@gmail.com", Password: "qwerty12345"}
+
+	want := &entity.User{ID: 1, FirstName: "Peter", LastName: "Anderson", DOB: dob, Location: "Canada", CellPhoneNumber: "+16479150167", Email: "Peter@gmail.com", Password: "qwerty12345"}
+	assert.Equal(t, want, req.ToEntity())
+}
+
+func TestNewUserResponse(t *testing.T) {
+	u := &entity.User{ID: 1, FirstName: "Peter", Password: "qwerty12345", PasswordHash: "hash", Role: entity.RoleLibrarian, Version: 3}
+
+	got := NewUserResponse(u)
+	assert.Equal(t, &UserResponse{ID: 1, FirstName: "Peter", Role: entity.RoleLibrarian, BorrowedBookIDs: []int{}, Version: 3}, got)
+
+	u.Books = []int{4, 5}
+	assert.Equal(t, []int{4, 5}, NewUserResponse(u).BorrowedBookIDs)
+	assert.Len(t, NewUserResponses([]*entity.User{u, u}), 2)
+	assert.NotNil(t, NewUserResponses(nil))
+}
@@ -0,0 +1,184 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strings"
+)
+
+type contextKey int
+
+const currentUserKey contextKey = 0
+
+type AuthHandler struct {
+	authUseCase auth.UseCase
+	version     apiVersion
+}
+
+func NewAuthHandler(a auth.UseCase) *AuthHandler {
+	return &AuthHandler{authUseCase: a}
+}
+
+// V2 serves the same use case in the v2 wire format.
+func (h *AuthHandler) V2() *AuthHandler {
+	return &AuthHandler{authUseCase: h.authUseCase, version: apiV2}
+}
+
+// Middleware puts the user of a valid bearer token into the request context and
+// turns away requests without one. Public routes in the permission table go through
+// either way, so that a client holding an expired access token can still refresh it.
+func (h *AuthHandler) Middleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		public := routePermission(r).public
+
+		tok := bearerToken(r)
+		if tok == "" {
+			if public {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeUnauthorized(w, entity.ErrUnauthorized)
+			return
+		}
+
+		u, err := h.authUseCase.Authenticate(tok)
+		if err != nil {
+			if public && err == entity.ErrUnauthorized {
+				next.ServeHTTP(w, r)
+				return
+			}
+			writeAuthError(w, err)
+			return
+		}
+
+		next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), currentUserKey, u)))
+	})
+}
+
+func (h *AuthHandler) LoginHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var c entity.Credentials
+	err = json.Unmarshal(reqBody, &c)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	tokens, err := h.authUseCase.Login(&c)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJson(w, tokens)
+}
+
+func (h *AuthHandler) RefreshHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var req struct {
+		RefreshToken string `json:"refresh_token"`
+	}
+	err = json.Unmarshal(reqBody, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	tokens, err := h.authUseCase.Refresh(req.RefreshToken)
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	w.Header().Set("Cache-Control", "no-store")
+	writeJson(w, tokens)
+}
+
+// LogoutHandler ends the session of the access token it is called with, which also
+// invalidates the session's refresh token.
+func (h *AuthHandler) LogoutHandler(w http.ResponseWriter, r *http.Request) {
+	err := h.authUseCase.Logout(bearerToken(r))
+	if err != nil {
+		writeAuthError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *AuthHandler) MeHandler(w http.ResponseWriter, r *http.Request) {
+	u := currentUser(r)
+	if u == nil {
+		writeUnauthorized(w, entity.ErrUnauthorized)
+		return
+	}
+
+	w.Header().Set("ETag", etag(u.Version))
+	if h.version == apiV2 {
+		writeJson(w, dto.NewUserResponse(u))
+		return
+	}
+	writeJson(w, u)
+}
+
+// currentUser is the user the middleware authenticated, nil when the route is public.
+func currentUser(r *http.Request) *entity.User {
+	u, _ := r.Context().Value(currentUserKey).(*entity.User)
+	return u
+}
+
+func bearerToken(r *http.Request) string {
+	scheme, tok, found := strings.Cut(r.Header.Get("Authorization"), " ")
+	if !found || !strings.EqualFold(scheme, "Bearer") {
+		return ""
+	}
+	return strings.TrimSpace(tok)
+}
+
+func writeAuthError(w http.ResponseWriter, err error) {
+	if err == entity.ErrUnauthorized {
+		writeUnauthorized(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusInternalServerError)
+	w.Write([]byte(err.Error()))
+}
+
+func writeUnauthorized(w http.ResponseWriter, err error) {
+	w.Header().Set("WWW-Authenticate", `Bearer realm="api"`)
+	w.WriteHeader(http.StatusUnauthorized)
+	w.Write([]byte(err.Error()))
+}
+
+func (h *AuthHandler) MakeAuthHandler(r *mux.Router) {
+	r.HandleFunc("/auth/login", h.LoginHandler).Methods(http.MethodPost)
+	r.HandleFunc("/auth/refresh", h.RefreshHandler).Methods(http.MethodPost)
+	r.HandleFunc("/auth/logout", h.LogoutHandler).Methods(http.MethodPost)
+	r.HandleFunc("/auth/me", h.MeHandler).Methods(http.MethodGet)
+	r.HandleFunc("/auth/verify", h.VerifyHandler).Methods(http.MethodGet)
+	r.HandleFunc("/auth/verify/resend", h.ResendVerificationHandler).Methods(http.MethodPost)
+	r.HandleFunc("/auth/forgot-password", h.ForgotPasswordHandler).Methods(http.MethodPost)
+	r.HandleFunc("/auth/reset-password", h.ResetPasswordHandler).Methods(http.MethodPost)
+}
@@ -0,0 +1,199 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	amock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+var testLibrarian = &entity.User{ID: 100, Role: entity.RoleLibrarian}
+
+// withUser stands in for the auth middleware, signing every request in as u.
+func withUser(u *entity.User) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			next.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), currentUserKey, u)))
+		})
+	}
+}
+
+func TestLoginHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockUseCase(controller)
+	h := NewAuthHandler(m)
+	r := mux.NewRouter()
+	r.Use(h.Middleware)
+	h.MakeAuthHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	tokens := &entity.TokenPair{AccessToken: "a", RefreshToken: "r", TokenType: "Bearer", ExpiresIn: 900}
+	m.EXPECT().Login(&entity.Credentials{Email: "peter@gmail.com", Password: "qwerty12345"}).Return(tokens, nil)
+	m.EXPECT().Login(&entity.Credentials{Email: "peter@gmail.com", Password: "wrong"}).Return(nil, entity.ErrUnauthorized)
+	m.EXPECT().Refresh("r").Return(tokens, nil)
+	m.EXPECT().Refresh("stale").Return(nil, entity.ErrUnauthorized)
+
+	resp, err := http.Post(testServ.URL+"/auth/login", "application/json", strings.NewReader(`{"email":"peter@gmail.com","password":"qwerty12345"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "no-store", resp.Header.Get("Cache-Control"))
+	var got entity.TokenPair
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, *tokens, got)
+
+	tests := []struct {
+		path       string
+		body       string
+		statusCode int
+	}{
+		{path: "/auth/login", body: `{"email":"peter@gmail.com","password":"wrong"}`, statusCode: http.StatusUnauthorized},
+		{path: "/auth/login", body: `{"email":`, statusCode: http.StatusBadRequest},
+		{path: "/auth/refresh", body: `{"refresh_token":"r"}`, statusCode: http.StatusOK},
+		{path: "/auth/refresh", body: `{"refresh_token":"stale"}`, statusCode: http.StatusUnauthorized},
+	}
+	for _, tt := range tests {
+		resp, err = http.Post(testServ.URL+tt.path, "application/json", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+	}
+}
+
+func TestAuthMiddleware(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockUseCase(controller)
+	h := NewAuthHandler(m)
+	r := mux.NewRouter()
+	r.Use(h.Middleware)
+	h.MakeAuthHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	user := &entity.User{ID: 7, FirstName: "Peter", Version: 2}
+	m.EXPECT().Authenticate("good").Return(user, nil).Times(2)
+	m.EXPECT().Authenticate("expired").Return(nil, entity.ErrUnauthorized).Times(2)
+	m.EXPECT().Logout("good").Return(nil)
+	m.EXPECT().Refresh("r").Return(&entity.TokenPair{}, nil)
+
+	tests := []struct {
+		method     string
+		path       string
+		token      string
+		statusCode int
+	}{
+		{method: http.MethodGet, path: "/auth/me", statusCode: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/auth/me", token: "expired", statusCode: http.StatusUnauthorized},
+		{method: http.MethodGet, path: "/auth/me", token: "good", statusCode: http.StatusOK},
+		{method: http.MethodPost, path: "/auth/logout", token: "good", statusCode: http.StatusNoContent},
+		// an expired access token doesn't stand in the way of refreshing it
+		{method: http.MethodPost, path: "/auth/refresh", token: "expired", statusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, testServ.URL+tt.path, strings.NewReader(`{"refresh_token":"r"}`))
+		assert.NoError(t, err)
+		if tt.token != "" {
+			req.Header.Set("Authorization", "Bearer "+tt.token)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+		if tt.statusCode == http.StatusUnauthorized {
+			assert.Equal(t, `Bearer realm="api"`, resp.Header.Get("WWW-Authenticate"))
+		}
+		if tt.path == "/auth/me" && tt.statusCode == http.StatusOK {
+			var got entity.User
+			assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+			assert.Equal(t, 7, got.ID)
+		}
+	}
+}
+
+func TestVerifyHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockUseCase(controller)
+	h := NewAuthHandler(m)
+	r := mux.NewRouter()
+	r.Use(h.Middleware, Authorize)
+	h.MakeAuthHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().VerifyEmail("good").Return(nil)
+	m.EXPECT().VerifyEmail("used").Return(entity.ErrInvalidToken)
+	m.EXPECT().VerifyEmail("old").Return(entity.ErrTokenExpired)
+	m.EXPECT().ResendVerification("peter@gmail.com").Return(nil)
+
+	tests := []struct {
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}{
+		{method: http.MethodGet, path: "/auth/verify?token=good", statusCode: http.StatusOK},
+		{method: http.MethodGet, path: "/auth/verify?token=used", statusCode: http.StatusBadRequest},
+		{method: http.MethodGet, path: "/auth/verify?token=old", statusCode: http.StatusGone},
+		{method: http.MethodPost, path: "/auth/verify/resend", body: `{"email":"peter@gmail.com"}`, statusCode: http.StatusAccepted},
+		{method: http.MethodPost, path: "/auth/verify/resend", body: `{"email":`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, testServ.URL+tt.path, strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, tt.path)
+	}
+}
+
+func TestPasswordResetHandlers(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := amock.NewMockUseCase(controller)
+	h := NewAuthHandler(m)
+	r := mux.NewRouter()
+	r.Use(h.Middleware, Authorize)
+	h.MakeAuthHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().ForgotPassword("peter@gmail.com").Return(nil)
+	m.EXPECT().ResetPassword(&entity.PasswordReset{Token: "good", Password: "newpassword1"}).Return(nil)
+	m.EXPECT().ResetPassword(&entity.PasswordReset{Token: "good", Password: "short"}).Return(entity.ErrWeakPassword)
+	m.EXPECT().ResetPassword(&entity.PasswordReset{Token: "used", Password: "newpassword1"}).Return(entity.ErrInvalidToken)
+	m.EXPECT().ResetPassword(&entity.PasswordReset{Token: "old", Password: "newpassword1"}).Return(entity.ErrTokenExpired)
+
+	tests := []struct {
+		path       string
+		body       string
+		statusCode int
+	}{
+		{path: "/auth/forgot-password", body: `{"email":"peter@gmail.com"}`, statusCode: http.StatusAccepted},
+		{path: "/auth/forgot-password", body: `{"email":`, statusCode: http.StatusBadRequest},
+		{path: "/auth/reset-password", body: `{"token":"good","password":"newpassword1"}`, statusCode: http.StatusNoContent},
+		{path: "/auth/reset-password", body: `{"token":"good","password":"short"}`, statusCode: http.StatusUnprocessableEntity},
+		{path: "/auth/reset-password", body: `{"token":"used","password":"newpassword1"}`, statusCode: http.StatusBadRequest},
+		{path: "/auth/reset-password", body: `{"token":"old","password":"newpassword1"}`, statusCode: http.StatusGone},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(testServ.URL+tt.path, "application/json", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, tt.body)
+	}
+}
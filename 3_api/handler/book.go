@@ -2,8 +2,11 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/mergepatch"
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
@@ -12,12 +15,18 @@ import (
 
 type BookHandler struct {
 	bookUseCase book.UseCase
+	version     apiVersion
 }
 
 func NewBookHandler(b book.UseCase) *BookHandler {
 	return &BookHandler{bookUseCase: b}
 }
 
+// V2 serves the same use case in the v2 wire format.
+func (h *BookHandler) V2() *BookHandler {
+	return &BookHandler{bookUseCase: h.bookUseCase, version: apiV2}
+}
+
 func (h *BookHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -26,15 +35,16 @@ func (h *BookHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var book entity.Book
-	err = json.Unmarshal(reqBody, &book)
+	book, err := h.decodeBook(reqBody)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	err = h.bookUseCase.CreateBook(&book)
+	book.Movement = stockNote(r)
+
+	err = h.bookUseCase.CreateBook(book)
 	if err != nil {
 		if err == entity.ErrConflict {
 			w.WriteHeader(http.StatusConflict)
@@ -42,7 +52,7 @@ func (h *BookHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
-		if err == entity.ErrInvalidEntity {
+		if err == entity.ErrInvalidEntity || err == entity.ErrClientID {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			w.Write([]byte(err.Error()))
 			return
@@ -53,7 +63,18 @@ func (h *BookHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	bookJson, err := json.Marshal(h.represent(book))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", h.version.path("/book/"+strconv.Itoa(book.ID)))
+	w.Header().Set("ETag", etag(book.Version))
 	w.WriteHeader(http.StatusCreated)
+	w.Write(bookJson)
 }
 
 func (h *BookHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -67,12 +88,17 @@ func (h *BookHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 
 	b, err := h.bookUseCase.GetByIDBook(id)
 	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	bookJson, err := json.Marshal(b)
+	bookJson, err := json.Marshal(h.represent(b))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -80,6 +106,7 @@ func (h *BookHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(b.Version))
 	w.Write(bookJson)
 }
 
@@ -91,7 +118,7 @@ func (h *BookHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	booksJson, err := json.Marshal(books)
+	booksJson, err := json.Marshal(h.representAll(books))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -103,6 +130,12 @@ func (h *BookHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *BookHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
 	reqBody, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -110,15 +143,16 @@ func (h *BookHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var book entity.Book
-	err = json.Unmarshal(reqBody, &book)
+	book, err := h.decodeBook(reqBody)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(err.Error()))
 		return
 	}
+	book.Version = version
+	book.Movement = stockNote(r)
 
-	err = h.bookUseCase.UpdateBook(&book)
+	err = h.bookUseCase.UpdateBook(book)
 	if err != nil {
 		if err == entity.ErrNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -126,6 +160,24 @@ func (h *BookHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrBelowOnLoan {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrConflict {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
 		if err == entity.ErrInvalidEntity {
 			w.WriteHeader(http.StatusUnprocessableEntity)
 			w.Write([]byte(err.Error()))
@@ -137,9 +189,125 @@ func (h *BookHandler) UpdateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
+	w.Header().Set("ETag", etag(book.Version))
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *BookHandler) PatchHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if !isMergePatch(r) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		w.Write([]byte("content type must be " + mergePatchContentType))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	current, err := h.bookUseCase.GetByIDBook(id)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	currentJson, err := json.Marshal(h.represent(current))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	merged, err := mergepatch.Apply(currentJson, patch)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	book, err := h.decodeBook(merged)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	book.ID = id
+	book.Version = version
+	book.CreatedAt = current.CreatedAt
+	book.Movement = stockNote(r)
+
+	err = h.bookUseCase.UpdateBook(book)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrBelowOnLoan {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrConflict {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	bookJson, err := json.Marshal(h.represent(book))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(book.Version))
+	w.Write(bookJson)
+}
+
 func (h *BookHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -149,7 +317,13 @@ func (h *BookHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = h.bookUseCase.DeleteBook(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	err = h.bookUseCase.DeleteBook(id, version, r.URL.Query().Get("force"))
 	if err != nil {
 		if err == entity.ErrNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -157,6 +331,24 @@ func (h *BookHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if errors.Is(err, entity.ErrOutstandingLoans) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("force must be return or writeoff"))
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
@@ -165,10 +357,87 @@ func (h *BookHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *BookHandler) RestoreHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	b, err := h.bookUseCase.RestoreBook(id)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrConflict {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	bookJson, err := json.Marshal(h.represent(b))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(b.Version))
+	w.Write(bookJson)
+}
+
+func (h *BookHandler) decodeBook(body []byte) (*entity.Book, error) {
+	if h.version == apiV2 {
+		var req dto.BookRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var b entity.Book
+	err := json.Unmarshal(body, &b)
+	if err != nil {
+		return nil, err
+	}
+	return &b, nil
+}
+
+func (h *BookHandler) represent(b *entity.Book) interface{} {
+	if h.version == apiV2 {
+		return dto.NewBookResponse(b)
+	}
+	return b
+}
+
+func (h *BookHandler) representAll(books []*entity.Book) interface{} {
+	if h.version == apiV2 {
+		return dto.NewBookResponses(books)
+	}
+	return books
+}
+
 func (h *BookHandler) MakeBookHandler(r *mux.Router) {
 	r.HandleFunc("/book", h.CreateHandler).Methods(http.MethodPost)
 	r.HandleFunc("/book/{id:[0-9]+}", h.GetByIDHandler).Methods(http.MethodGet)
 	r.HandleFunc("/book", h.GetAllHandler).Methods(http.MethodGet)
 	r.HandleFunc("/book", h.UpdateHandler).Methods(http.MethodPut)
+	r.HandleFunc("/book/{id:[0-9]+}", h.PatchHandler).Methods(http.MethodPatch)
 	r.HandleFunc("/book/{id:[0-9]+}", h.DeleteHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/book/{id:[0-9]+}/restore", h.RestoreHandler).Methods(http.MethodPost)
+	r.HandleFunc("/book/{id:[0-9]+}/movements", h.MovementsHandler).Methods(http.MethodGet)
+	r.HandleFunc("/book/duplicates", h.DuplicatesHandler).Methods(http.MethodGet)
+	r.HandleFunc("/book/merge", h.MergeHandler).Methods(http.MethodPost)
 }
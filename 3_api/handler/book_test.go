@@ -42,19 +42,49 @@ func TestCreateHandler_Book_Success(t *testing.T) {
 	defer testServ.Close()
 
 	payload := `{"ID":1,"Tittle":"God's Little Acre","Author":"Erskine Caldwell","Pages":224,"Quantity":5,"CreatedAt":"0001-01-01T00:00:00Z","UpdatedAt":"0001-01-01T00:00:00Z"}`
+	payloadMetadata := `{"ID":2,"Tittle":"Tobacco Road","Author":"Erskine Caldwell","Pages":241,"Quantity":2,"Publisher":"Charles Scribner's Sons","PublicationYear":1932,"Edition":"1st","Language":"en","Description":"Sharecroppers in Georgia","Format":"hardcover"}`
 
 	tests := []bookTest{
-		{book: payload, want: wantBook{err: nil, statusCode: http.StatusCreated}},
+		{book: payload, want: wantBook{err: nil, statusCode: http.StatusCreated, book: entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}}},
+		{book: payloadMetadata, want: wantBook{err: nil, statusCode: http.StatusCreated, book: entity.Book{ID: 2, Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2, Publisher: "Charles Scribner's Sons", PublicationYear: 1932, Edition: "1st", Language: "en", Description: "Sharecroppers in Georgia", Format: entity.FormatHardcover}}},
 	}
 
 	for _, bt := range tests {
-		m.EXPECT().CreateBook(gomock.Any()).Return(bt.want.err)
+		want := bt.want.book
+		want.Movement = &entity.Movement{Actor: "anonymous"}
+		m.EXPECT().CreateBook(&want).Return(bt.want.err)
 		resp, err := http.Post(testServ.URL+"/book", "application/json", strings.NewReader(bt.book))
 		assert.NoError(t, err)
 		assert.Equal(t, bt.want.statusCode, resp.StatusCode)
 	}
 }
 
+func TestCreateHandler_Book_GeneratedID(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateBook(&entity.Book{Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, Movement: &entity.Movement{Actor: "anonymous"}}).DoAndReturn(func(book *entity.Book) error {
+		book.ID = 42
+		return nil
+	})
+	resp, err := http.Post(testServ.URL+"/book", "application/json", strings.NewReader(`{"Tittle":"God's Little Acre","Author":"Erskine Caldwell","Pages":224,"Quantity":5}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/book/42", resp.Header.Get("Location"))
+
+	var got entity.Book
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, 42, got.ID)
+}
+
 func TestCreateHandler_Book_Error(t *testing.T) {
 	controller := gomock.NewController(t)
 	defer controller.Finish()
@@ -80,6 +110,7 @@ func TestCreateHandler_Book_Error(t *testing.T) {
 	tests = []bookTest{
 		{book: payload, want: wantBook{err: entity.ErrConflict, statusCode: http.StatusConflict}},
 		{book: payload, want: wantBook{err: entity.ErrInvalidEntity, statusCode: http.StatusUnprocessableEntity}},
+		{book: payload, want: wantBook{err: entity.ErrClientID, statusCode: http.StatusUnprocessableEntity}},
 		{book: payload, want: wantBook{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
 	}
 	for _, bt := range tests {
@@ -103,7 +134,7 @@ func TestGetByIDHandler_Book_Success(t *testing.T) {
 	defer testServ.Close()
 
 	tests := []bookTest{
-		{id: "1", want: wantBook{err: nil, statusCode: http.StatusOK, book: entity.Book{ID: 1}}},
+		{id: "1", want: wantBook{err: nil, statusCode: http.StatusOK, book: entity.Book{ID: 1, Version: 4}}},
 	}
 
 	for _, bt := range tests {
@@ -121,6 +152,7 @@ func TestGetByIDHandler_Book_Success(t *testing.T) {
 
 		assert.Equal(t, bt.want.statusCode, resp.StatusCode)
 		assert.Equal(t, bt.want.book, bookGot)
+		assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
 	}
 }
 
@@ -138,6 +170,7 @@ func TestGetByIDHandler_Book_Error(t *testing.T) {
 
 	tests := []bookTest{
 		{id: "1", want: wantBook{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
+		{id: "2", want: wantBook{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
 	}
 
 	for _, bt := range tests {
@@ -221,10 +254,11 @@ func TestDeleteByIDHandler_Book_Success(t *testing.T) {
 		idInt, err := strconv.Atoi(bt.id)
 		assert.NoError(t, err)
 
-		m.EXPECT().DeleteBook(idInt).Return(bt.want.err)
+		m.EXPECT().DeleteBook(idInt, 1, entity.DeleteRestrict).Return(bt.want.err)
 
 		req, err := http.NewRequest(http.MethodDelete, testServ.URL+"/book/"+bt.id, nil)
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"1"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -249,16 +283,19 @@ func TestDeleteByIDHandler_Book_Error(t *testing.T) {
 	tests := []bookTest{
 		{id: "1", want: wantBook{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
 		{id: "2", want: wantBook{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
+		{id: "3", want: wantBook{err: entity.ErrVersionConflict, statusCode: http.StatusPreconditionFailed}},
+		{id: "4", want: wantBook{err: &entity.OutstandingLoansError{UserIDs: []int{2}}, statusCode: http.StatusConflict}},
 	}
 
 	for _, bt := range tests {
 		idInt, err := strconv.Atoi(bt.id)
 		assert.NoError(t, err)
 
-		m.EXPECT().DeleteBook(idInt).Return(bt.want.err)
+		m.EXPECT().DeleteBook(idInt, 1, entity.DeleteRestrict).Return(bt.want.err)
 
 		req, err := http.NewRequest(http.MethodDelete, testServ.URL+"/book/"+bt.id, nil)
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"1"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -290,6 +327,7 @@ func TestUpdateHandler_Book_Success(t *testing.T) {
 
 		req, err := http.NewRequest(http.MethodPut, testServ.URL+"/book", strings.NewReader(bt.book))
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"1"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -315,6 +353,7 @@ func TestUpdateHandler_Book_Error(t *testing.T) {
 	tests := []bookTest{
 		{book: payload, want: wantBook{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
 		{book: payload, want: wantBook{err: entity.ErrInvalidEntity, statusCode: http.StatusUnprocessableEntity}},
+		{book: payload, want: wantBook{err: entity.ErrVersionConflict, statusCode: http.StatusPreconditionFailed}},
 		{book: payload, want: wantBook{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
 	}
 
@@ -323,6 +362,7 @@ func TestUpdateHandler_Book_Error(t *testing.T) {
 
 		req, err := http.NewRequest(http.MethodPut, testServ.URL+"/book", strings.NewReader(bt.book))
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"1"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -330,3 +370,251 @@ func TestUpdateHandler_Book_Error(t *testing.T) {
 		assert.Equal(t, bt.want.statusCode, resp.StatusCode)
 	}
 }
+
+func TestPatchHandler_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	current := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, Publisher: "Viking", Language: "en"}
+	m.EXPECT().GetByIDBook(1).Return(current, nil)
+	m.EXPECT().UpdateBook(&entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 7, Language: "en", Version: 2, Movement: &entity.Movement{Actor: "anonymous"}}).DoAndReturn(func(b *entity.Book) error {
+		b.Version++
+		return nil
+	})
+
+	req, err := http.NewRequest(http.MethodPatch, testServ.URL+"/book/1", strings.NewReader(`{"Quantity":7,"Publisher":null,"ID":99}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"2"`)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+
+	var got entity.Book
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, 7, got.Quantity)
+	assert.Equal(t, 1, got.ID)
+}
+
+func TestPatchHandler_Book_Error(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	current := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5}
+	tests := []struct {
+		contentType string
+		patch       string
+		errFromGet  error
+		errUpdate   error
+		ttcGet      int
+		ttcUpdate   int
+		statusCode  int
+	}{
+		{contentType: "text/plain", patch: `{"Quantity":7}`, statusCode: http.StatusUnsupportedMediaType},
+		{contentType: "application/merge-patch+json", patch: `{"Quantity":7}`, errFromGet: entity.ErrNotFound, ttcGet: 1, statusCode: http.StatusNotFound},
+		{contentType: "application/merge-patch+json", patch: `{"Quantity":`, ttcGet: 1, statusCode: http.StatusBadRequest},
+		{contentType: "application/merge-patch+json", patch: `{"Quantity":"seven"}`, ttcGet: 1, statusCode: http.StatusBadRequest},
+		{contentType: "application/json", patch: `{"Tittle":null}`, errUpdate: entity.ErrInvalidEntity, ttcGet: 1, ttcUpdate: 1, statusCode: http.StatusUnprocessableEntity},
+		{contentType: "application/json", patch: `{"ISBN":"9780140449136"}`, errUpdate: entity.ErrConflict, ttcGet: 1, ttcUpdate: 1, statusCode: http.StatusConflict},
+		{contentType: "application/json", patch: `{"Quantity":7}`, errUpdate: entity.ErrVersionConflict, ttcGet: 1, ttcUpdate: 1, statusCode: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		m.EXPECT().GetByIDBook(1).Return(current, tt.errFromGet).Times(tt.ttcGet)
+		m.EXPECT().UpdateBook(gomock.Any()).Return(tt.errUpdate).Times(tt.ttcUpdate)
+
+		req, err := http.NewRequest(http.MethodPatch, testServ.URL+"/book/1", strings.NewReader(tt.patch))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", tt.contentType)
+		req.Header.Set("If-Match", `"1"`)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, tt.patch)
+	}
+}
+
+func TestIfMatch_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	payload := `{"ID":1,"Tittle":"God's Little Acre","Author":"Erskine Caldwell","Pages":224,"Quantity":5}`
+	tests := []struct {
+		method     string
+		path       string
+		ifMatch    string
+		statusCode int
+	}{
+		{method: http.MethodPut, path: "/book", statusCode: http.StatusPreconditionRequired},
+		{method: http.MethodPut, path: "/book", ifMatch: `W/"1"`, statusCode: http.StatusPreconditionFailed},
+		{method: http.MethodPatch, path: "/book/1", statusCode: http.StatusPreconditionRequired},
+		{method: http.MethodPatch, path: "/book/1", ifMatch: "1", statusCode: http.StatusPreconditionFailed},
+		{method: http.MethodDelete, path: "/book/1", statusCode: http.StatusPreconditionRequired},
+		{method: http.MethodDelete, path: "/book/1", ifMatch: `"one"`, statusCode: http.StatusPreconditionFailed},
+	}
+
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, testServ.URL+tt.path, strings.NewReader(payload))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		if tt.ifMatch != "" {
+			req.Header.Set("If-Match", tt.ifMatch)
+		}
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, tt.method+" "+tt.ifMatch)
+	}
+}
+
+func TestRestoreHandler_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	tests := []bookTest{
+		{id: "1", want: wantBook{err: nil, statusCode: http.StatusOK, book: entity.Book{ID: 1, Version: 3}}},
+		{id: "2", want: wantBook{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
+		{id: "3", want: wantBook{err: entity.ErrConflict, statusCode: http.StatusConflict}},
+		{id: "4", want: wantBook{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
+	}
+
+	for _, bt := range tests {
+		idInt, err := strconv.Atoi(bt.id)
+		assert.NoError(t, err)
+		if bt.want.err == nil {
+			m.EXPECT().RestoreBook(idInt).Return(&bt.want.book, nil)
+		} else {
+			m.EXPECT().RestoreBook(idInt).Return(nil, bt.want.err)
+		}
+
+		resp, err := http.Post(testServ.URL+"/book/"+bt.id+"/restore", "", nil)
+		assert.NoError(t, err)
+		assert.Equal(t, bt.want.statusCode, resp.StatusCode)
+		if bt.want.err == nil {
+			assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+		}
+	}
+}
+
+func TestDeleteByIDHandler_Book_Force(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	tests := []struct {
+		force      string
+		err        error
+		statusCode int
+	}{
+		{force: entity.DeleteForceReturn, statusCode: http.StatusOK},
+		{force: entity.DeleteWriteOff, statusCode: http.StatusOK},
+		{force: "burn", err: entity.ErrInvalidEntity, statusCode: http.StatusBadRequest},
+	}
+
+	for _, tt := range tests {
+		m.EXPECT().DeleteBook(1, 1, tt.force).Return(tt.err)
+
+		req, err := http.NewRequest(http.MethodDelete, testServ.URL+"/book/1?force="+tt.force, nil)
+		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"1"`)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+	}
+}
+
+func TestMovementsHandler_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	movements := []*entity.Movement{
+		{ID: 1, BookID: 1, Delta: 5, AvailableDelta: 5, Reason: entity.MovementPurchase, Actor: "user 100", Reference: "INV-1042"},
+		{ID: 2, BookID: 1, Delta: 0, AvailableDelta: -1, Reason: entity.MovementLoan, Actor: "user 3"},
+	}
+	m.EXPECT().GetMovementsBook(1).Return(movements, nil)
+	m.EXPECT().GetMovementsBook(2).Return(nil, entity.ErrNotFound)
+
+	resp, err := http.Get(testServ.URL + "/book/1/movements")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []*entity.Movement
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, movements, got)
+
+	resp, err = http.Get(testServ.URL + "/book/2/movements")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestUpdateHandler_Book_Movement(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	r.Use(withUser(testLibrarian))
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().UpdateBook(gomock.Any()).DoAndReturn(func(b *entity.Book) error {
+		assert.Equal(t, &entity.Movement{Reason: entity.MovementPurchase, Actor: "user 100", Reference: "INV-1042"}, b.Movement)
+		return nil
+	})
+
+	payload := `{"ID":1,"Tittle":"God's Little Acre","Author":"Erskine Caldwell","Pages":224,"Quantity":7}`
+	req, err := http.NewRequest(http.MethodPut, testServ.URL+"/book?reason=purchase&reference=INV-1042", strings.NewReader(payload))
+	assert.NoError(t, err)
+	req.Header.Set("If-Match", `"1"`)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
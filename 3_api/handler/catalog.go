@@ -0,0 +1,77 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/marc"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+type CatalogHandler struct {
+	catalogUseCase catalog.UseCase
+}
+
+func NewCatalogHandler(c catalog.UseCase) *CatalogHandler {
+	return &CatalogHandler{catalogUseCase: c}
+}
+
+func (h *CatalogHandler) ExportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		format = catalog.FormatMARCXML
+	}
+
+	var ids []int
+	for _, v := range r.URL.Query()["id"] {
+		id, err := strconv.Atoi(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		ids = append(ids, id)
+	}
+
+	// buffer the export so that a failure halfway still gets a proper status code
+	var buf bytes.Buffer
+	err := h.catalogUseCase.Export(&buf, format, ids)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidEntity) {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// MARCXML has no length limits
+		if errors.Is(err, marc.ErrTooLong) {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(err.Error() + "; export it as marcxml instead"))
+			return
+		}
+
+		if errors.Is(err, entity.ErrNotFound) {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if format == catalog.FormatMARC {
+		w.Header().Set("Content-Type", "application/marc")
+	} else {
+		w.Header().Set("Content-Type", "application/marcxml+xml")
+	}
+	w.Write(buf.Bytes())
+}
+
+func (h *CatalogHandler) MakeCatalogHandler(r *mux.Router) {
+	r.HandleFunc("/book/export", h.ExportHandler).Methods(http.MethodGet)
+}
@@ -0,0 +1,64 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	catmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+type exportTest struct {
+	query      string
+	format     string
+	ids        []int
+	err        error
+	statusCode int
+}
+
+func TestExportHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := catmock.NewMockUseCase(controller)
+	h := NewCatalogHandler(m)
+	r := mux.NewRouter()
+	h.MakeCatalogHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	tests := []exportTest{
+		{query: "", format: "marcxml", statusCode: http.StatusOK},
+		{query: "?format=marc&id=1&id=2", format: "marc", ids: []int{1, 2}, statusCode: http.StatusOK},
+		{query: "?format=pdf", format: "pdf", err: fmt.Errorf("unsupported: %w", entity.ErrInvalidEntity), statusCode: http.StatusBadRequest},
+		{query: "?id=9", format: "marcxml", ids: []int{9}, err: entity.ErrNotFound, statusCode: http.StatusNotFound},
+		{query: "", format: "marcxml", err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError},
+	}
+
+	for _, et := range tests {
+		m.EXPECT().Export(gomock.Any(), et.format, et.ids).DoAndReturn(func(w io.Writer, format string, ids []int) error {
+			w.Write([]byte("<collection/>"))
+			return et.err
+		})
+
+		resp, err := http.Get(testServ.URL + "/book/export" + et.query)
+		assert.NoError(t, err)
+		assert.Equal(t, et.statusCode, resp.StatusCode)
+		if et.err == nil {
+			body, _ := io.ReadAll(resp.Body)
+			assert.Equal(t, "<collection/>", string(body))
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Get(testServ.URL + "/book/export?id=abc")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
@@ -0,0 +1,131 @@
+package handler
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/cover"
+	"github.com/gorilla/mux"
+	"io"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+type CoverHandler struct {
+	coverUseCase cover.UseCase
+}
+
+func NewCoverHandler(c cover.UseCase) *CoverHandler {
+	return &CoverHandler{coverUseCase: c}
+}
+
+func (h *CoverHandler) UploadHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	// one extra byte lets the usecase tell an exactly-max upload from an oversized one
+	r.Body = http.MaxBytesReader(w, r.Body, cover.MaxSize+1)
+
+	var body io.Reader = r.Body
+	mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if mediaType == "multipart/form-data" {
+		file, _, err := r.FormFile("cover")
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+		defer file.Close()
+		body = file
+	}
+
+	data, err := io.ReadAll(body)
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(entity.ErrImageTooLarge.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.coverUseCase.UploadCover(id, data)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrUnsupportedImage {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrImageTooLarge {
+			w.WriteHeader(http.StatusRequestEntityTooLarge)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *CoverHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	c, err := h.coverUseCase.GetCover(id, r.URL.Query().Get("size"))
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("unknown cover size"))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	sum := sha256.Sum256(c.Data)
+	w.Header().Set("Content-Type", c.ContentType)
+	w.Header().Set("Cache-Control", "public, max-age=86400")
+	w.Header().Set("ETag", `"`+hex.EncodeToString(sum[:16])+`"`)
+	// ServeContent answers If-None-Match/If-Modified-Since with 304 for us
+	http.ServeContent(w, r, "", c.UpdatedAt, bytes.NewReader(c.Data))
+}
+
+func (h *CoverHandler) MakeCoverHandler(r *mux.Router) {
+	r.HandleFunc("/book/{id:[0-9]+}/cover", h.UploadHandler).Methods(http.MethodPut)
+	r.HandleFunc("/book/{id:[0-9]+}/cover", h.GetHandler).Methods(http.MethodGet)
+}
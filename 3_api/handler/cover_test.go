@@ -0,0 +1,122 @@
+package handler
+
+import (
+	"bytes"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	cmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/cover/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"mime/multipart"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+type coverTest struct {
+	path string
+	err  error
+	want int
+}
+
+func TestUploadCoverHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := cmock.NewMockUseCase(controller)
+	h := NewCoverHandler(m)
+	r := mux.NewRouter()
+	h.MakeCoverHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	tests := []coverTest{
+		{err: nil, want: http.StatusOK},
+		{err: entity.ErrNotFound, want: http.StatusNotFound},
+		{err: entity.ErrUnsupportedImage, want: http.StatusUnsupportedMediaType},
+		{err: entity.ErrImageTooLarge, want: http.StatusRequestEntityTooLarge},
+		{err: errors.New("some internal server error"), want: http.StatusInternalServerError},
+	}
+
+	for _, ct := range tests {
+		m.EXPECT().UploadCover(1, []byte("raw image")).Return(ct.err)
+
+		req, err := http.NewRequest(http.MethodPut, testServ.URL+"/book/1/cover", bytes.NewReader([]byte("raw image")))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "image/png")
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+
+		assert.Equal(t, ct.want, resp.StatusCode)
+	}
+
+	//multipart uploads read the "cover" field
+	var body bytes.Buffer
+	mw := multipart.NewWriter(&body)
+	fw, err := mw.CreateFormFile("cover", "cover.png")
+	assert.NoError(t, err)
+	fw.Write([]byte("multipart image"))
+	mw.Close()
+
+	m.EXPECT().UploadCover(2, []byte("multipart image")).Return(nil)
+	req, err := http.NewRequest(http.MethodPut, testServ.URL+"/book/2/cover", &body)
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", mw.FormDataContentType())
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestGetCoverHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := cmock.NewMockUseCase(controller)
+	h := NewCoverHandler(m)
+	r := mux.NewRouter()
+	h.MakeCoverHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	c := &entity.Cover{BookID: 1, Size: entity.CoverSizeSmall, ContentType: "image/png", Data: []byte("small png"), UpdatedAt: time.Date(2023, 3, 1, 0, 0, 0, 0, time.UTC)}
+	m.EXPECT().GetCover(1, entity.CoverSizeSmall).Return(c, nil).Times(2)
+
+	resp, err := http.Get(testServ.URL + "/book/1/cover?size=small")
+	assert.NoError(t, err)
+	respBody, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	resp.Body.Close()
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, c.Data, respBody)
+	assert.Equal(t, "image/png", resp.Header.Get("Content-Type"))
+	assert.Equal(t, "public, max-age=86400", resp.Header.Get("Cache-Control"))
+	etag := resp.Header.Get("ETag")
+	assert.NotEmpty(t, etag)
+
+	req, err := http.NewRequest(http.MethodGet, testServ.URL+"/book/1/cover?size=small", nil)
+	assert.NoError(t, err)
+	req.Header.Set("If-None-Match", etag)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotModified, resp.StatusCode)
+
+	tests := []coverTest{
+		{path: "/book/2/cover", err: entity.ErrNotFound, want: http.StatusNotFound},
+		{path: "/book/2/cover?size=huge", err: entity.ErrInvalidEntity, want: http.StatusBadRequest},
+		{path: "/book/2/cover", err: errors.New("some internal server error"), want: http.StatusInternalServerError},
+	}
+
+	for _, ct := range tests {
+		m.EXPECT().GetCover(2, gomock.Any()).Return(nil, ct.err)
+		resp, err := http.Get(testServ.URL + ct.path)
+		assert.NoError(t, err)
+
+		assert.Equal(t, ct.want, resp.StatusCode)
+	}
+}
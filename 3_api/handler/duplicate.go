@@ -0,0 +1,104 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+// DuplicatesHandler lists likely duplicates for review; ?threshold= (0 to 1] tunes
+// how alike title and author must be.
+func (h *BookHandler) DuplicatesHandler(w http.ResponseWriter, r *http.Request) {
+	threshold := book.DefaultDuplicateThreshold
+	if v := r.URL.Query().Get("threshold"); v != "" {
+		var err error
+		threshold, err = strconv.ParseFloat(v, 64)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	candidates, err := h.bookUseCase.FindDuplicates(threshold)
+	if err != nil {
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("threshold must be above 0 and at most 1"))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if candidates == nil {
+		candidates = []*entity.DuplicateCandidate{}
+	}
+
+	if h.version == apiV2 {
+		writeJson(w, dto.NewDuplicateResponses(candidates))
+		return
+	}
+	writeJson(w, candidates)
+}
+
+func (h *BookHandler) MergeHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	merge, err := h.decodeMerge(reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	survivor, err := h.bookUseCase.MergeBooks(merge, requestActor(r))
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("ETag", etag(survivor.Version))
+	writeJson(w, h.represent(survivor))
+}
+
+func (h *BookHandler) decodeMerge(body []byte) (*entity.BookMerge, error) {
+	if h.version == apiV2 {
+		var req dto.MergeRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var merge entity.BookMerge
+	err := json.Unmarshal(body, &merge)
+	if err != nil {
+		return nil, err
+	}
+	return &merge, nil
+}
@@ -0,0 +1,91 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDuplicatesHandler_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	candidates := []*entity.DuplicateCandidate{{Book: &entity.Book{ID: 1, Tittle: "Tobacco Road"}, Other: &entity.Book{ID: 5, Tittle: "Tobaco Road"}, Score: 0.93, Reason: entity.DuplicateTitleAuthor}}
+	m.EXPECT().FindDuplicates(0.85).Return(candidates, nil)
+	m.EXPECT().FindDuplicates(0.95).Return(nil, nil)
+	m.EXPECT().FindDuplicates(2.0).Return(nil, entity.ErrInvalidEntity)
+
+	resp, err := http.Get(testServ.URL + "/book/duplicates")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []*entity.DuplicateCandidate
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, candidates, got)
+
+	resp, err = http.Get(testServ.URL + "/book/duplicates?threshold=0.95")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	got = nil
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, []*entity.DuplicateCandidate{}, got)
+
+	for _, threshold := range []string{"2", "high"} {
+		resp, err = http.Get(testServ.URL + "/book/duplicates?threshold=" + threshold)
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+	}
+}
+
+func TestMergeHandler_Book(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	r.Use(withUser(testLibrarian))
+	h.MakeBookHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	survivor := &entity.Book{ID: 1, Tittle: "Tobacco Road", Quantity: 7, Available: 6, Version: 4}
+	m.EXPECT().MergeBooks(&entity.BookMerge{SurvivorID: 1, DuplicateIDs: []int{5}}, "user 100").Return(survivor, nil)
+	m.EXPECT().MergeBooks(&entity.BookMerge{SurvivorID: 1, DuplicateIDs: []int{9}}, "user 100").Return(nil, entity.ErrNotFound)
+	m.EXPECT().MergeBooks(&entity.BookMerge{SurvivorID: 1}, "user 100").Return(nil, entity.ErrInvalidEntity)
+
+	tests := []struct {
+		body       string
+		statusCode int
+	}{
+		{body: `{"SurvivorID":1,"DuplicateIDs":[5]}`, statusCode: http.StatusOK},
+		{body: `{"SurvivorID":1,"DuplicateIDs":[9]}`, statusCode: http.StatusNotFound},
+		{body: `{"SurvivorID":1}`, statusCode: http.StatusUnprocessableEntity},
+		{body: `{"SurvivorID":`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(http.MethodPost, testServ.URL+"/book/merge", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+		if tt.statusCode == http.StatusOK {
+			assert.Equal(t, `"4"`, resp.Header.Get("ETag"))
+		}
+	}
+}
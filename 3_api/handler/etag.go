@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"net/http"
+	"strconv"
+	"strings"
+)
+
+var errIfMatchRequired = errors.New("If-Match header is required")
+
+func etag(version int) string {
+	return `"` + strconv.Itoa(version) + `"`
+}
+
+// ifMatchVersion reads the version the client last saw from If-Match; a tag we
+// could never have issued can't match, so it reports a version conflict.
+func ifMatchVersion(r *http.Request) (int, error) {
+	tag := strings.TrimSpace(r.Header.Get("If-Match"))
+	if tag == "" {
+		return 0, errIfMatchRequired
+	}
+
+	if len(tag) < 2 || tag[0] != '"' || tag[len(tag)-1] != '"' {
+		return 0, entity.ErrVersionConflict
+	}
+	version, err := strconv.Atoi(tag[1 : len(tag)-1])
+	if err != nil {
+		return 0, entity.ErrVersionConflict
+	}
+	return version, nil
+}
+
+func writeIfMatchError(w http.ResponseWriter, err error) {
+	if err == errIfMatchRequired {
+		w.WriteHeader(http.StatusPreconditionRequired)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusPreconditionFailed)
+	w.Write([]byte(err.Error()))
+}
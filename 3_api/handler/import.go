@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/bookimport"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"mime"
+	"net/http"
+	"strconv"
+)
+
+const maxImportSize = 32 << 20
+
+type ImportHandler struct {
+	importUseCase bookimport.UseCase
+	version       apiVersion
+}
+
+func NewImportHandler(i bookimport.UseCase) *ImportHandler {
+	return &ImportHandler{importUseCase: i}
+}
+
+// V2 serves the same use case in the v2 wire format.
+func (h *ImportHandler) V2() *ImportHandler {
+	return &ImportHandler{importUseCase: h.importUseCase, version: apiV2}
+}
+
+func (h *ImportHandler) ImportHandler(w http.ResponseWriter, r *http.Request) {
+	format := r.URL.Query().Get("format")
+	if format == "" {
+		mediaType, _, _ := mime.ParseMediaType(r.Header.Get("Content-Type"))
+		switch mediaType {
+		case "text/csv":
+			format = bookimport.FormatCSV
+		case "application/x-ndjson", "application/jsonl":
+			format = bookimport.FormatJSONL
+		case "application/marc":
+			format = catalog.FormatMARC
+		case "application/marcxml+xml":
+			format = catalog.FormatMARCXML
+		}
+	}
+
+	dryRun := false
+	if v := r.URL.Query().Get("dry_run"); v != "" {
+		var err error
+		dryRun, err = strconv.ParseBool(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte(err.Error()))
+			return
+		}
+	}
+
+	report, err := h.importUseCase.Import(http.MaxBytesReader(w, r.Body, maxImportSize), format, dryRun)
+	if err != nil {
+		if errors.Is(err, entity.ErrInvalidEntity) {
+			w.WriteHeader(http.StatusUnsupportedMediaType)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var body interface{} = report
+	if h.version == apiV2 {
+		body = dto.NewImportReportResponse(report)
+	}
+	reportJson, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(reportJson)
+}
+
+func (h *ImportHandler) MakeImportHandler(r *mux.Router) {
+	r.HandleFunc("/book/import", h.ImportHandler).Methods(http.MethodPost)
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	imock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/bookimport/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+type importTest struct {
+	query       string
+	contentType string
+	format      string
+	dryRun      bool
+	err         error
+	statusCode  int
+}
+
+func TestImportHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := imock.NewMockUseCase(controller)
+	h := NewImportHandler(m)
+	r := mux.NewRouter()
+	h.MakeImportHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	report := &entity.ImportReport{Created: 1, Rows: []*entity.ImportRow{{Row: 2, ID: 1, Status: entity.ImportCreated}}}
+
+	tests := []importTest{
+		{contentType: "text/csv", format: "csv", statusCode: http.StatusOK},
+		{query: "?format=jsonl&dry_run=true", contentType: "text/plain", format: "jsonl", dryRun: true, statusCode: http.StatusOK},
+		{contentType: "application/x-ndjson; charset=utf-8", format: "jsonl", statusCode: http.StatusOK},
+		{contentType: "application/pdf", format: "", err: fmt.Errorf("unsupported: %w", entity.ErrInvalidEntity), statusCode: http.StatusUnsupportedMediaType},
+		{contentType: "text/csv", format: "csv", err: errors.New("reading csv header: EOF"), statusCode: http.StatusBadRequest},
+	}
+
+	for _, it := range tests {
+		if it.err != nil {
+			m.EXPECT().Import(gomock.Any(), it.format, it.dryRun).Return(nil, it.err)
+		} else {
+			m.EXPECT().Import(gomock.Any(), it.format, it.dryRun).Return(report, nil)
+		}
+
+		resp, err := http.Post(testServ.URL+"/book/import"+it.query, it.contentType, strings.NewReader("payload"))
+		assert.NoError(t, err)
+		assert.Equal(t, it.statusCode, resp.StatusCode)
+
+		if it.err == nil {
+			var reportGot entity.ImportReport
+			err = json.NewDecoder(resp.Body).Decode(&reportGot)
+			assert.NoError(t, err)
+			assert.Equal(t, *report, reportGot)
+		}
+		resp.Body.Close()
+	}
+
+	resp, err := http.Post(testServ.URL+"/book/import?dry_run=maybe", "text/csv", strings.NewReader("payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
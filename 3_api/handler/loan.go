@@ -32,7 +32,7 @@ func (l *LoanHandler) BorrowHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = l.LoanUseCase.Borrow(userID, bookID)
+	err = l.LoanUseCase.Borrow(currentUser(r), userID, bookID)
 	if err != nil {
 		if errors.Is(err, entity.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -40,6 +40,19 @@ func (l *LoanHandler) BorrowHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err == entity.ErrForbidden || err == entity.ErrNotVerified {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// ErrConflict: another request lent the user the same book meanwhile
+		if errors.Is(err, entity.ErrVersionConflict) || errors.Is(err, entity.ErrNoCopiesAvailable) || err == entity.ErrConflict {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
@@ -64,7 +77,7 @@ func (l *LoanHandler) ReturnHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	err = l.LoanUseCase.Return(userID, bookID)
+	err = l.LoanUseCase.Return(currentUser(r), userID, bookID)
 	if err != nil {
 		if errors.Is(err, entity.ErrNotFound) {
 			w.WriteHeader(http.StatusNotFound)
@@ -72,6 +85,19 @@ func (l *LoanHandler) ReturnHandler(w http.ResponseWriter, r *http.Request) {
 			return
 		}
 
+		if err == entity.ErrForbidden {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		// ErrNotBorrowed: another request returned the book meanwhile
+		if errors.Is(err, entity.ErrVersionConflict) || errors.Is(err, entity.ErrNoCopiesAvailable) || err == entity.ErrNotBorrowed {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
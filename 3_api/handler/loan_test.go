@@ -31,6 +31,7 @@ func TestBorrowHandler_Success(t *testing.T) {
 	h := NewLoanHandler(m)
 	r := mux.NewRouter()
 	h.MakeLoanHandler(r)
+	r.Use(withUser(testLibrarian))
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
@@ -45,7 +46,7 @@ func TestBorrowHandler_Success(t *testing.T) {
 		bIDInt, err := strconv.Atoi(lt.bID)
 		assert.NoError(t, err)
 
-		m.EXPECT().Borrow(uIDInt, bIDInt).Return(lt.want.err)
+		m.EXPECT().Borrow(testLibrarian, uIDInt, bIDInt).Return(lt.want.err)
 		resp, err := http.Get(fmt.Sprintf("%s/loan/borrow/%s/%s", testServ.URL, lt.uID, lt.bID))
 		assert.NoError(t, err)
 
@@ -61,6 +62,7 @@ func TestBorrowHandler_Error(t *testing.T) {
 	h := NewLoanHandler(m)
 	r := mux.NewRouter()
 	h.MakeLoanHandler(r)
+	r.Use(withUser(testLibrarian))
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
@@ -69,6 +71,7 @@ func TestBorrowHandler_Error(t *testing.T) {
 		{uID: "1", bID: "1", want: wantLoan{err: fmt.Errorf("user %w", entity.ErrNotFound), statusCode: http.StatusNotFound}},
 		{uID: "2", bID: "2", want: wantLoan{err: fmt.Errorf("book %w", entity.ErrNotFound), statusCode: http.StatusNotFound}},
 		{uID: "3", bID: "3", want: wantLoan{err: fmt.Errorf("some internal server error"), statusCode: http.StatusInternalServerError}},
+		{uID: "4", bID: "4", want: wantLoan{err: entity.ErrNotVerified, statusCode: http.StatusForbidden}},
 	}
 
 	for _, lt := range tests {
@@ -77,7 +80,7 @@ func TestBorrowHandler_Error(t *testing.T) {
 		bIDInt, err := strconv.Atoi(lt.bID)
 		assert.NoError(t, err)
 
-		m.EXPECT().Borrow(uIDInt, bIDInt).Return(lt.want.err)
+		m.EXPECT().Borrow(testLibrarian, uIDInt, bIDInt).Return(lt.want.err)
 		resp, err := http.Get(fmt.Sprintf("%s/loan/borrow/%s/%s", testServ.URL, lt.uID, lt.bID))
 		assert.NoError(t, err)
 
@@ -93,6 +96,7 @@ func TestReturnHandler_Success(t *testing.T) {
 	h := NewLoanHandler(m)
 	r := mux.NewRouter()
 	h.MakeLoanHandler(r)
+	r.Use(withUser(testLibrarian))
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
@@ -107,7 +111,7 @@ func TestReturnHandler_Success(t *testing.T) {
 		bIDInt, err := strconv.Atoi(lt.bID)
 		assert.NoError(t, err)
 
-		m.EXPECT().Return(uIDInt, bIDInt).Return(lt.want.err)
+		m.EXPECT().Return(testLibrarian, uIDInt, bIDInt).Return(lt.want.err)
 		resp, err := http.Get(fmt.Sprintf("%s/loan/return/%s/%s", testServ.URL, lt.uID, lt.bID))
 		assert.NoError(t, err)
 
@@ -124,6 +128,7 @@ func TestReturnHandler_Error(t *testing.T) {
 	h := NewLoanHandler(m)
 	r := mux.NewRouter()
 	h.MakeLoanHandler(r)
+	r.Use(withUser(testLibrarian))
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
@@ -140,7 +145,7 @@ func TestReturnHandler_Error(t *testing.T) {
 		bIDInt, err := strconv.Atoi(lt.bID)
 		assert.NoError(t, err)
 
-		m.EXPECT().Return(uIDInt, bIDInt).Return(lt.want.err)
+		m.EXPECT().Return(testLibrarian, uIDInt, bIDInt).Return(lt.want.err)
 		resp, err := http.Get(fmt.Sprintf("%s/loan/return/%s/%s", testServ.URL, lt.uID, lt.bID))
 		assert.NoError(t, err)
 
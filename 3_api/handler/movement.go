@@ -0,0 +1,67 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// stockNote describes a request's change of quantity for the inventory ledger. The
+// reason comes from ?reason= (purchase, loss or correction) and the reference from
+// ?reference=, e.g. an invoice number.
+func stockNote(r *http.Request) *entity.Movement {
+	return &entity.Movement{
+		Reason:    r.URL.Query().Get("reason"),
+		Actor:     requestActor(r),
+		Reference: r.URL.Query().Get("reference"),
+	}
+}
+
+// requestActor names who made the request for the audit trail. Only the session is
+// trusted; anything the client says about itself is not.
+func requestActor(r *http.Request) string {
+	if u := currentUser(r); u != nil {
+		return "user " + strconv.Itoa(u.ID)
+	}
+	return "anonymous"
+}
+
+func (h *BookHandler) MovementsHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	movements, err := h.bookUseCase.GetMovementsBook(id)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var body interface{} = movements
+	if h.version == apiV2 {
+		body = dto.NewMovementResponses(movements)
+	}
+	movementsJson, err := json.Marshal(body)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(movementsJson)
+}
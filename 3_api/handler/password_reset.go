@@ -0,0 +1,68 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"io"
+	"net/http"
+)
+
+// ForgotPasswordHandler mails a reset token, {"email":"..."}. It answers 202 whether
+// or not the address has an account.
+func (h *AuthHandler) ForgotPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	err = json.Unmarshal(reqBody, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.authUseCase.ForgotPassword(req.Email)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+// ResetPasswordHandler sets a new password with a reset token, {"token":"...","password":"..."}.
+func (h *AuthHandler) ResetPasswordHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var req entity.PasswordReset
+	err = json.Unmarshal(reqBody, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.authUseCase.ResetPassword(&req)
+	if err != nil {
+		if err == entity.ErrWeakPassword {
+			writeUnprocessable(w, err)
+			return
+		}
+		writeTokenError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
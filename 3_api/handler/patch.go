@@ -0,0 +1,16 @@
+package handler
+
+import (
+	"mime"
+	"net/http"
+)
+
+const mergePatchContentType = "application/merge-patch+json"
+
+func isMergePatch(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil {
+		return false
+	}
+	return mediaType == mergePatchContentType || mediaType == "application/json"
+}
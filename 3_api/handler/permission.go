@@ -0,0 +1,143 @@
+package handler
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/gorilla/mux"
+	"net/http"
+	"strconv"
+)
+
+// permission says who may call a route: anyone when public, otherwise a signed-in
+// user with one of roles or, when self names a path parameter, the user whose id it holds.
+type permission struct {
+	public bool
+	roles  []string
+	self   string
+}
+
+var (
+	anyone   = permission{public: true}
+	signedIn = permission{roles: []string{entity.RoleMember, entity.RoleLibrarian, entity.RoleAdmin}}
+	staff    = permission{roles: []string{entity.RoleLibrarian, entity.RoleAdmin}}
+	admin    = permission{roles: []string{entity.RoleAdmin}}
+)
+
+// selfOrStaff lets members at their own account, given by the path parameter param.
+func selfOrStaff(param string) permission {
+	return permission{roles: staff.roles, self: param}
+}
+
+// permissions is keyed by method and route template, as registered on the router
+// but without the version prefix. Routes missing here are left to admins.
+var permissions = map[string]permission{
+	"POST /auth/login":               anyone,
+	"POST /auth/refresh":             anyone,
+	"POST /auth/logout":              signedIn,
+	"GET /auth/me":                   signedIn,
+	"GET /auth/verify":               anyone,
+	"POST /auth/verify/resend":       anyone,
+	"POST /auth/forgot-password":     anyone,
+	"POST /auth/reset-password":      anyone,
+	"POST /user":                     anyone,
+	"GET /user":                      staff,
+	"GET /user/{id:[0-9]+}":          selfOrStaff("id"),
+	"PUT /user":                      signedIn, // the handler checks the id in the body
+	"PATCH /user/{id:[0-9]+}":        selfOrStaff("id"),
+	"DELETE /user/{id:[0-9]+}":       selfOrStaff("id"),
+	"POST /user/{id:[0-9]+}/restore": staff,
+	"PUT /user/{id:[0-9]+}/role":     admin,
+
+	"GET /book":                       signedIn,
+	"GET /book/{id:[0-9]+}":           signedIn,
+	"POST /book":                      staff,
+	"PUT /book":                       staff,
+	"PATCH /book/{id:[0-9]+}":         staff,
+	"DELETE /book/{id:[0-9]+}":        staff,
+	"POST /book/{id:[0-9]+}/restore":  staff,
+	"GET /book/{id:[0-9]+}/movements": staff,
+	"GET /book/duplicates":            staff,
+	"POST /book/merge":                staff,
+	"POST /book/import":               staff,
+	"GET /book/export":                staff,
+	"GET /book/{id:[0-9]+}/cover":     signedIn,
+	"PUT /book/{id:[0-9]+}/cover":     staff,
+	"GET /book/{id:[0-9]+}/next":      signedIn,
+	"GET /book/{id:[0-9]+}/reviews":   signedIn,
+	"POST /book/{id:[0-9]+}/reviews":  signedIn,
+
+	"GET /loan/borrow/{u_id:[0-9]+}/{b_id:[0-9]+}": selfOrStaff("u_id"),
+	"GET /loan/return/{u_id:[0-9]+}/{b_id:[0-9]+}": selfOrStaff("u_id"),
+
+	"GET /review":                      staff,
+	"GET /review/{id:[0-9]+}":          signedIn,
+	"DELETE /review/{id:[0-9]+}":       staff,
+	"POST /review/{id:[0-9]+}/hide":    staff,
+	"POST /review/{id:[0-9]+}/publish": staff,
+
+	"GET /series":                   signedIn,
+	"POST /series":                  staff,
+	"GET /series/{id:[0-9]+}":       signedIn,
+	"GET /series/{id:[0-9]+}/books": signedIn,
+
+	"POST /stocktake":                    staff,
+	"GET /stocktake/{id:[0-9]+}":         staff,
+	"POST /stocktake/{id:[0-9]+}/counts": staff,
+	"GET /stocktake/{id:[0-9]+}/report":  staff,
+	"POST /stocktake/{id:[0-9]+}/close":  staff,
+}
+
+func routePermission(r *http.Request) permission {
+	route := mux.CurrentRoute(r)
+	if route == nil {
+		return admin
+	}
+	tpl, err := route.GetPathTemplate()
+	if err != nil {
+		return admin
+	}
+
+	p, ok := permissions[r.Method+" "+unversioned(tpl)]
+	if !ok {
+		return admin
+	}
+	return p
+}
+
+// Authorize enforces the permission table; it runs after the auth middleware
+// has put the signed-in user into the context.
+func Authorize(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		p := routePermission(r)
+		if p.public {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		u := currentUser(r)
+		if u == nil {
+			writeUnauthorized(w, entity.ErrUnauthorized)
+			return
+		}
+		if !p.allows(u, r) {
+			w.WriteHeader(http.StatusForbidden)
+			w.Write([]byte(entity.ErrForbidden.Error()))
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+func (p permission) allows(u *entity.User, r *http.Request) bool {
+	for _, role := range p.roles {
+		if u.Role == role {
+			return true
+		}
+	}
+	if p.self == "" {
+		return false
+	}
+
+	id, err := strconv.Atoi(mux.Vars(r)[p.self])
+	return err == nil && id == u.ID
+}
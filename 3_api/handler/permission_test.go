@@ -0,0 +1,93 @@
+package handler
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+)
+
+func TestPermissions_EveryRoute(t *testing.T) {
+	r := mux.NewRouter()
+	NewAuthHandler(nil).MakeAuthHandler(r)
+	NewUserHandler(nil).MakeUserHandler(r)
+	NewImportHandler(nil).MakeImportHandler(r)
+	NewCatalogHandler(nil).MakeCatalogHandler(r)
+	NewBookHandler(nil).MakeBookHandler(r)
+	NewLoanHandler(nil).MakeLoanHandler(r)
+	NewCoverHandler(nil).MakeCoverHandler(r)
+	NewStocktakeHandler(nil).MakeStocktakeHandler(r)
+	NewSeriesHandler(nil).MakeSeriesHandler(r)
+	NewReviewHandler(nil).MakeReviewHandler(r)
+	v2 := r.PathPrefix("/v2").Subrouter()
+	NewAuthHandler(nil).V2().MakeAuthHandler(v2)
+	NewUserHandler(nil).V2().MakeUserHandler(v2)
+	NewBookHandler(nil).V2().MakeBookHandler(v2)
+	NewLoanHandler(nil).MakeLoanHandler(v2)
+
+	err := r.Walk(func(route *mux.Route, router *mux.Router, ancestors []*mux.Route) error {
+		if route.GetHandler() == nil {
+			// a version prefix, walked into next
+			return nil
+		}
+		tpl, err := route.GetPathTemplate()
+		if err != nil {
+			return err
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return err
+		}
+		for _, m := range methods {
+			_, ok := permissions[m+" "+unversioned(tpl)]
+			assert.True(t, ok, "no permission for %s %s", m, tpl)
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+}
+
+func TestAuthorize(t *testing.T) {
+	ok := func(w http.ResponseWriter, r *http.Request) {}
+
+	tests := []struct {
+		user       *entity.User
+		method     string
+		path       string
+		statusCode int
+	}{
+		{user: nil, method: http.MethodPost, path: "/user", statusCode: http.StatusOK},
+		{user: nil, method: http.MethodGet, path: "/book", statusCode: http.StatusUnauthorized},
+		{user: &entity.User{ID: 7, Role: entity.RoleMember}, method: http.MethodGet, path: "/book", statusCode: http.StatusOK},
+		{user: &entity.User{ID: 7, Role: entity.RoleMember}, method: http.MethodPost, path: "/book", statusCode: http.StatusForbidden},
+		{user: testLibrarian, method: http.MethodPost, path: "/book", statusCode: http.StatusOK},
+		{user: &entity.User{ID: 7, Role: entity.RoleMember}, method: http.MethodGet, path: "/loan/borrow/7/1", statusCode: http.StatusOK},
+		{user: &entity.User{ID: 7, Role: entity.RoleMember}, method: http.MethodGet, path: "/loan/borrow/8/1", statusCode: http.StatusForbidden},
+		{user: testLibrarian, method: http.MethodGet, path: "/loan/borrow/8/1", statusCode: http.StatusOK},
+		{user: testLibrarian, method: http.MethodPut, path: "/user/7/role", statusCode: http.StatusForbidden},
+		{user: &entity.User{ID: 1, Role: entity.RoleAdmin}, method: http.MethodPut, path: "/user/7/role", statusCode: http.StatusOK},
+		{user: &entity.User{ID: 7, Role: entity.RoleMember}, method: http.MethodPost, path: "/v2/book", statusCode: http.StatusForbidden},
+		{user: testLibrarian, method: http.MethodPost, path: "/v2/book", statusCode: http.StatusOK},
+		// a route nobody listed is left to admins
+		{user: testLibrarian, method: http.MethodGet, path: "/unlisted", statusCode: http.StatusForbidden},
+	}
+	for _, tt := range tests {
+		r := mux.NewRouter()
+		if tt.user != nil {
+			r.Use(withUser(tt.user))
+		}
+		r.Use(Authorize)
+		r.HandleFunc("/user", ok).Methods(http.MethodPost)
+		r.HandleFunc("/book", ok).Methods(http.MethodGet, http.MethodPost)
+		r.HandleFunc("/loan/borrow/{u_id:[0-9]+}/{b_id:[0-9]+}", ok).Methods(http.MethodGet)
+		r.HandleFunc("/user/{id:[0-9]+}/role", ok).Methods(http.MethodPut)
+		r.HandleFunc("/unlisted", ok).Methods(http.MethodGet)
+		r.HandleFunc("/v2/book", ok).Methods(http.MethodPost)
+
+		w := httptest.NewRecorder()
+		r.ServeHTTP(w, httptest.NewRequest(tt.method, tt.path, nil))
+		assert.Equal(t, tt.statusCode, w.Code, "%s %s", tt.method, tt.path)
+	}
+}
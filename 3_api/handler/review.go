@@ -0,0 +1,217 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/review"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type ReviewHandler struct {
+	reviewUseCase review.UseCase
+	version       apiVersion
+}
+
+func NewReviewHandler(rv review.UseCase) *ReviewHandler {
+	return &ReviewHandler{reviewUseCase: rv}
+}
+
+// V2 serves the same use case in the v2 wire format.
+func (h *ReviewHandler) V2() *ReviewHandler {
+	return &ReviewHandler{reviewUseCase: h.reviewUseCase, version: apiV2}
+}
+
+func (h *ReviewHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	bookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	rv, err := h.decodeReview(reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	rv.BookID = bookID
+	if u := currentUser(r); u != nil {
+		// signed-in patrons review as themselves
+		rv.UserID = u.ID
+	}
+
+	err = h.reviewUseCase.CreateReview(rv)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	rvJson, err := json.Marshal(h.represent(rv))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", h.version.path("/review/"+strconv.Itoa(rv.ID)))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(rvJson)
+}
+
+func (h *ReviewHandler) BookReviewsHandler(w http.ResponseWriter, r *http.Request) {
+	bookID, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	reviews, err := h.reviewUseCase.GetByBookReviews(bookID)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeJson(w, h.representAll(reviews))
+}
+
+func (h *ReviewHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	rv, err := h.reviewUseCase.GetByIDReview(id)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeJson(w, h.represent(rv))
+}
+
+// GetAllHandler is the librarians' moderation queue, ?status=hidden or ?status=published.
+func (h *ReviewHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
+	reviews, err := h.reviewUseCase.GetAllReviews(r.URL.Query().Get("status"))
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeJson(w, h.representAll(reviews))
+}
+
+func (h *ReviewHandler) HideHandler(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, entity.ReviewHidden)
+}
+
+func (h *ReviewHandler) PublishHandler(w http.ResponseWriter, r *http.Request) {
+	h.moderate(w, r, entity.ReviewPublished)
+}
+
+func (h *ReviewHandler) moderate(w http.ResponseWriter, r *http.Request, status string) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	rv, err := h.reviewUseCase.ModerateReview(id, status, requestActor(r))
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	writeJson(w, h.represent(rv))
+}
+
+func (h *ReviewHandler) DeleteHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.reviewUseCase.DeleteReview(id)
+	if err != nil {
+		writeReviewError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func writeReviewError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case entity.ErrNotBorrowed:
+		w.WriteHeader(http.StatusForbidden)
+	case entity.ErrConflict:
+		w.WriteHeader(http.StatusConflict)
+	case entity.ErrInvalidEntity:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write([]byte(err.Error()))
+}
+
+func (h *ReviewHandler) decodeReview(body []byte) (*entity.Review, error) {
+	if h.version == apiV2 {
+		var req dto.ReviewRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var rv entity.Review
+	err := json.Unmarshal(body, &rv)
+	if err != nil {
+		return nil, err
+	}
+	return &rv, nil
+}
+
+func (h *ReviewHandler) represent(rv *entity.Review) interface{} {
+	if h.version == apiV2 {
+		return dto.NewReviewResponse(rv)
+	}
+	return rv
+}
+
+func (h *ReviewHandler) representAll(reviews []*entity.Review) interface{} {
+	if h.version == apiV2 {
+		return dto.NewReviewResponses(reviews)
+	}
+	return reviews
+}
+
+func (h *ReviewHandler) MakeReviewHandler(r *mux.Router) {
+	r.HandleFunc("/book/{id:[0-9]+}/reviews", h.CreateHandler).Methods(http.MethodPost)
+	r.HandleFunc("/book/{id:[0-9]+}/reviews", h.BookReviewsHandler).Methods(http.MethodGet)
+	r.HandleFunc("/review", h.GetAllHandler).Methods(http.MethodGet)
+	r.HandleFunc("/review/{id:[0-9]+}", h.GetByIDHandler).Methods(http.MethodGet)
+	r.HandleFunc("/review/{id:[0-9]+}", h.DeleteHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/review/{id:[0-9]+}/hide", h.HideHandler).Methods(http.MethodPost)
+	r.HandleFunc("/review/{id:[0-9]+}/publish", h.PublishHandler).Methods(http.MethodPost)
+}
@@ -0,0 +1,121 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	rmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/review/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateHandler_Review(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockUseCase(controller)
+	h := NewReviewHandler(m)
+	r := mux.NewRouter()
+	h.MakeReviewHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateReview(&entity.Review{BookID: 1, UserID: 2, Rating: 5}).DoAndReturn(func(rv *entity.Review) error {
+		rv.ID = 3
+		return nil
+	})
+	m.EXPECT().CreateReview(&entity.Review{BookID: 1, UserID: 4, Rating: 5}).Return(entity.ErrNotBorrowed)
+	m.EXPECT().CreateReview(&entity.Review{BookID: 1, UserID: 2, Rating: 4}).Return(entity.ErrConflict)
+	m.EXPECT().CreateReview(&entity.Review{BookID: 1, UserID: 2, Rating: 9}).Return(entity.ErrInvalidEntity)
+
+	tests := []struct {
+		body       string
+		statusCode int
+	}{
+		{body: `{"UserID":2,"Rating":5}`, statusCode: http.StatusCreated},
+		{body: `{"UserID":4,"Rating":5}`, statusCode: http.StatusForbidden},
+		{body: `{"UserID":2,"Rating":4}`, statusCode: http.StatusConflict},
+		{body: `{"UserID":2,"Rating":9}`, statusCode: http.StatusUnprocessableEntity},
+		{body: `{"UserID":`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(testServ.URL+"/book/1/reviews", "application/json", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+		if tt.statusCode == http.StatusCreated {
+			assert.Equal(t, "/review/3", resp.Header.Get("Location"))
+		}
+	}
+}
+
+func TestBookReviewsHandler_Review(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockUseCase(controller)
+	h := NewReviewHandler(m)
+	r := mux.NewRouter()
+	h.MakeReviewHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	reviews := []*entity.Review{{ID: 3, BookID: 1, UserID: 2, Rating: 5, Status: entity.ReviewPublished}}
+	m.EXPECT().GetByBookReviews(1).Return(reviews, nil)
+	m.EXPECT().GetByBookReviews(2).Return(nil, entity.ErrNotFound)
+
+	resp, err := http.Get(testServ.URL + "/book/1/reviews")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []*entity.Review
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, reviews, got)
+
+	resp, err = http.Get(testServ.URL + "/book/2/reviews")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestModerationHandlers_Review(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockUseCase(controller)
+	h := NewReviewHandler(m)
+	r := mux.NewRouter()
+	r.Use(withUser(testLibrarian))
+	h.MakeReviewHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().ModerateReview(3, entity.ReviewHidden, "user 100").Return(&entity.Review{ID: 3, Status: entity.ReviewHidden, ModeratedBy: "user 100"}, nil)
+	m.EXPECT().ModerateReview(4, entity.ReviewPublished, "user 100").Return(nil, entity.ErrNotFound)
+	m.EXPECT().GetAllReviews(entity.ReviewHidden).Return([]*entity.Review{}, nil)
+	m.EXPECT().GetAllReviews("spam").Return(nil, entity.ErrInvalidEntity)
+	m.EXPECT().DeleteReview(3).Return(nil)
+
+	tests := []struct {
+		method     string
+		path       string
+		statusCode int
+	}{
+		{method: http.MethodPost, path: "/review/3/hide", statusCode: http.StatusOK},
+		{method: http.MethodPost, path: "/review/4/publish", statusCode: http.StatusNotFound},
+		{method: http.MethodGet, path: "/review?status=hidden", statusCode: http.StatusOK},
+		{method: http.MethodGet, path: "/review?status=spam", statusCode: http.StatusUnprocessableEntity},
+		{method: http.MethodDelete, path: "/review/3", statusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		req, err := http.NewRequest(tt.method, testServ.URL+tt.path, nil)
+		assert.NoError(t, err)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+	}
+}
@@ -0,0 +1,182 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/series"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type SeriesHandler struct {
+	seriesUseCase series.UseCase
+	version       apiVersion
+}
+
+func NewSeriesHandler(s series.UseCase) *SeriesHandler {
+	return &SeriesHandler{seriesUseCase: s}
+}
+
+// V2 serves the same use case in the v2 wire format.
+func (h *SeriesHandler) V2() *SeriesHandler {
+	return &SeriesHandler{seriesUseCase: h.seriesUseCase, version: apiV2}
+}
+
+func (h *SeriesHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	s, err := h.decodeSeries(reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.seriesUseCase.CreateSeries(s)
+	if err != nil {
+		writeSeriesError(w, err)
+		return
+	}
+
+	sJson, err := json.Marshal(h.represent(s))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", h.version.path("/series/"+strconv.Itoa(s.ID)))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(sJson)
+}
+
+func (h *SeriesHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	s, err := h.seriesUseCase.GetByIDSeries(id)
+	if err != nil {
+		writeSeriesError(w, err)
+		return
+	}
+
+	writeJson(w, h.represent(s))
+}
+
+func (h *SeriesHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
+	s, err := h.seriesUseCase.GetAllSeries()
+	if err != nil {
+		writeSeriesError(w, err)
+		return
+	}
+
+	var body interface{} = s
+	if h.version == apiV2 {
+		body = dto.NewSeriesResponses(s)
+	}
+	writeJson(w, body)
+}
+
+func (h *SeriesHandler) BooksHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	books, err := h.seriesUseCase.GetBooksSeries(id)
+	if err != nil {
+		writeSeriesError(w, err)
+		return
+	}
+
+	var body interface{} = books
+	if h.version == apiV2 {
+		body = dto.NewBookResponses(books)
+	}
+	writeJson(w, body)
+}
+
+// NextHandler answers "what do I read next": the following volume with its available copies.
+func (h *SeriesHandler) NextHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	next, err := h.seriesUseCase.NextInSeries(id)
+	if err != nil {
+		writeSeriesError(w, err)
+		return
+	}
+
+	w.Header().Set("ETag", etag(next.Version))
+	var body interface{} = next
+	if h.version == apiV2 {
+		body = dto.NewBookResponse(next)
+	}
+	writeJson(w, body)
+}
+
+func writeSeriesError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrNotFound, entity.ErrNotInSeries:
+		w.WriteHeader(http.StatusNotFound)
+	case entity.ErrConflict:
+		w.WriteHeader(http.StatusConflict)
+	case entity.ErrInvalidEntity:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write([]byte(err.Error()))
+}
+
+func (h *SeriesHandler) decodeSeries(body []byte) (*entity.Series, error) {
+	if h.version == apiV2 {
+		var req dto.SeriesRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var s entity.Series
+	err := json.Unmarshal(body, &s)
+	if err != nil {
+		return nil, err
+	}
+	return &s, nil
+}
+
+func (h *SeriesHandler) represent(s *entity.Series) interface{} {
+	if h.version == apiV2 {
+		return dto.NewSeriesResponse(s)
+	}
+	return s
+}
+
+func (h *SeriesHandler) MakeSeriesHandler(r *mux.Router) {
+	r.HandleFunc("/series", h.CreateHandler).Methods(http.MethodPost)
+	r.HandleFunc("/series", h.GetAllHandler).Methods(http.MethodGet)
+	r.HandleFunc("/series/{id:[0-9]+}", h.GetByIDHandler).Methods(http.MethodGet)
+	r.HandleFunc("/series/{id:[0-9]+}/books", h.BooksHandler).Methods(http.MethodGet)
+	r.HandleFunc("/book/{id:[0-9]+}/next", h.NextHandler).Methods(http.MethodGet)
+}
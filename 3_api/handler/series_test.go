@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	sermock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/series/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestCreateHandler_Series(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockUseCase(controller)
+	h := NewSeriesHandler(m)
+	r := mux.NewRouter()
+	h.MakeSeriesHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateSeries(&entity.Series{Name: "The Expanse"}).DoAndReturn(func(s *entity.Series) error {
+		s.ID = 1
+		return nil
+	})
+	m.EXPECT().CreateSeries(&entity.Series{Name: "The Expanse"}).Return(entity.ErrConflict)
+	m.EXPECT().CreateSeries(&entity.Series{}).Return(entity.ErrInvalidEntity)
+
+	tests := []struct {
+		body       string
+		statusCode int
+	}{
+		{body: `{"Name":"The Expanse"}`, statusCode: http.StatusCreated},
+		{body: `{"Name":"The Expanse"}`, statusCode: http.StatusConflict},
+		{body: `{}`, statusCode: http.StatusUnprocessableEntity},
+		{body: `{"Name":`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(testServ.URL+"/series", "application/json", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+	}
+}
+
+func TestBooksHandler_Series(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockUseCase(controller)
+	h := NewSeriesHandler(m)
+	r := mux.NewRouter()
+	h.MakeSeriesHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	books := []*entity.Book{{ID: 3, Tittle: "Leviathan Wakes", SeriesID: 1, Volume: 1}, {ID: 2, Tittle: "Caliban's War", SeriesID: 1, Volume: 2}}
+	m.EXPECT().GetBooksSeries(1).Return(books, nil)
+	m.EXPECT().GetBooksSeries(2).Return(nil, entity.ErrNotFound)
+
+	resp, err := http.Get(testServ.URL + "/series/1/books")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got []*entity.Book
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, books, got)
+
+	resp, err = http.Get(testServ.URL + "/series/2/books")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestNextHandler_Series(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockUseCase(controller)
+	h := NewSeriesHandler(m)
+	r := mux.NewRouter()
+	h.MakeSeriesHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	next := &entity.Book{ID: 2, Tittle: "Caliban's War", SeriesID: 1, Volume: 2, Quantity: 3, Available: 1, Version: 5}
+	m.EXPECT().NextInSeries(3).Return(next, nil)
+	m.EXPECT().NextInSeries(2).Return(nil, entity.ErrNotFound)
+	m.EXPECT().NextInSeries(7).Return(nil, entity.ErrNotInSeries)
+
+	resp, err := http.Get(testServ.URL + "/book/3/next")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"5"`, resp.Header.Get("ETag"))
+	var got entity.Book
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, next, &got)
+
+	for _, id := range []string{"2", "7"} {
+		resp, err = http.Get(testServ.URL + "/book/" + id + "/next")
+		assert.NoError(t, err)
+		assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+	}
+}
@@ -0,0 +1,238 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/stocktake"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/gorilla/mux"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+type StocktakeHandler struct {
+	stocktakeUseCase stocktake.UseCase
+	version          apiVersion
+}
+
+func NewStocktakeHandler(s stocktake.UseCase) *StocktakeHandler {
+	return &StocktakeHandler{stocktakeUseCase: s}
+}
+
+// V2 serves the same use case in the v2 wire format.
+func (h *StocktakeHandler) V2() *StocktakeHandler {
+	return &StocktakeHandler{stocktakeUseCase: h.stocktakeUseCase, version: apiV2}
+}
+
+func (h *StocktakeHandler) OpenHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	st, err := h.decodeStocktake(reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.stocktakeUseCase.OpenStocktake(st)
+	if err != nil {
+		writeStocktakeError(w, err)
+		return
+	}
+
+	stJson, err := json.Marshal(h.represent(st))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", h.version.path("/stocktake/"+strconv.Itoa(st.ID)))
+	w.WriteHeader(http.StatusCreated)
+	w.Write(stJson)
+}
+
+func (h *StocktakeHandler) GetHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	st, err := h.stocktakeUseCase.GetStocktake(id)
+	if err != nil {
+		writeStocktakeError(w, err)
+		return
+	}
+
+	writeJson(w, h.represent(st))
+}
+
+// SubmitHandler takes a batch of scanned barcodes and hand counts; batches add up.
+func (h *StocktakeHandler) SubmitHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	sub, err := h.decodeSubmission(reqBody)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.stocktakeUseCase.SubmitCounts(id, sub)
+	if err != nil {
+		writeStocktakeError(w, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusOK)
+}
+
+func (h *StocktakeHandler) ReportHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	report, err := h.stocktakeUseCase.Report(id)
+	if err != nil {
+		writeStocktakeError(w, err)
+		return
+	}
+
+	writeJson(w, h.representReport(report))
+}
+
+// CloseHandler closes the session; ?apply=true also writes the counts to the inventory ledger.
+func (h *StocktakeHandler) CloseHandler(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	apply := false
+	if v := r.URL.Query().Get("apply"); v != "" {
+		apply, err = strconv.ParseBool(v)
+		if err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("apply must be true or false"))
+			return
+		}
+	}
+
+	report, err := h.stocktakeUseCase.CloseStocktake(id, apply, requestActor(r))
+	if err != nil {
+		writeStocktakeError(w, err)
+		return
+	}
+
+	writeJson(w, h.representReport(report))
+}
+
+func writeStocktakeError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrNotFound:
+		w.WriteHeader(http.StatusNotFound)
+	case entity.ErrConflict, entity.ErrStocktakeClosed, entity.ErrVersionConflict:
+		w.WriteHeader(http.StatusConflict)
+	case entity.ErrInvalidEntity:
+		w.WriteHeader(http.StatusUnprocessableEntity)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write([]byte(err.Error()))
+}
+
+func (h *StocktakeHandler) decodeStocktake(body []byte) (*entity.Stocktake, error) {
+	if h.version == apiV2 {
+		var req dto.StocktakeRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var st entity.Stocktake
+	err := json.Unmarshal(body, &st)
+	if err != nil {
+		return nil, err
+	}
+	return &st, nil
+}
+
+func (h *StocktakeHandler) decodeSubmission(body []byte) (*entity.StocktakeSubmission, error) {
+	if h.version == apiV2 {
+		var req dto.StocktakeSubmissionRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var sub entity.StocktakeSubmission
+	err := json.Unmarshal(body, &sub)
+	if err != nil {
+		return nil, err
+	}
+	return &sub, nil
+}
+
+func (h *StocktakeHandler) represent(st *entity.Stocktake) interface{} {
+	if h.version == apiV2 {
+		return dto.NewStocktakeResponse(st)
+	}
+	return st
+}
+
+func (h *StocktakeHandler) representReport(report *entity.StocktakeReport) interface{} {
+	if h.version == apiV2 {
+		return dto.NewStocktakeReportResponse(report)
+	}
+	return report
+}
+
+func writeJson(w http.ResponseWriter, v interface{}) {
+	body, err := json.Marshal(v)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(body)
+}
+
+func (h *StocktakeHandler) MakeStocktakeHandler(r *mux.Router) {
+	r.HandleFunc("/stocktake", h.OpenHandler).Methods(http.MethodPost)
+	r.HandleFunc("/stocktake/{id:[0-9]+}", h.GetHandler).Methods(http.MethodGet)
+	r.HandleFunc("/stocktake/{id:[0-9]+}/counts", h.SubmitHandler).Methods(http.MethodPost)
+	r.HandleFunc("/stocktake/{id:[0-9]+}/report", h.ReportHandler).Methods(http.MethodGet)
+	r.HandleFunc("/stocktake/{id:[0-9]+}/close", h.CloseHandler).Methods(http.MethodPost)
+}
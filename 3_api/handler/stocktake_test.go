@@ -0,0 +1,115 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	smock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/stocktake/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestOpenHandler_Stocktake(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockUseCase(controller)
+	h := NewStocktakeHandler(m)
+	r := mux.NewRouter()
+	h.MakeStocktakeHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().OpenStocktake(&entity.Stocktake{Branch: "main"}).DoAndReturn(func(st *entity.Stocktake) error {
+		st.ID = 3
+		st.Status = entity.StocktakeOpen
+		return nil
+	})
+	m.EXPECT().OpenStocktake(&entity.Stocktake{Branch: "main"}).Return(entity.ErrConflict)
+
+	resp, err := http.Post(testServ.URL+"/stocktake", "application/json", strings.NewReader(`{"Branch":"main"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/stocktake/3", resp.Header.Get("Location"))
+
+	resp, err = http.Post(testServ.URL+"/stocktake", "application/json", strings.NewReader(`{"Branch":"main"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestSubmitHandler_Stocktake(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockUseCase(controller)
+	h := NewStocktakeHandler(m)
+	r := mux.NewRouter()
+	h.MakeStocktakeHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	sub := &entity.StocktakeSubmission{Barcodes: []string{"9780140449136"}, Counts: []*entity.StocktakeCount{{BookID: 1, Counted: 3}}}
+	m.EXPECT().SubmitCounts(1, sub).Return(nil)
+	m.EXPECT().SubmitCounts(2, sub).Return(entity.ErrStocktakeClosed)
+
+	tests := []struct {
+		id         string
+		body       string
+		statusCode int
+	}{
+		{id: "1", body: `{"Barcodes":["9780140449136"],"Counts":[{"BookID":1,"Counted":3}]}`, statusCode: http.StatusOK},
+		{id: "2", body: `{"Barcodes":["9780140449136"],"Counts":[{"BookID":1,"Counted":3}]}`, statusCode: http.StatusConflict},
+		{id: "1", body: `{"Barcodes":`, statusCode: http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		resp, err := http.Post(testServ.URL+"/stocktake/"+tt.id+"/counts", "application/json", strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode)
+	}
+}
+
+func TestCloseHandler_Stocktake(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockUseCase(controller)
+	h := NewStocktakeHandler(m)
+	r := mux.NewRouter()
+	r.Use(withUser(testLibrarian))
+	h.MakeStocktakeHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	report := &entity.StocktakeReport{
+		Stocktake:     &entity.Stocktake{ID: 1, Branch: "main", Status: entity.StocktakeClosed},
+		Discrepancies: []*entity.Discrepancy{{BookID: 2, Kind: entity.DiscrepancyMiscounted, Expected: 3, Counted: 2, Corrected: true}},
+	}
+	m.EXPECT().CloseStocktake(1, true, "user 100").Return(report, nil)
+	m.EXPECT().CloseStocktake(2, false, "user 100").Return(nil, entity.ErrNotFound)
+
+	req, err := http.NewRequest(http.MethodPost, testServ.URL+"/stocktake/1/close?apply=true", nil)
+	assert.NoError(t, err)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got entity.StocktakeReport
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, report, &got)
+
+	req, err = http.NewRequest(http.MethodPost, testServ.URL+"/stocktake/2/close", nil)
+	assert.NoError(t, err)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	resp, err = http.Post(testServ.URL+"/stocktake/1/close?apply=maybe", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+}
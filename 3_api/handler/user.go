@@ -2,22 +2,34 @@ package handler
 
 import (
 	"encoding/json"
+	"errors"
+	"fmt"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/dto"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/mergepatch"
 	"github.com/gorilla/mux"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
 )
 
 type UserHandler struct {
 	userUsecase user.UseCase
+	version     apiVersion
 }
 
 func NewUserHandler(u user.UseCase) *UserHandler {
 	return &UserHandler{userUsecase: u}
 }
 
+// V2 serves the same use case in the v2 wire format.
+func (h *UserHandler) V2() *UserHandler {
+	return &UserHandler{userUsecase: h.userUsecase, version: apiV2}
+}
+
 func (h *UserHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 	body, err := io.ReadAll(r.Body)
 	if err != nil {
@@ -26,25 +38,23 @@ func (h *UserHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	var u entity.User
-	err = json.Unmarshal(body, &u)
+	u, err := h.decodeUser(body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
-	err = h.userUsecase.CreateUser(&u)
+	err = h.userUsecase.CreateUser(u)
 	if err != nil {
-		if err == entity.ErrConflict {
+		if err == entity.ErrConflict || err == entity.ErrEmailTaken {
 			w.WriteHeader(http.StatusConflict)
 			w.Write([]byte(err.Error()))
 			return
 		}
 
-		if err == entity.ErrInvalidEntity {
-			w.WriteHeader(http.StatusUnprocessableEntity)
-			w.Write([]byte(err.Error()))
+		if errors.Is(err, entity.ErrInvalidEntity) || err == entity.ErrWeakPassword || err == entity.ErrClientID {
+			writeUnprocessable(w, err)
 			return
 		}
 
@@ -52,7 +62,19 @@ func (h *UserHandler) CreateHandler(w http.ResponseWriter, r *http.Request) {
 		w.Write([]byte(err.Error()))
 		return
 	}
+
+	userJson, err := json.Marshal(h.represent(u))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("Location", h.version.path("/user/"+strconv.Itoa(u.ID)))
+	w.Header().Set("ETag", etag(u.Version))
 	w.WriteHeader(http.StatusCreated)
+	w.Write(userJson)
 }
 
 func (h *UserHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
@@ -76,7 +98,7 @@ func (h *UserHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	userJson, err := json.Marshal(u)
+	userJson, err := json.Marshal(h.represent(u))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -84,18 +106,40 @@ func (h *UserHandler) GetByIDHandler(w http.ResponseWriter, r *http.Request) {
 	}
 
 	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(u.Version))
 	w.Write(userJson)
 }
 
+// GetAllHandler lists a page of users, ?q= searching name, email and phone number,
+// filtered by ?location= and ?has_overdue=, ordered by ?sort= and paged by ?page= and
+// ?per_page=. X-Total-Count and Link tell how many there are and where the neighbours are.
 func (h *UserHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
-	users, err := h.userUsecase.GetAllUsers()
+	filter, err := userFilter(r.URL.Query())
+	if err != nil {
+		writeUnprocessable(w, err)
+		return
+	}
+
+	users, total, err := h.userUsecase.SearchUsers(filter)
 	if err != nil {
+		if errors.Is(err, entity.ErrInvalidEntity) {
+			writeUnprocessable(w, err)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
 	}
+	if users == nil {
+		users = []*entity.User{}
+	}
+	w.Header().Set("X-Total-Count", strconv.Itoa(total))
+	if links := pageLinks(r.URL, filter, total); links != "" {
+		w.Header().Set("Link", links)
+	}
 
-	usersJson, err := json.Marshal(users)
+	usersJson, err := json.Marshal(h.representAll(users))
 	if err != nil {
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
@@ -107,6 +151,12 @@ func (h *UserHandler) GetAllHandler(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *UserHandler) UpdateByIDHandler(w http.ResponseWriter, r *http.Request) {
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
 	jsonUser, err := io.ReadAll(r.Body)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
@@ -114,15 +164,15 @@ func (h *UserHandler) UpdateByIDHandler(w http.ResponseWriter, r *http.Request)
 		return
 	}
 
-	var user entity.User
-	err = json.Unmarshal(jsonUser, &user)
+	user, err := h.decodeUser(jsonUser)
 	if err != nil {
 		w.WriteHeader(http.StatusBadRequest)
 		w.Write([]byte(err.Error()))
 		return
 	}
+	user.Version = version
 
-	err = h.userUsecase.UpdateUser(&user)
+	current, err := h.userUsecase.GetByIDUser(user.ID)
 	if err != nil {
 		if err == entity.ErrNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -130,20 +180,162 @@ func (h *UserHandler) UpdateByIDHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
-		if err == entity.ErrInvalidEntity {
-			w.WriteHeader(http.StatusUnprocessableEntity)
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	if !h.authorizeEdit(w, r, user.ID, current, user.Password != "") {
+		return
+	}
+	// borrowed books only change through the loan endpoints
+	user.Books = current.Books
+
+	err = h.userUsecase.UpdateUser(user)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrEmailTaken {
+			w.WriteHeader(http.StatusConflict)
 			w.Write([]byte(err.Error()))
 			return
 		}
 
+		if errors.Is(err, entity.ErrInvalidEntity) || err == entity.ErrWeakPassword {
+			writeUnprocessable(w, err)
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
 	}
 
+	w.Header().Set("ETag", etag(user.Version))
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *UserHandler) PatchByIDHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	if !isMergePatch(r) {
+		w.WriteHeader(http.StatusUnsupportedMediaType)
+		w.Write([]byte("content type must be " + mergePatchContentType))
+		return
+	}
+
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	patch, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	current, err := h.userUsecase.GetByIDUser(id)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	currentJson, err := json.Marshal(h.represent(current))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	merged, err := mergepatch.Apply(currentJson, patch)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	user, err := h.decodeUser(merged)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+	user.ID = id
+	user.Version = version
+	user.CreatedAt = current.CreatedAt
+	if !h.authorizeEdit(w, r, id, current, user.Password != "") {
+		return
+	}
+	// borrowed books only change through the loan endpoints
+	user.Books = current.Books
+
+	err = h.userUsecase.UpdateUser(user)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrEmailTaken {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if errors.Is(err, entity.ErrInvalidEntity) || err == entity.ErrWeakPassword {
+			writeUnprocessable(w, err)
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	userJson, err := json.Marshal(h.represent(user))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(user.Version))
+	w.Write(userJson)
+}
+
 func (h *UserHandler) DeleteByIDHandler(w http.ResponseWriter, r *http.Request) {
 	vars := mux.Vars(r)
 	id, err := strconv.Atoi(vars["id"])
@@ -152,7 +344,17 @@ func (h *UserHandler) DeleteByIDHandler(w http.ResponseWriter, r *http.Request)
 		w.Write([]byte(err.Error()))
 	}
 
-	err = h.userUsecase.DeleteUser(id)
+	version, err := ifMatchVersion(r)
+	if err != nil {
+		writeIfMatchError(w, err)
+		return
+	}
+
+	if !h.authorizeEdit(w, r, id, nil, false) {
+		return
+	}
+
+	err = h.userUsecase.DeleteUser(id, version, r.URL.Query().Get("force"))
 	if err != nil {
 		if err == entity.ErrNotFound {
 			w.WriteHeader(http.StatusNotFound)
@@ -160,6 +362,24 @@ func (h *UserHandler) DeleteByIDHandler(w http.ResponseWriter, r *http.Request)
 			return
 		}
 
+		if err == entity.ErrVersionConflict {
+			w.WriteHeader(http.StatusPreconditionFailed)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if errors.Is(err, entity.ErrOutstandingLoans) {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusBadRequest)
+			w.Write([]byte("force must be return or writeoff"))
+			return
+		}
+
 		w.WriteHeader(http.StatusInternalServerError)
 		w.Write([]byte(err.Error()))
 		return
@@ -168,10 +388,246 @@ func (h *UserHandler) DeleteByIDHandler(w http.ResponseWriter, r *http.Request)
 	w.WriteHeader(http.StatusOK)
 }
 
+func (h *UserHandler) RestoreByIDHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	u, err := h.userUsecase.RestoreUser(id)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrConflict || err == entity.ErrEmailTaken {
+			w.WriteHeader(http.StatusConflict)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	userJson, err := json.Marshal(h.represent(u))
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.Header().Set("ETag", etag(u.Version))
+	w.Write(userJson)
+}
+
+// SetRoleHandler changes a user's role, {"role":"librarian"}.
+func (h *UserHandler) SetRoleHandler(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var req struct {
+		Role string `json:"role"`
+	}
+	err = json.Unmarshal(reqBody, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	u, err := h.userUsecase.SetRoleUser(id, req.Role)
+	if err != nil {
+		if err == entity.ErrNotFound {
+			w.WriteHeader(http.StatusNotFound)
+			w.Write([]byte(err.Error()))
+			return
+		}
+
+		if err == entity.ErrInvalidEntity {
+			w.WriteHeader(http.StatusUnprocessableEntity)
+			w.Write([]byte("role must be member, librarian or admin"))
+			return
+		}
+
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("ETag", etag(u.Version))
+	writeJson(w, h.represent(u))
+}
+
+// writeUnprocessable answers 422; when the service named the fields at fault the
+// body is {"error": ..., "fields": {"email": "is not a valid address"}}.
+func writeUnprocessable(w http.ResponseWriter, err error) {
+	var v *entity.ValidationError
+	if !errors.As(err, &v) {
+		w.WriteHeader(http.StatusUnprocessableEntity)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	body, err := json.Marshal(struct {
+		Error  string            `json:"error"`
+		Fields map[string]string `json:"fields"`
+	}{Error: entity.ErrInvalidEntity.Error(), Fields: v.Fields})
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusUnprocessableEntity)
+	w.Write(body)
+}
+
+// authorizeEdit answers 403, or 404 for an unknown user, and returns false unless the
+// signed-in user may change the account with id; target is loaded when not given.
+func (h *UserHandler) authorizeEdit(w http.ResponseWriter, r *http.Request, id int, target *entity.User, setsPassword bool) bool {
+	actor := currentUser(r)
+	if actor == nil || actor.ID == id {
+		return true
+	}
+	if !actor.IsStaff() {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(entity.ErrForbidden.Error()))
+		return false
+	}
+
+	if target == nil {
+		var err error
+		target, err = h.userUsecase.GetByIDUser(id)
+		if err != nil {
+			if err == entity.ErrNotFound {
+				w.WriteHeader(http.StatusNotFound)
+				w.Write([]byte(err.Error()))
+				return false
+			}
+
+			w.WriteHeader(http.StatusInternalServerError)
+			w.Write([]byte(err.Error()))
+			return false
+		}
+	}
+
+	if !actor.CanEdit(target, setsPassword) {
+		w.WriteHeader(http.StatusForbidden)
+		w.Write([]byte(entity.ErrForbidden.Error()))
+		return false
+	}
+	return true
+}
+
+func (h *UserHandler) decodeUser(body []byte) (*entity.User, error) {
+	if h.version == apiV2 {
+		var req dto.UserRequest
+		err := json.Unmarshal(body, &req)
+		if err != nil {
+			return nil, err
+		}
+		return req.ToEntity(), nil
+	}
+
+	var u entity.User
+	err := json.Unmarshal(body, &u)
+	if err != nil {
+		return nil, err
+	}
+	return &u, nil
+}
+
+func (h *UserHandler) represent(u *entity.User) interface{} {
+	if h.version == apiV2 {
+		return dto.NewUserResponse(u)
+	}
+	return u
+}
+
+func (h *UserHandler) representAll(users []*entity.User) interface{} {
+	if h.version == apiV2 {
+		return dto.NewUserResponses(users)
+	}
+	return users
+}
+
 func (h *UserHandler) MakeUserHandler(r *mux.Router) {
 	r.HandleFunc("/user", h.CreateHandler).Methods(http.MethodPost)
 	r.HandleFunc("/user/{id:[0-9]+}", h.GetByIDHandler).Methods(http.MethodGet)
 	r.HandleFunc("/user", h.GetAllHandler).Methods(http.MethodGet)
 	r.HandleFunc("/user", h.UpdateByIDHandler).Methods(http.MethodPut)
+	r.HandleFunc("/user/{id:[0-9]+}", h.PatchByIDHandler).Methods(http.MethodPatch)
 	r.HandleFunc("/user/{id:[0-9]+}", h.DeleteByIDHandler).Methods(http.MethodDelete)
+	r.HandleFunc("/user/{id:[0-9]+}/restore", h.RestoreByIDHandler).Methods(http.MethodPost)
+	r.HandleFunc("/user/{id:[0-9]+}/role", h.SetRoleHandler).Methods(http.MethodPut)
+}
+
+func userFilter(q url.Values) (entity.UserFilter, error) {
+	f := entity.UserFilter{Query: q.Get("q"), Location: q.Get("location"), Sort: q.Get("sort")}
+	var v entity.ValidationError
+	if s := q.Get("has_overdue"); s != "" {
+		overdue, err := strconv.ParseBool(s)
+		if err != nil {
+			v.Add("has_overdue", "must be true or false")
+		} else {
+			f.HasOverdue = &overdue
+		}
+	}
+	if s := q.Get("page"); s != "" {
+		page, err := strconv.Atoi(s)
+		if err != nil {
+			v.Add("page", "must be a whole number")
+		}
+		f.Page = page
+	}
+	if s := q.Get("per_page"); s != "" {
+		perPage, err := strconv.Atoi(s)
+		if err != nil {
+			v.Add("per_page", "must be a whole number")
+		}
+		f.PerPage = perPage
+	}
+	return f, v.OrNil()
+}
+
+// pageLinks is a Link header (RFC 8288) pointing at the previous and next pages of
+// the listing at u.
+func pageLinks(u *url.URL, f entity.UserFilter, total int) string {
+	f = user.WithDefaults(f)
+	page, perPage := f.Page, f.PerPage
+
+	link := func(p int, rel string) string {
+		q := u.Query()
+		q.Set("page", strconv.Itoa(p))
+		return fmt.Sprintf(`<%s?%s>; rel="%s"`, u.Path, q.Encode(), rel)
+	}
+	var links []string
+	if page > 1 {
+		links = append(links, link(page-1, "prev"))
+	}
+	if page*perPage < total {
+		links = append(links, link(page+1, "next"))
+	}
+	return strings.Join(links, ", ")
 }
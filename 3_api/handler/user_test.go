@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/json"
 	"errors"
+	"fmt"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/custom_mocks"
 	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
@@ -46,11 +47,13 @@ func TestCreateUserHandler(t *testing.T) {
 	u1 := `{"id":1,"first_name":"Peter","last_name":"Anderson","dob":"1990-01-15T00:00:00Z","location":"Canada","cellphone_number":"+16479150167","email":"Peter@gmail.com","password":"qwerty12345"}`
 	u2 := `{"id":3,"first_name":"","last_name":"","dob":"0001-01-01T00:00:00Z","location":"","cellphone_number":"","email":"","password":""}`
 	u3 := `{"id":1,"first_name":"Peter","last_name":"Anderson","dob":"1990-01-15T00:00:00Z","location":"Canada","cellphone_number":"+16479150167","email":"Peter@gmail.com","password":"qwerty12345"}`
+	u4 := `{"id":4,"first_name":"Peter","last_name":"Anderson","dob":"1990-01-15T00:00:00Z","location":"Canada","cellphone_number":"+16479150167","email":"Peter@gmail.com","password":"12345"}`
 
 	tests := []userTest{
 		{user: u1, want: wantUser{err: nil, statusCode: http.StatusCreated}},
 		{user: u2, want: wantUser{err: entity.ErrInvalidEntity, statusCode: http.StatusUnprocessableEntity}},
 		{user: u3, want: wantUser{err: entity.ErrConflict, statusCode: http.StatusConflict}},
+		{user: u4, want: wantUser{err: entity.ErrWeakPassword, statusCode: http.StatusUnprocessableEntity}},
 	}
 
 	for _, ut := range tests {
@@ -109,8 +112,11 @@ func TestGetAllUsersHandler(t *testing.T) {
 	h.MakeUserHandler(r)
 
 	users := []*entity.User{{ID: 1}, {ID: 2}, {ID: 3}}
+	overdue := true
 
-	m.EXPECT().GetAllUsers().Return(users, nil)
+	m.EXPECT().SearchUsers(entity.UserFilter{}).Return(users, 3, nil)
+	m.EXPECT().SearchUsers(entity.UserFilter{Query: "taras", Location: "Kyiv", HasOverdue: &overdue, Sort: "-created_at", Page: 2, PerPage: 3}).Return(users, 10, nil)
+	m.EXPECT().SearchUsers(entity.UserFilter{Sort: "password"}).Return(nil, 0, &entity.ValidationError{Fields: map[string]string{"sort": "is not sortable"}})
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
@@ -118,6 +124,37 @@ func TestGetAllUsersHandler(t *testing.T) {
 	resp, _ := http.Get(testServ.URL + "/user")
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "3", resp.Header.Get("X-Total-Count"))
+	assert.Empty(t, resp.Header.Get("Link"))
+
+	resp, err := http.Get(testServ.URL + "/user?q=taras&location=Kyiv&has_overdue=true&sort=-created_at&page=2&per_page=3")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, "10", resp.Header.Get("X-Total-Count"))
+	assert.Equal(t, `</user?has_overdue=true&location=Kyiv&page=1&per_page=3&q=taras&sort=-created_at>; rel="prev", </user?has_overdue=true&location=Kyiv&page=3&per_page=3&q=taras&sort=-created_at>; rel="next"`, resp.Header.Get("Link"))
+
+	tests := []struct {
+		query      string
+		statusCode int
+	}{
+		{query: "sort=password", statusCode: http.StatusUnprocessableEntity},
+		{query: "page=two", statusCode: http.StatusUnprocessableEntity},
+		{query: "per_page=x", statusCode: http.StatusUnprocessableEntity},
+		{query: "has_overdue=maybe", statusCode: http.StatusUnprocessableEntity},
+	}
+	for _, tt := range tests {
+		resp, err = http.Get(testServ.URL + "/user?" + tt.query)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, tt.query)
+	}
+
+	// a query that doesn't parse is answered like one the service refuses
+	resp, err = http.Get(testServ.URL + "/user?page=two&has_overdue=maybe")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error":"invalid entity","fields":{"page":"must be a whole number","has_overdue":"must be true or false"}}`, string(body))
 }
 
 func TestUpdateByIDUserHandler(t *testing.T) {
@@ -141,17 +178,26 @@ func TestUpdateByIDUserHandler(t *testing.T) {
 		{user: u1, want: wantUser{err: nil, statusCode: http.StatusOK}},
 		{user: u2, want: wantUser{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
 		{user: u3, want: wantUser{err: entity.ErrInvalidEntity, statusCode: http.StatusUnprocessableEntity}},
+		{user: u1, want: wantUser{err: entity.ErrVersionConflict, statusCode: http.StatusPreconditionFailed}},
 	}
 
 	for _, ut := range tests {
 		var userUnmarshalled entity.User
 		err := json.Unmarshal([]byte(ut.user), &userUnmarshalled)
 		assert.NoError(t, err)
-
-		m.EXPECT().UpdateUser(&userUnmarshalled).Return(ut.want.err)
+		userUnmarshalled.Version = 5
+
+		// an unknown user is already caught by looking up their loans
+		if ut.want.err == entity.ErrNotFound {
+			m.EXPECT().GetByIDUser(userUnmarshalled.ID).Return(nil, entity.ErrNotFound)
+		} else {
+			m.EXPECT().GetByIDUser(userUnmarshalled.ID).Return(&entity.User{ID: userUnmarshalled.ID}, nil)
+			m.EXPECT().UpdateUser(&userUnmarshalled).Return(ut.want.err)
+		}
 
 		req, err := http.NewRequest(http.MethodPut, testServ.URL+"/user", strings.NewReader(ut.user))
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"5"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -174,16 +220,19 @@ func TestDeleteByIDUserHandler(t *testing.T) {
 		{id: 1, want: wantUser{err: nil, statusCode: http.StatusOK}},
 		{id: 2, want: wantUser{err: entity.ErrNotFound, statusCode: http.StatusNotFound}},
 		{id: 3, want: wantUser{err: errors.New("some internal server error"), statusCode: http.StatusInternalServerError}},
+		{id: 4, want: wantUser{err: entity.ErrVersionConflict, statusCode: http.StatusPreconditionFailed}},
+		{id: 5, want: wantUser{err: &entity.OutstandingLoansError{BookIDs: []int{3}}, statusCode: http.StatusConflict}},
 	}
 
 	testServ := httptest.NewServer(r)
 	defer testServ.Close()
 
 	for _, ut := range tests {
-		m.EXPECT().DeleteUser(ut.id).Return(ut.want.err)
+		m.EXPECT().DeleteUser(ut.id, 2, entity.DeleteRestrict).Return(ut.want.err)
 
 		req, err := http.NewRequest(http.MethodDelete, testServ.URL+"/user/"+strconv.Itoa(ut.id), nil)
 		assert.NoError(t, err)
+		req.Header.Set("If-Match", `"2"`)
 		client := http.DefaultClient
 		resp, err := client.Do(req)
 		assert.NoError(t, err)
@@ -294,6 +343,233 @@ func TestCreateHandler1(t *testing.T) {
 	assert.NoError(t, err)
 
 	assert.Equal(t, resp1.StatusCode, http.StatusCreated)
+	assert.Equal(t, "/user/1", resp1.Header.Get("Location"))
 	assert.Equal(t, resp2.StatusCode, http.StatusUnprocessableEntity)
 	assert.Equal(t, resp3.StatusCode, http.StatusConflict)
+
+	u4 := &entity.User{ID: 5, FirstName: "Peter", LastName: "Anderson", DOB: time.Date(1990, time.January, 15, 0, 0, 0, 0, time.UTC), Location: "Canada", CellPhoneNumber: "+16479150167", Email: "Peter@gmail.com", Password: "qwerty12345"}
+	m.EXPECT().CreateUser(u4).Return(entity.ErrClientID)
+	u4Json, err := json.Marshal(u4)
+	assert.NoError(t, err)
+	resp4, err := http.Post(testServ.URL+"/user", "application/json", bytes.NewReader(u4Json))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp4.StatusCode)
+}
+
+func TestPatchByIDUserHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	current := &entity.User{ID: 1, FirstName: "Jonathan", LastName: "Adams", DOB: time.Date(1987, time.March, 21, 0, 0, 0, 0, time.UTC), Location: "USA", CellPhoneNumber: "+16479250145", Email: "Jonathan@gmail.com", Password: "pw124567", Books: []int{3}}
+	want := *current
+	want.Location = "Canada"
+	want.Version = 7
+
+	m.EXPECT().GetByIDUser(1).Return(current, nil).Times(3)
+	m.EXPECT().UpdateUser(&want).Return(nil)
+	m.EXPECT().UpdateUser(gomock.Any()).Return(entity.ErrInvalidEntity)
+
+	patches := []struct {
+		patch      string
+		statusCode int
+	}{
+		{patch: `{"location":"Canada","Books":[]}`, statusCode: http.StatusOK},
+		{patch: `{"email":null}`, statusCode: http.StatusUnprocessableEntity},
+		{patch: `{"dob":"yesterday"}`, statusCode: http.StatusBadRequest},
+	}
+
+	for _, p := range patches {
+		req, err := http.NewRequest(http.MethodPatch, testServ.URL+"/user/1", strings.NewReader(p.patch))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"7"`)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, p.statusCode, resp.StatusCode, p.patch)
+	}
+
+	m.EXPECT().GetByIDUser(2).Return(nil, entity.ErrNotFound)
+	req, err := http.NewRequest(http.MethodPatch, testServ.URL+"/user/2", strings.NewReader(`{"location":"Canada"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"1"`)
+	resp, err := http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+
+	req, err = http.NewRequest(http.MethodPatch, testServ.URL+"/user/1", strings.NewReader(`{"location":"Canada"}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusPreconditionRequired, resp.StatusCode)
+}
+
+func TestRestoreByIDUserHandler(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().RestoreUser(1).Return(&entity.User{ID: 1, Version: 2, Books: []int{4}}, nil)
+	m.EXPECT().RestoreUser(2).Return(nil, entity.ErrNotFound)
+
+	resp, err := http.Post(testServ.URL+"/user/1/restore", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"2"`, resp.Header.Get("ETag"))
+
+	var got entity.User
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, []int{4}, got.Books)
+
+	resp, err = http.Post(testServ.URL+"/user/2/restore", "", nil)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusNotFound, resp.StatusCode)
+}
+
+func TestGetByIDUserHandler_NoPassword(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	u := &entity.User{ID: 1, FirstName: "Peter", LastName: "Anderson", Email: "Peter@gmail.com", PasswordHash: "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", Version: 1}
+	m.EXPECT().GetByIDUser(1).Return(u, nil)
+
+	resp, err := http.Get(testServ.URL + "/user/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.NotContains(t, string(body), "password")
+	assert.NotContains(t, string(body), "$2a$")
+}
+
+func TestCreateUserHandler_FieldErrors(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateUser(gomock.Any()).Return(&entity.ValidationError{Fields: map[string]string{"email": "is not a valid address"}})
+
+	resp, err := http.Post(testServ.URL+"/user", "application/json", strings.NewReader(`{"email":"peter"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusUnprocessableEntity, resp.StatusCode)
+	assert.Equal(t, "application/json", resp.Header.Get("Content-Type"))
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.JSONEq(t, `{"error":"invalid entity","fields":{"email":"is not a valid address"}}`, string(body))
+}
+
+func TestCreateUserHandler_EmailTaken(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r)
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateUser(gomock.Any()).Return(entity.ErrEmailTaken)
+	m.EXPECT().GetByIDUser(1).Return(&entity.User{ID: 1}, nil)
+	m.EXPECT().UpdateUser(gomock.Any()).Return(entity.ErrEmailTaken)
+
+	resp, err := http.Post(testServ.URL+"/user", "application/json", strings.NewReader(`{"email":"peter@gmail.com"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+
+	req, err := http.NewRequest(http.MethodPut, testServ.URL+"/user", strings.NewReader(`{"id":1,"email":"peter@gmail.com"}`))
+	assert.NoError(t, err)
+	req.Header.Set("If-Match", `"1"`)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusConflict, resp.StatusCode)
+}
+
+func TestEditUserHandler_Permissions(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+
+	admin := &entity.User{ID: 1, FirstName: "Ada", LastName: "Admin", DOB: time.Date(1980, time.May, 1, 0, 0, 0, 0, time.UTC), Location: "USA", CellPhoneNumber: "+16479250145", Email: "ada@gmail.com", Role: entity.RoleAdmin}
+	member := &entity.User{ID: 2, FirstName: "Mia", LastName: "Member", DOB: time.Date(1990, time.May, 1, 0, 0, 0, 0, time.UTC), Location: "USA", CellPhoneNumber: "+16479250146", Email: "mia@gmail.com", Role: entity.RoleMember}
+	librarian := &entity.User{ID: 3, Role: entity.RoleLibrarian}
+	m.EXPECT().GetByIDUser(1).Return(admin, nil).AnyTimes()
+	m.EXPECT().GetByIDUser(2).Return(member, nil).AnyTimes()
+	m.EXPECT().UpdateUser(gomock.Any()).Return(nil).Times(3)
+	m.EXPECT().DeleteUser(2, 1, "").Return(nil)
+
+	put := func(u *entity.User, password string) string {
+		return fmt.Sprintf(`{"id":%d,"first_name":%q,"last_name":%q,"dob":"1990-05-01T00:00:00Z","location":"USA","cellphone_number":%q,"email":%q,"password":%q}`,
+			u.ID, u.FirstName, u.LastName, u.CellPhoneNumber, u.Email, password)
+	}
+	tests := []struct {
+		actor      *entity.User
+		method     string
+		path       string
+		body       string
+		statusCode int
+	}{
+		// staff can't touch accounts above their role
+		{actor: librarian, method: http.MethodPatch, path: "/user/1", body: `{"location":"Canada"}`, statusCode: http.StatusForbidden},
+		{actor: librarian, method: http.MethodPut, path: "/user", body: put(admin, ""), statusCode: http.StatusForbidden},
+		{actor: librarian, method: http.MethodDelete, path: "/user/1", statusCode: http.StatusForbidden},
+		{actor: librarian, method: http.MethodPatch, path: "/user/2", body: `{"location":"Canada"}`, statusCode: http.StatusOK},
+		{actor: librarian, method: http.MethodDelete, path: "/user/2", statusCode: http.StatusOK},
+		// nobody sets somebody else's password
+		{actor: librarian, method: http.MethodPatch, path: "/user/2", body: `{"password":"stolen12345"}`, statusCode: http.StatusForbidden},
+		{actor: librarian, method: http.MethodPut, path: "/user", body: put(member, "stolen12345"), statusCode: http.StatusForbidden},
+		{actor: admin, method: http.MethodPut, path: "/user", body: put(member, "stolen12345"), statusCode: http.StatusForbidden},
+		{actor: member, method: http.MethodPut, path: "/user", body: put(admin, ""), statusCode: http.StatusForbidden},
+		{actor: member, method: http.MethodPut, path: "/user", body: put(member, "newpassword1"), statusCode: http.StatusOK},
+		{actor: admin, method: http.MethodPatch, path: "/user/2", body: `{"location":"Canada"}`, statusCode: http.StatusOK},
+	}
+	for _, tt := range tests {
+		r := mux.NewRouter()
+		r.Use(withUser(tt.actor))
+		h.MakeUserHandler(r)
+		testServ := httptest.NewServer(r)
+
+		req, err := http.NewRequest(tt.method, testServ.URL+tt.path, strings.NewReader(tt.body))
+		assert.NoError(t, err)
+		req.Header.Set("Content-Type", "application/merge-patch+json")
+		req.Header.Set("If-Match", `"1"`)
+		resp, err := http.DefaultClient.Do(req)
+		assert.NoError(t, err)
+		assert.Equal(t, tt.statusCode, resp.StatusCode, "%s %s as %s: %s", tt.method, tt.path, tt.actor.Role, tt.body)
+		testServ.Close()
+	}
 }
@@ -0,0 +1,62 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"io"
+	"net/http"
+)
+
+// VerifyHandler is where the link in the verification mail leads: GET /auth/verify?token=.
+func (h *AuthHandler) VerifyHandler(w http.ResponseWriter, r *http.Request) {
+	err := h.authUseCase.VerifyEmail(r.URL.Query().Get("token"))
+	if err != nil {
+		writeTokenError(w, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.Write([]byte("Your email address is confirmed.\n"))
+}
+
+// ResendVerificationHandler mails a new link, {"email":"..."}. It answers 202 whether
+// or not the address has an account.
+func (h *AuthHandler) ResendVerificationHandler(w http.ResponseWriter, r *http.Request) {
+	reqBody, err := io.ReadAll(r.Body)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	var req struct {
+		Email string `json:"email"`
+	}
+	err = json.Unmarshal(reqBody, &req)
+	if err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	err = h.authUseCase.ResendVerification(req.Email)
+	if err != nil {
+		w.WriteHeader(http.StatusInternalServerError)
+		w.Write([]byte(err.Error()))
+		return
+	}
+
+	w.WriteHeader(http.StatusAccepted)
+}
+
+func writeTokenError(w http.ResponseWriter, err error) {
+	switch err {
+	case entity.ErrInvalidToken:
+		w.WriteHeader(http.StatusBadRequest)
+	case entity.ErrTokenExpired:
+		w.WriteHeader(http.StatusGone)
+	default:
+		w.WriteHeader(http.StatusInternalServerError)
+	}
+	w.Write([]byte(err.Error()))
+}
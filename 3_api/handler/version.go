@@ -0,0 +1,33 @@
+package handler
+
+import "strings"
+
+// apiVersion is the wire format a handler speaks. v1 marshals the entities as they
+// are; v2 goes through the request and response types of package dto.
+type apiVersion int
+
+const (
+	apiV1 apiVersion = iota
+	apiV2
+)
+
+// versionPrefixes are mounted in front of every route; unprefixed routes are v1.
+var versionPrefixes = []string{"/v1", "/v2"}
+
+// path is where a resource lives in this version, for Location headers.
+func (v apiVersion) path(p string) string {
+	if v == apiV2 {
+		return "/v2" + p
+	}
+	return p
+}
+
+// unversioned strips the version prefix off a route template.
+func unversioned(tpl string) string {
+	for _, prefix := range versionPrefixes {
+		if rest := strings.TrimPrefix(tpl, prefix); rest != tpl && strings.HasPrefix(rest, "/") {
+			return rest
+		}
+	}
+	return tpl
+}
@@ -0,0 +1,267 @@
+package handler
+
+import (
+	"encoding/json"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	bmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book/mocks"
+	imock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/bookimport/mocks"
+	rmock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/review/mocks"
+	sermock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/series/mocks"
+	smock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/stocktake/mocks"
+	umock "github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user/mocks"
+	"github.com/golang/mock/gomock"
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestUserHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := umock.NewMockUseCase(controller)
+	h := NewUserHandler(m)
+	r := mux.NewRouter()
+	h.MakeUserHandler(r.PathPrefix("/v1").Subrouter())
+	h.V2().MakeUserHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	dob := time.Date(1990, 1, 15, 0, 0, 0, 0, time.UTC)
+	m.EXPECT().CreateUser(&entity.User{FirstName: "Peter", LastName: "Anderson", DOB: dob, Location: "Canada", CellPhoneNumber: "+16479150167", Email: "Peter@gmail.com", Password: "qwerty12345"}).
+		DoAndReturn(func(u *entity.User) error {
+			u.ID = 5
+			u.Password = ""
+			u.Role = entity.RoleMember
+			u.Version = 1
+			return nil
+		})
+
+	// the role and version in the body are ignored
+	resp, err := http.Post(testServ.URL+"/v2/user", "application/json", strings.NewReader(`{"first_name":"Peter","last_name":"Anderson","dob":"1990-01-15T00:00:00Z","location":"Canada","cellphone_number":"+16479150167","email":"Peter@gmail.com","password":"qwerty12345","role":"admin","version":9}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/v2/user/5", resp.Header.Get("Location"))
+	var created map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&created))
+	assert.Equal(t, float64(5), created["id"])
+	assert.Equal(t, "member", created["role"])
+	assert.Equal(t, []interface{}{}, created["borrowed_book_ids"])
+	assert.NotContains(t, created, "password")
+
+	u := &entity.User{ID: 5, FirstName: "Peter", Email: "Peter@gmail.com", PasswordHash: "hash", Role: entity.RoleMember, Version: 1, Books: []int{3}}
+	m.EXPECT().GetByIDUser(5).Return(u, nil).Times(2)
+
+	resp, err = http.Get(testServ.URL + "/v2/user/5")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"borrowed_book_ids":[3]`)
+	assert.NotContains(t, string(body), "Books")
+
+	// v1 keeps the entity as it always was
+	resp, err = http.Get(testServ.URL + "/v1/user/5")
+	assert.NoError(t, err)
+	body, err = io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"Books":[3]`)
+
+	// v2 bodies have no books, so a profile edit keeps the loans as they are
+	m.EXPECT().GetByIDUser(5).Return(u, nil)
+	m.EXPECT().UpdateUser(gomock.Any()).DoAndReturn(func(e *entity.User) error {
+		assert.Equal(t, []int{3}, e.Books)
+		e.Version++
+		return nil
+	})
+	req, err := http.NewRequest(http.MethodPut, testServ.URL+"/v2/user", strings.NewReader(`{"id":5,"first_name":"Peter","last_name":"Anderson","dob":"1990-01-15T00:00:00Z","location":"Canada","cellphone_number":"+16479150167","email":"Peter@gmail.com"}`))
+	assert.NoError(t, err)
+	req.Header.Set("If-Match", `"1"`)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestBookHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := bmock.NewMockUseCase(controller)
+	h := NewBookHandler(m)
+	r := mux.NewRouter()
+	h.V2().MakeBookHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	current := &entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 5, Available: 4, Publisher: "Viking", PublicationYear: 1933, Version: 2}
+	m.EXPECT().GetByIDBook(1).Return(current, nil).Times(2)
+	m.EXPECT().UpdateBook(&entity.Book{ID: 1, Tittle: "God's Little Acre", Author: "Erskine Caldwell", Pages: 224, Quantity: 7, PublicationYear: 1933, Version: 2, Movement: &entity.Movement{Actor: "anonymous"}}).
+		DoAndReturn(func(b *entity.Book) error {
+			b.Version++
+			return nil
+		})
+
+	resp, err := http.Get(testServ.URL + "/v2/book/1")
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	var got map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "God's Little Acre", got["title"])
+	assert.Equal(t, float64(1933), got["publication_year"])
+	assert.Equal(t, float64(4), got["available"])
+	assert.NotContains(t, got, "Tittle")
+
+	req, err := http.NewRequest(http.MethodPatch, testServ.URL+"/v2/book/1", strings.NewReader(`{"quantity":7,"publisher":null}`))
+	assert.NoError(t, err)
+	req.Header.Set("Content-Type", "application/merge-patch+json")
+	req.Header.Set("If-Match", `"2"`)
+	resp, err = http.DefaultClient.Do(req)
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, `"3"`, resp.Header.Get("ETag"))
+	got = nil
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, float64(7), got["quantity"])
+	assert.Equal(t, "", got["publisher"])
+
+	m.EXPECT().MergeBooks(&entity.BookMerge{SurvivorID: 1, DuplicateIDs: []int{2}}, "anonymous").Return(current, nil)
+	resp, err = http.Post(testServ.URL+"/v2/book/merge", "application/json", strings.NewReader(`{"survivor_id":1,"duplicate_ids":[2]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+}
+
+func TestReviewHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := rmock.NewMockUseCase(controller)
+	r := mux.NewRouter()
+	NewReviewHandler(m).V2().MakeReviewHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateReview(&entity.Review{BookID: 1, UserID: 2, Rating: 5, Text: "Grim"}).DoAndReturn(func(rv *entity.Review) error {
+		rv.ID = 3
+		rv.Status = entity.ReviewPublished
+		return nil
+	})
+
+	resp, err := http.Post(testServ.URL+"/v2/book/1/reviews", "application/json", strings.NewReader(`{"user_id":2,"rating":5,"text":"Grim"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/v2/review/3", resp.Header.Get("Location"))
+	var got map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, float64(1), got["book_id"])
+	assert.Equal(t, "published", got["status"])
+	assert.NotContains(t, got, "BookID")
+
+	m.EXPECT().GetByBookReviews(1).Return([]*entity.Review{{ID: 3, BookID: 1, UserID: 2, Rating: 5}}, nil)
+	resp, err = http.Get(testServ.URL + "/v2/book/1/reviews")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"user_id":2`)
+}
+
+func TestSeriesHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := sermock.NewMockUseCase(controller)
+	r := mux.NewRouter()
+	NewSeriesHandler(m).V2().MakeSeriesHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().CreateSeries(&entity.Series{Name: "Earthsea"}).DoAndReturn(func(s *entity.Series) error {
+		s.ID = 4
+		return nil
+	})
+
+	resp, err := http.Post(testServ.URL+"/v2/series", "application/json", strings.NewReader(`{"name":"Earthsea"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/v2/series/4", resp.Header.Get("Location"))
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"name":"Earthsea"`)
+
+	m.EXPECT().NextInSeries(1).Return(&entity.Book{ID: 2, Tittle: "The Tombs of Atuan", SeriesID: 4, Volume: 2, Version: 1}, nil)
+	resp, err = http.Get(testServ.URL + "/v2/book/1/next")
+	assert.NoError(t, err)
+	var got map[string]interface{}
+	assert.NoError(t, json.NewDecoder(resp.Body).Decode(&got))
+	assert.Equal(t, "The Tombs of Atuan", got["title"])
+	assert.Equal(t, float64(4), got["series_id"])
+}
+
+func TestStocktakeHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := smock.NewMockUseCase(controller)
+	r := mux.NewRouter()
+	NewStocktakeHandler(m).V2().MakeStocktakeHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	m.EXPECT().OpenStocktake(&entity.Stocktake{Branch: "west"}).DoAndReturn(func(st *entity.Stocktake) error {
+		st.ID = 7
+		st.Status = entity.StocktakeOpen
+		return nil
+	})
+	resp, err := http.Post(testServ.URL+"/v2/stocktake", "application/json", strings.NewReader(`{"branch":"west"}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, "/v2/stocktake/7", resp.Header.Get("Location"))
+
+	m.EXPECT().SubmitCounts(7, &entity.StocktakeSubmission{Barcodes: []string{"31000000001"}, Counts: []*entity.StocktakeCount{{BookID: 2, Counted: 3}}}).Return(nil)
+	resp, err = http.Post(testServ.URL+"/v2/stocktake/7/counts", "application/json", strings.NewReader(`{"barcodes":["31000000001"],"counts":[{"book_id":2,"counted":3}]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	report := &entity.StocktakeReport{
+		Stocktake:     &entity.Stocktake{ID: 7, Branch: "west", Status: entity.StocktakeOpen},
+		Discrepancies: []*entity.Discrepancy{{BookID: 2, Kind: entity.DiscrepancyMiscounted, Expected: 4, Counted: 3}},
+	}
+	m.EXPECT().Report(7).Return(report, nil)
+	resp, err = http.Get(testServ.URL + "/v2/stocktake/7/report")
+	assert.NoError(t, err)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"closed_at":null`)
+	assert.Contains(t, string(body), `"discrepancies":[{"book_id":2,"barcode":"","kind":"miscounted","expected":4,"counted":3,"corrected":false}]`)
+}
+
+func TestImportHandler_V2(t *testing.T) {
+	controller := gomock.NewController(t)
+	defer controller.Finish()
+
+	m := imock.NewMockUseCase(controller)
+	r := mux.NewRouter()
+	NewImportHandler(m).V2().MakeImportHandler(r.PathPrefix("/v2").Subrouter())
+
+	testServ := httptest.NewServer(r)
+	defer testServ.Close()
+
+	report := &entity.ImportReport{DryRun: true, Rejected: 1, Rows: []*entity.ImportRow{{Row: 2, Status: entity.ImportRejected, Error: "invalid entity"}}}
+	m.EXPECT().Import(gomock.Any(), "csv", true).Return(report, nil)
+
+	resp, err := http.Post(testServ.URL+"/v2/book/import?dry_run=true", "text/csv", strings.NewReader("payload"))
+	assert.NoError(t, err)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+	assert.Contains(t, string(body), `"dry_run":true`)
+	assert.Contains(t, string(body), `"rows":[{"row":2,"id":0,"isbn":"","status":"rejected","error":"invalid entity"}]`)
+}
@@ -0,0 +1,16 @@
+package notifierLog
+
+import (
+	"bytes"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+)
+
+func TestNotify(t *testing.T) {
+	var out bytes.Buffer
+	n := NewLog(log.New(&out, "", 0))
+
+	assert.NoError(t, n.Notify("peter@gmail.com", "Confirm your email address", "http://localhost:8080/auth/verify?token=abc"))
+	assert.Equal(t, "mail to peter@gmail.com: Confirm your email address\nhttp://localhost:8080/auth/verify?token=abc\n", out.String())
+}
@@ -0,0 +1,58 @@
+package notifierSMTP
+
+import (
+	"bytes"
+	"errors"
+	"mime"
+	"net"
+	"net/smtp"
+	"strings"
+	"time"
+)
+
+var errHeaderInjection = errors.New("line breaks are not allowed in mail headers")
+
+// SMTP sends plain-text mail through a relay such as a provider's submission port.
+type SMTP struct {
+	addr string
+	from string
+	auth smtp.Auth
+}
+
+// NewSMTP sends through addr (host:port) as from, logging in when username is set.
+func NewSMTP(addr, from, username, password string) *SMTP {
+	s := &SMTP{addr: addr, from: from}
+	if username != "" {
+		host, _, _ := net.SplitHostPort(addr)
+		s.auth = smtp.PlainAuth("", username, password, host)
+	}
+	return s
+}
+
+func (s *SMTP) Notify(to, subject, body string) error {
+	msg, err := message(s.from, to, subject, body, time.Now())
+	if err != nil {
+		return err
+	}
+	return smtp.SendMail(s.addr, s.auth, s.from, []string{to}, msg)
+}
+
+func message(from, to, subject, body string, date time.Time) ([]byte, error) {
+	for _, h := range []string{from, to, subject} {
+		if strings.ContainsAny(h, "\r\n") {
+			return nil, errHeaderInjection
+		}
+	}
+
+	var b bytes.Buffer
+	b.WriteString("From: " + from + "\r\n")
+	b.WriteString("To: " + to + "\r\n")
+	b.WriteString("Subject: " + mime.QEncoding.Encode("utf-8", subject) + "\r\n")
+	b.WriteString("Date: " + date.Format(time.RFC1123Z) + "\r\n")
+	b.WriteString("MIME-Version: 1.0\r\n")
+	b.WriteString("Content-Type: text/plain; charset=utf-8\r\n")
+	b.WriteString("Content-Transfer-Encoding: 8bit\r\n")
+	b.WriteString("\r\n")
+	b.WriteString(strings.ReplaceAll(strings.ReplaceAll(body, "\r\n", "\n"), "\n", "\r\n"))
+	return b.Bytes(), nil
+}
@@ -0,0 +1,29 @@
+package notifierSMTP
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestMessage(t *testing.T) {
+	date := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	msg, err := message("library@example.com", "peter@gmail.com", "Confirm your email address", "Hello Peter,\n\nopen the link.\n", date)
+	assert.NoError(t, err)
+	assert.Equal(t, "From: library@example.com\r\n"+
+		"To: peter@gmail.com\r\n"+
+		"Subject: Confirm your email address\r\n"+
+		"Date: Fri, 01 Mar 2024 12:00:00 +0000\r\n"+
+		"MIME-Version: 1.0\r\n"+
+		"Content-Type: text/plain; charset=utf-8\r\n"+
+		"Content-Transfer-Encoding: 8bit\r\n"+
+		"\r\n"+
+		"Hello Peter,\r\n\r\nopen the link.\r\n", string(msg))
+
+	msg, err = message("library@example.com", "peter@gmail.com", "Підтвердіть адресу", "", date)
+	assert.NoError(t, err)
+	assert.Contains(t, string(msg), "Subject: =?utf-8?q?")
+
+	_, err = message("library@example.com", "peter@gmail.com\r\nBcc: everyone@example.com", "Hi", "", date)
+	assert.Equal(t, errHeaderInjection, err)
+}
@@ -0,0 +1,56 @@
+package repositoryAuth
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+type PostgreSQL struct {
+	db *sql.DB
+}
+
+func NewSessions(db *sql.DB) *PostgreSQL {
+	return &PostgreSQL{db: db}
+}
+
+func (r *PostgreSQL) CreateSession(s *entity.Session) error {
+	_, err := r.db.Exec("INSERT INTO sessions (id, id_user, nonce, created_at, expires_at) VALUES ($1, $2, $3, $4, $5)",
+		s.ID, s.UserID, s.Nonce, s.CreatedAt, s.ExpiresAt)
+	return err
+}
+
+func (r *PostgreSQL) GetSession(id string) (*entity.Session, error) {
+	var s entity.Session
+	var revokedAt sql.NullTime
+	err := r.db.QueryRow("SELECT id, id_user, nonce, created_at, expires_at, revoked_at FROM sessions WHERE id = $1", id).
+		Scan(&s.ID, &s.UserID, &s.Nonce, &s.CreatedAt, &s.ExpiresAt, &revokedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	s.RevokedAt = revokedAt.Time
+	return &s, err
+}
+
+// RotateNonce swaps in the next refresh token only if nonce is still the current
+// one, so of two refreshes racing with the same token only one succeeds.
+func (r *PostgreSQL) RotateNonce(id, nonce, newNonce string, expiresAt time.Time) error {
+	res, err := r.db.Exec("UPDATE sessions SET nonce = $1, expires_at = $2 WHERE id = $3 AND nonce = $4 AND revoked_at IS NULL", newNonce, expiresAt, id, nonce)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
+
+func (r *PostgreSQL) RevokeSession(id string, at time.Time) error {
+	_, err := r.db.Exec("UPDATE sessions SET revoked_at = $1 WHERE id = $2 AND revoked_at IS NULL", at, id)
+	return err
+}
@@ -0,0 +1,73 @@
+package repositoryAuth
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+)
+
+var db *sql.DB
+
+func setUp() {
+	var err error
+	db, err = database.NewPostgresConnection(database.ConnectionInfo{Host: "localhost", Port: 5432, UserName: "crud-6", DBName: "crud-6-db", SSLMode: "disable", Password: "12345"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	for _, table := range []string{"sessions", "user_tokens"} {
+		_, err = db.Exec("DELETE FROM " + table)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func tearDown() {
+	defer db.Close()
+
+	for _, table := range []string{"sessions", "user_tokens"} {
+		_, err := db.Exec("DELETE FROM " + table)
+		if err != nil {
+			log.Fatal(err)
+		}
+	}
+}
+
+func TestMain(m *testing.M) {
+	setUp()
+	m.Run()
+	tearDown()
+}
+
+func TestSessions(t *testing.T) {
+	repo := NewSessions(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	s := &entity.Session{ID: "0123456789abcdef0123456789abcdef", UserID: 7, Nonce: "n1", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}
+	assert.NoError(t, repo.CreateSession(s))
+
+	sessionGot, err := repo.GetSession(s.ID)
+	assert.NoError(t, err)
+	sessionGot.CreatedAt = sessionGot.CreatedAt.UTC()
+	sessionGot.ExpiresAt = sessionGot.ExpiresAt.UTC()
+	assert.Equal(t, s, sessionGot)
+
+	_, err = repo.GetSession("missing")
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	assert.NoError(t, repo.RotateNonce(s.ID, "n1", "n2", now.Add(2*time.Hour)))
+	assert.Equal(t, entity.ErrNotFound, repo.RotateNonce(s.ID, "n1", "n3", now.Add(2*time.Hour)))
+
+	assert.NoError(t, repo.RevokeSession(s.ID, now))
+	sessionGot, err = repo.GetSession(s.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "n2", sessionGot.Nonce)
+	assert.False(t, sessionGot.RevokedAt.IsZero())
+	assert.Equal(t, entity.ErrNotFound, repo.RotateNonce(s.ID, "n2", "n3", now.Add(2*time.Hour)))
+}
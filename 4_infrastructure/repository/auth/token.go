@@ -0,0 +1,116 @@
+package repositoryAuth
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"time"
+)
+
+func (r *PostgreSQL) CreateToken(t *entity.AccountToken) error {
+	_, err := r.db.Exec("INSERT INTO user_tokens (token_hash, id_user, purpose, email, created_at, expires_at) VALUES ($1, $2, $3, $4, $5, $6)",
+		t.Hash, t.UserID, t.Purpose, t.Email, t.CreatedAt, t.ExpiresAt)
+	return err
+}
+
+func (r *PostgreSQL) GetToken(hash string) (*entity.AccountToken, error) {
+	return r.scanToken(r.db.QueryRow("SELECT token_hash, id_user, purpose, email, created_at, expires_at, used_at FROM user_tokens WHERE token_hash = $1", hash))
+}
+
+func (r *PostgreSQL) LatestToken(userID int, purpose string) (*entity.AccountToken, error) {
+	return r.scanToken(r.db.QueryRow("SELECT token_hash, id_user, purpose, email, created_at, expires_at, used_at FROM user_tokens WHERE id_user = $1 AND purpose = $2 ORDER BY created_at DESC LIMIT 1", userID, purpose))
+}
+
+// ConfirmEmail uses up the token and verifies the user's address in one go. It fails
+// with ErrInvalidToken when the token was used meanwhile or the user has since changed
+// their address or been deleted.
+func (r *PostgreSQL) ConfirmEmail(hash string, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var email string
+	err = tx.QueryRow("UPDATE user_tokens SET used_at = $1 WHERE token_hash = $2 AND used_at IS NULL RETURNING id_user, email", at, hash).Scan(&userID, &email)
+	if err == sql.ErrNoRows {
+		return entity.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE users SET email_verified_at = COALESCE(email_verified_at, $1), version = version + 1 WHERE id = $2 AND lower(email) = lower($3) AND deleted_at IS NULL", at, userID, email)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrInvalidToken
+	}
+	return tx.Commit()
+}
+
+// ResetPassword uses up the token together with the other unused reset tokens the
+// user was sent, stores the new password hash and ends every session of the user, all
+// in one transaction. It fails with ErrInvalidToken when the token was used meanwhile
+// or the user has since changed their address or been deleted.
+func (r *PostgreSQL) ResetPassword(hash, passwordHash string, at time.Time) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var userID int
+	var email string
+	err = tx.QueryRow("UPDATE user_tokens SET used_at = $1 WHERE token_hash = $2 AND purpose = $3 AND used_at IS NULL RETURNING id_user, email", at, hash, entity.TokenResetPassword).Scan(&userID, &email)
+	if err == sql.ErrNoRows {
+		return entity.ErrInvalidToken
+	}
+	if err != nil {
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE user_tokens SET used_at = $1 WHERE id_user = $2 AND purpose = $3 AND used_at IS NULL", at, userID, entity.TokenResetPassword)
+	if err != nil {
+		return err
+	}
+
+	res, err := tx.Exec("UPDATE users SET password = $1 WHERE id = $2 AND lower(email) = lower($3) AND deleted_at IS NULL", passwordHash, userID, email)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrInvalidToken
+	}
+
+	_, err = tx.Exec("UPDATE sessions SET revoked_at = $1 WHERE id_user = $2 AND revoked_at IS NULL", at, userID)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgreSQL) scanToken(row *sql.Row) (*entity.AccountToken, error) {
+	var t entity.AccountToken
+	var usedAt sql.NullTime
+	err := row.Scan(&t.Hash, &t.UserID, &t.Purpose, &t.Email, &t.CreatedAt, &t.ExpiresAt, &usedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	t.UsedAt = usedAt.Time
+	return &t, nil
+}
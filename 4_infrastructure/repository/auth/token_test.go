@@ -0,0 +1,107 @@
+package repositoryAuth
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+	"time"
+)
+
+func TestTokens(t *testing.T) {
+	repo := NewSessions(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	first := &entity.AccountToken{Hash: "hash1", UserID: 7, Purpose: entity.TokenVerifyEmail, Email: "peter@gmail.com", CreatedAt: now.Add(-time.Hour), ExpiresAt: now.Add(time.Hour)}
+	second := &entity.AccountToken{Hash: "hash2", UserID: 7, Purpose: entity.TokenVerifyEmail, Email: "peter@gmail.com", CreatedAt: now, ExpiresAt: now.Add(2 * time.Hour)}
+	assert.NoError(t, repo.CreateToken(first))
+	assert.NoError(t, repo.CreateToken(second))
+
+	tokenGot, err := repo.GetToken("hash1")
+	assert.NoError(t, err)
+	tokenGot.CreatedAt = tokenGot.CreatedAt.UTC()
+	tokenGot.ExpiresAt = tokenGot.ExpiresAt.UTC()
+	assert.Equal(t, first, tokenGot)
+
+	tokenGot, err = repo.LatestToken(7, entity.TokenVerifyEmail)
+	assert.NoError(t, err)
+	assert.Equal(t, "hash2", tokenGot.Hash)
+
+	_, err = repo.GetToken("missing")
+	assert.Equal(t, entity.ErrNotFound, err)
+	_, err = repo.LatestToken(8, entity.TokenVerifyEmail)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestConfirmEmail(t *testing.T) {
+	repo := NewSessions(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var userID int
+	err := db.QueryRow("INSERT INTO users (first_name, email, version, created_at) VALUES ('Anna', 'Anna@gmail.com', 1, $1) RETURNING id", now).Scan(&userID)
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id = $1", userID)
+
+	assert.NoError(t, repo.CreateToken(&entity.AccountToken{Hash: "confirm", UserID: userID, Purpose: entity.TokenVerifyEmail, Email: "anna@gmail.com", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.CreateToken(&entity.AccountToken{Hash: "stale", UserID: userID, Purpose: entity.TokenVerifyEmail, Email: "old@gmail.com", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	assert.NoError(t, repo.ConfirmEmail("confirm", now))
+	var verifiedAt time.Time
+	var version int
+	assert.NoError(t, db.QueryRow("SELECT email_verified_at, version FROM users WHERE id = $1", userID).Scan(&verifiedAt, &version))
+	assert.Equal(t, now, verifiedAt.UTC())
+	assert.Equal(t, 2, version)
+
+	tokenGot, err := repo.GetToken("confirm")
+	assert.NoError(t, err)
+	assert.Equal(t, now, tokenGot.UsedAt.UTC())
+
+	// a token works once, and not for an address the user no longer has
+	assert.Equal(t, entity.ErrInvalidToken, repo.ConfirmEmail("confirm", now))
+	assert.Equal(t, entity.ErrInvalidToken, repo.ConfirmEmail("stale", now))
+	tokenGot, err = repo.GetToken("stale")
+	assert.NoError(t, err)
+	assert.True(t, tokenGot.UsedAt.IsZero())
+}
+
+func TestResetPassword(t *testing.T) {
+	repo := NewSessions(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	var userID int
+	err := db.QueryRow("INSERT INTO users (first_name, email, password, version, created_at) VALUES ('Olena', 'Olena@gmail.com', 'old', 1, $1) RETURNING id", now).Scan(&userID)
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id = $1", userID)
+
+	for _, hash := range []string{"reset1", "reset2"} {
+		assert.NoError(t, repo.CreateToken(&entity.AccountToken{Hash: hash, UserID: userID, Purpose: entity.TokenResetPassword, Email: "olena@gmail.com", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	}
+	assert.NoError(t, repo.CreateToken(&entity.AccountToken{Hash: "verify9", UserID: userID, Purpose: entity.TokenVerifyEmail, Email: "olena@gmail.com", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.CreateToken(&entity.AccountToken{Hash: "moved", UserID: userID, Purpose: entity.TokenResetPassword, Email: "old@gmail.com", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+	assert.NoError(t, repo.CreateSession(&entity.Session{ID: "bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb1", UserID: userID, Nonce: "n", CreatedAt: now, ExpiresAt: now.Add(time.Hour)}))
+
+	// a token sent to an address the user no longer has changes nothing
+	assert.Equal(t, entity.ErrInvalidToken, repo.ResetPassword("moved", "new", now))
+	var stored string
+	assert.NoError(t, db.QueryRow("SELECT password FROM users WHERE id = $1", userID).Scan(&stored))
+	assert.Equal(t, "old", stored)
+	tokenGot, err := repo.GetToken("reset1")
+	assert.NoError(t, err)
+	assert.True(t, tokenGot.UsedAt.IsZero())
+
+	assert.NoError(t, repo.ResetPassword("reset1", "new", now))
+	assert.NoError(t, db.QueryRow("SELECT password FROM users WHERE id = $1", userID).Scan(&stored))
+	assert.Equal(t, "new", stored)
+	sessionGot, err := repo.GetSession("bbbbbbbbbbbbbbbbbbbbbbbbbbbbbbb1")
+	assert.NoError(t, err)
+	assert.False(t, sessionGot.RevokedAt.IsZero())
+
+	assert.Equal(t, entity.ErrInvalidToken, repo.ResetPassword("reset1", "newer", now))
+	// the user's other reset tokens are used up with it
+	assert.Equal(t, entity.ErrInvalidToken, repo.ResetPassword("reset2", "newer", now))
+	assert.Equal(t, entity.ErrInvalidToken, repo.ResetPassword("verify9", "newer", now))
+	assert.Equal(t, entity.ErrInvalidToken, repo.ResetPassword("missing", "newer", now))
+
+	tokenGot, err = repo.GetToken("verify9")
+	assert.NoError(t, err)
+	assert.True(t, tokenGot.UsedAt.IsZero())
+}
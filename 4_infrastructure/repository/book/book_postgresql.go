@@ -4,6 +4,9 @@ import (
 	"database/sql"
 	"fmt"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/pgerr"
+	"github.com/lib/pq"
+	"sort"
 	"time"
 )
 
@@ -15,16 +18,80 @@ func NewBooks(db *sql.DB) *PostgreSQL {
 	return &PostgreSQL{db: db}
 }
 
+// ratingsCTE sums up the published reviews of every book for the reads to join on.
+const ratingsCTE = "WITH ratings AS (SELECT id_book, ROUND(AVG(rating), 2) AS rating, COUNT(*) AS rating_count FROM book_reviews WHERE status = 'published' GROUP BY id_book) "
+
 func (r *PostgreSQL) Create(b *entity.Book) error {
-	_, err := r.db.Exec("INSERT INTO books (id, tittle, author, pages, quantity, created_at, updated_at) VALUES($1,$2,$3,$4,$5,$6,$7)",
-		b.ID, b.Tittle, b.Author, b.Pages, b.Quantity, b.CreatedAt, time.Time{})
-	return err
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	if b.ID == 0 {
+		err = tx.QueryRow("INSERT INTO books (isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, series_id, volume, branch, barcode, version, created_at, updated_at) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,NULLIF($13, 0),$14,$15,$16,$17,$18,$19) RETURNING id",
+			b.ISBN, b.Tittle, b.Author, b.Pages, b.Quantity, b.Available, b.Publisher, b.PublicationYear, b.Edition, b.Language, b.Description, b.Format, b.SeriesID, b.Volume, b.Branch, b.Barcode, b.Version, b.CreatedAt, time.Time{}).Scan(&b.ID)
+		if pgerr.IsUniqueViolation(err) {
+			return entity.ErrConflict
+		}
+		if pgerr.IsForeignKeyViolation(err) {
+			// no such series
+			return entity.ErrInvalidEntity
+		}
+		if err != nil {
+			return err
+		}
+	} else {
+		_, err = tx.Exec("INSERT INTO books (id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, series_id, volume, branch, barcode, version, created_at, updated_at) VALUES($1,$2,$3,$4,$5,$6,$7,$8,$9,$10,$11,$12,$13,NULLIF($14, 0),$15,$16,$17,$18,$19,$20)",
+			b.ID, b.ISBN, b.Tittle, b.Author, b.Pages, b.Quantity, b.Available, b.Publisher, b.PublicationYear, b.Edition, b.Language, b.Description, b.Format, b.SeriesID, b.Volume, b.Branch, b.Barcode, b.Version, b.CreatedAt, time.Time{})
+		if pgerr.IsUniqueViolation(err) {
+			// the id is taken, even by a soft-deleted book, or a live book has the ISBN
+			return entity.ErrConflict
+		}
+		if pgerr.IsForeignKeyViolation(err) {
+			return entity.ErrInvalidEntity
+		}
+		if err != nil {
+			return err
+		}
+		// keep the identity ahead of client-picked ids so generated ones don't collide
+		_, err = tx.Exec("SELECT setval(pg_get_serial_sequence('books', 'id'), GREATEST((SELECT MAX(id) FROM books), COALESCE(pg_sequence_last_value(pg_get_serial_sequence('books', 'id')::regclass), 1)))")
+		if err != nil {
+			return err
+		}
+	}
+
+	err = insertMovement(tx, b.ID, b.Quantity, b.Available, movementNote(b.Movement, entity.MovementPurchase))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
 }
 
 func (r *PostgreSQL) GetByID(id int) (*entity.Book, error) {
 	var book entity.Book
-	row := r.db.QueryRow("SELECT id, tittle, author, pages, quantity, created_at, updated_at FROM books WHERE id = $1", id)
-	err := row.Scan(&book.ID, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.CreatedAt, &book.UpdatedAt)
+	row := r.db.QueryRow(ratingsCTE+"SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE id = $1 AND deleted_at IS NULL", id)
+	err := row.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	return &book, err
+}
+
+func (r *PostgreSQL) GetByISBN(isbn string) (*entity.Book, error) {
+	var book entity.Book
+	row := r.db.QueryRow(ratingsCTE+"SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE isbn = $1 AND deleted_at IS NULL", isbn)
+	err := row.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	return &book, err
+}
+
+func (r *PostgreSQL) GetByBarcode(barcode string) (*entity.Book, error) {
+	var book entity.Book
+	row := r.db.QueryRow(ratingsCTE+"SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE barcode = $1 AND deleted_at IS NULL", barcode)
+	err := row.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
 	if err == sql.ErrNoRows {
 		return nil, entity.ErrNotFound
 	}
@@ -32,7 +99,7 @@ func (r *PostgreSQL) GetByID(id int) (*entity.Book, error) {
 }
 
 func (r *PostgreSQL) GetAll() ([]*entity.Book, error) {
-	rows, err := r.db.Query("SELECT id, tittle, author, pages, quantity, created_at, updated_at FROM books")
+	rows, err := r.db.Query(ratingsCTE + "SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE deleted_at IS NULL")
 	if err != nil {
 		return nil, err
 	}
@@ -40,7 +107,7 @@ func (r *PostgreSQL) GetAll() ([]*entity.Book, error) {
 	var books []*entity.Book
 	for rows.Next() {
 		var book entity.Book
-		err = rows.Scan(&book.ID, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.CreatedAt, &book.UpdatedAt)
+		err = rows.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
 		if err != nil {
 			return nil, err
 		}
@@ -49,36 +116,536 @@ func (r *PostgreSQL) GetAll() ([]*entity.Book, error) {
 	return books, nil
 }
 
+// GetBySeries lists a series in reading order; unnumbered volumes come last.
+func (r *PostgreSQL) GetBySeries(seriesID int) ([]*entity.Book, error) {
+	rows, err := r.db.Query(ratingsCTE+"SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE series_id = $1 AND deleted_at IS NULL ORDER BY volume = 0, volume, id", seriesID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*entity.Book{}
+	for rows.Next() {
+		var book entity.Book
+		err = rows.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, &book)
+	}
+	return books, rows.Err()
+}
+
+// GetByBranch lists the books kept at a branch by id.
+func (r *PostgreSQL) GetByBranch(branch string) ([]*entity.Book, error) {
+	rows, err := r.db.Query(ratingsCTE+"SELECT id, isbn, tittle, author, pages, quantity, available, publisher, publication_year, edition, language, description, format, COALESCE(series_id, 0), volume, branch, barcode, COALESCE(rating, 0), COALESCE(rating_count, 0), version, created_at, updated_at FROM books LEFT JOIN ratings ON ratings.id_book = books.id WHERE branch = $1 AND deleted_at IS NULL ORDER BY id", branch)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	books := []*entity.Book{}
+	for rows.Next() {
+		var book entity.Book
+		err = rows.Scan(&book.ID, &book.ISBN, &book.Tittle, &book.Author, &book.Pages, &book.Quantity, &book.Available, &book.Publisher, &book.PublicationYear, &book.Edition, &book.Language, &book.Description, &book.Format, &book.SeriesID, &book.Volume, &book.Branch, &book.Barcode, &book.Rating, &book.RatingCount, &book.Version, &book.CreatedAt, &book.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		books = append(books, &book)
+	}
+	return books, rows.Err()
+}
+
 func (r *PostgreSQL) Update(e *entity.Book) error {
-	res, err := r.db.Exec("UPDATE books SET tittle = $1, author = $2, pages = $3, quantity = $4, updated_at = $5 WHERE id = $6",
-		e.Tittle, e.Author, e.Pages, e.Quantity, e.UpdatedAt, e.ID)
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
-	rowsAff, err := res.RowsAffected()
+	defer tx.Rollback()
+
+	var oldQuantity int
+	err = tx.QueryRow("SELECT quantity FROM books WHERE id = $1 AND deleted_at IS NULL FOR UPDATE", e.ID).Scan(&oldQuantity)
+	if err == sql.ErrNoRows {
+		return entity.ErrNotFound
+	}
 	if err != nil {
 		return err
 	}
-	if rowsAff != 1 {
-		return fmt.Errorf("weird behavior, total rows affected = %d", rowsAff)
+
+	var available, version int
+	err = tx.QueryRow("UPDATE books SET isbn = $1, tittle = $2, author = $3, pages = $4, available = available + ($5 - quantity), quantity = $5, publisher = $6, publication_year = $7, edition = $8, language = $9, description = $10, format = $11, series_id = NULLIF($12, 0), volume = $13, branch = $14, barcode = $15, updated_at = $16, version = version + 1 WHERE id = $17 AND version = $18 AND deleted_at IS NULL AND $5 >= quantity - available RETURNING available, version",
+		e.ISBN, e.Tittle, e.Author, e.Pages, e.Quantity, e.Publisher, e.PublicationYear, e.Edition, e.Language, e.Description, e.Format, e.SeriesID, e.Volume, e.Branch, e.Barcode, e.UpdatedAt, e.ID, e.Version).Scan(&available, &version)
+	if err == sql.ErrNoRows {
+		return r.whyNotUpdated(tx, e)
+	}
+	if pgerr.IsUniqueViolation(err) {
+		// the volume is taken in that series
+		return entity.ErrConflict
+	}
+	if pgerr.IsForeignKeyViolation(err) {
+		return entity.ErrInvalidEntity
+	}
+	if err != nil {
+		return err
+	}
+
+	if delta := e.Quantity - oldQuantity; delta != 0 {
+		err = insertMovement(tx, e.ID, delta, delta, movementNote(e.Movement, entity.MovementCorrection))
+		if err != nil {
+			return err
+		}
 	}
 
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	e.Available, e.Version = available, version
 	return nil
 }
 
-func (r *PostgreSQL) Delete(id int) error {
-	res, err := r.db.Exec("DELETE FROM books WHERE id = $1", id)
+// whyNotUpdated tells whether Update missed because of the version or because the
+// new quantity would not cover the copies on loan.
+func (r *PostgreSQL) whyNotUpdated(tx *sql.Tx, e *entity.Book) error {
+	var version int
+	err := tx.QueryRow("SELECT version FROM books WHERE id = $1", e.ID).Scan(&version)
+	if err != nil {
+		return err
+	}
+	if version == e.Version {
+		return entity.ErrBelowOnLoan
+	}
+	return entity.ErrVersionConflict
+}
+
+// CheckOut lends a copy to the user: the copy leaves stock, the loan is recorded and
+// the ledger notes it, all in one transaction. Loans change nowhere else.
+func (r *PostgreSQL) CheckOut(id, userID int) error {
+	tx, err := r.db.Begin()
 	if err != nil {
 		return err
 	}
+	defer tx.Rollback()
 
+	res, err := tx.Exec("UPDATE users SET version = version + 1 WHERE id = $1 AND deleted_at IS NULL", userID)
+	if err != nil {
+		return err
+	}
 	rowsAff, err := res.RowsAffected()
 	if err != nil {
 		return err
 	}
+	if rowsAff == 0 {
+		return fmt.Errorf("user %w", entity.ErrNotFound)
+	}
+
+	res, err = tx.Exec("UPDATE books SET available = available - 1, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND available > 0", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err = res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		err = r.missingOrChanged(id)
+		if err == entity.ErrVersionConflict {
+			return entity.ErrNoCopiesAvailable
+		}
+		return err
+	}
+
+	now := time.Now()
+	res, err = tx.Exec("INSERT INTO users_books (id_user, id_book, borrowed_at, due_at) SELECT $1, $2, $3, $4 WHERE NOT EXISTS (SELECT 1 FROM users_books WHERE id_user = $1 AND id_book = $2)",
+		userID, id, now, now.Add(entity.LoanPeriod))
+	if err != nil {
+		return err
+	}
+	rowsAff, err = res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrConflict
+	}
+	_, err = tx.Exec("INSERT INTO loans (id_user, id_book, borrowed_at) VALUES ($1, $2, $3)", userID, id, now)
+	if err != nil {
+		return err
+	}
+
+	err = insertMovement(tx, id, 0, -1, &entity.Movement{Reason: entity.MovementLoan, Actor: userActor(userID)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// CheckIn ends the user's loan of the book and puts the copy back in stock in one
+// transaction. It fails with ErrNotBorrowed when the user doesn't have the book.
+func (r *PostgreSQL) CheckIn(id, userID int) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("DELETE FROM users_books WHERE id_user = $1 AND id_book = $2", userID, id)
+	if err != nil {
+		return err
+	}
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrNotBorrowed
+	}
+
+	res, err = tx.Exec("UPDATE books SET available = available + 1, version = version + 1 WHERE id = $1 AND deleted_at IS NULL AND available < quantity", id)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err = res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		err = r.missingOrChanged(id)
+		if err == entity.ErrVersionConflict {
+			return fmt.Errorf("all copies of book %d are already in stock", id)
+		}
+		return err
+	}
+
+	_, err = tx.Exec("UPDATE users SET version = version + 1 WHERE id = $1", userID)
+	if err != nil {
+		return err
+	}
+
+	err = insertMovement(tx, id, 0, 1, &entity.Movement{Reason: entity.MovementReturn, Actor: userActor(userID)})
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+func (r *PostgreSQL) GetMovements(id int) ([]*entity.Movement, error) {
+	var exists bool
+	// movements of a soft-deleted book are still there for the auditors
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM books WHERE id = $1)", id).Scan(&exists)
+	if err != nil {
+		return nil, err
+	}
+	if !exists {
+		return nil, entity.ErrNotFound
+	}
+
+	rows, err := r.db.Query("SELECT id, id_book, delta, available_delta, reason, actor, reference, created_at FROM book_movements WHERE id_book = $1 ORDER BY id", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	movements := []*entity.Movement{}
+	for rows.Next() {
+		var m entity.Movement
+		err = rows.Scan(&m.ID, &m.BookID, &m.Delta, &m.AvailableDelta, &m.Reason, &m.Actor, &m.Reference, &m.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		movements = append(movements, &m)
+	}
+	return movements, rows.Err()
+}
+
+// Reconcile lists the books whose quantity or available copies differ from the sum of their movements.
+func (r *PostgreSQL) Reconcile() ([]*entity.StockMismatch, error) {
+	rows, err := r.db.Query("SELECT b.id, b.quantity, b.available, COALESCE(SUM(m.delta), 0), COALESCE(SUM(m.available_delta), 0) FROM books b LEFT JOIN book_movements m ON m.id_book = b.id WHERE b.deleted_at IS NULL GROUP BY b.id HAVING b.quantity <> COALESCE(SUM(m.delta), 0) OR b.available <> COALESCE(SUM(m.available_delta), 0) ORDER BY b.id")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var mismatches []*entity.StockMismatch
+	for rows.Next() {
+		var m entity.StockMismatch
+		err = rows.Scan(&m.BookID, &m.Quantity, &m.Available, &m.LedgerQuantity, &m.LedgerAvailable)
+		if err != nil {
+			return nil, err
+		}
+		mismatches = append(mismatches, &m)
+	}
+	return mismatches, rows.Err()
+}
+
+// Merge folds the duplicates into the survivor. The stock moves through the ledger
+// so each side shows where its copies went, loans are relinked and the duplicates
+// are soft-deleted and marked as merged so they can't be restored.
+func (r *PostgreSQL) Merge(survivorID int, duplicateIDs []int, actor string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	// lock in id order so that two overlapping merges can't deadlock
+	ids := append([]int{survivorID}, duplicateIDs...)
+	sort.Ints(ids)
+	rows, err := tx.Query("SELECT id, quantity, available, isbn FROM books WHERE id = ANY($1) AND deleted_at IS NULL ORDER BY id FOR UPDATE", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	type stock struct {
+		quantity, available int
+		isbn                string
+	}
+	found := make(map[int]stock)
+	for rows.Next() {
+		var id int
+		var s stock
+		err = rows.Scan(&id, &s.quantity, &s.available, &s.isbn)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		found[id] = s
+	}
+	rows.Close()
+	if err = rows.Err(); err != nil {
+		return err
+	}
+	if len(found) != len(ids) {
+		return entity.ErrNotFound
+	}
+
+	var quantity, available int
+	isbn := found[survivorID].isbn
+	for _, id := range duplicateIDs {
+		dup := found[id]
+		quantity += dup.quantity
+		available += dup.available
+		if isbn == "" {
+			isbn = dup.isbn
+		}
+
+		err = insertMovement(tx, id, -dup.quantity, -dup.available, &entity.Movement{Reason: entity.MovementCorrection, Actor: actor, Reference: fmt.Sprintf("merged into book %d", survivorID)})
+		if err != nil {
+			return err
+		}
+		err = insertMovement(tx, survivorID, dup.quantity, dup.available, &entity.Movement{Reason: entity.MovementCorrection, Actor: actor, Reference: fmt.Sprintf("merged from book %d", id)})
+		if err != nil {
+			return err
+		}
+	}
+
+	// the duplicates go first so the survivor can take over an ISBN
+	_, err = tx.Exec("UPDATE books SET quantity = 0, available = 0, deleted_at = $1, merged_into = $2, version = version + 1 WHERE id = ANY($3)", time.Now(), survivorID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE books SET quantity = quantity + $1, available = available + $2, isbn = $3, version = version + 1 WHERE id = $4", quantity, available, isbn, survivorID)
+	if err != nil {
+		return err
+	}
+	//loan usecase code
+	_, err = tx.Exec("UPDATE users SET version = version + 1 WHERE id IN (SELECT id_user FROM users_books WHERE id_book = ANY($1))", pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE users_books SET id_book = $1 WHERE id_book = ANY($2)", survivorID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("UPDATE loans SET id_book = $1 WHERE id_book = ANY($2)", survivorID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	//end of loan usecase code
+	// a reviewer keeps one review, so only the first of theirs moves when the survivor has none
+	_, err = tx.Exec("UPDATE book_reviews SET id_book = $1 WHERE id_book = ANY($2) AND NOT EXISTS (SELECT 1 FROM book_reviews o WHERE o.id_user = book_reviews.id_user AND (o.id_book = $1 OR (o.id_book = ANY($2) AND o.id < book_reviews.id)))", survivorID, pq.Array(duplicateIDs))
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
+
+// HasBorrowed looks through the loan history, which keeps the returned loans and
+// follows merged duplicates to the survivor, for a loan of the book to the user.
+func (r *PostgreSQL) HasBorrowed(id, userID int) (bool, error) {
+	var borrowed bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM loans WHERE id_book = $1 AND id_user = $2)", id, userID).Scan(&borrowed)
+	return borrowed, err
+}
+
+func (r *PostgreSQL) GetBorrowers(id int) ([]int, error) {
+	rows, err := r.db.Query("SELECT id_user FROM users_books WHERE id_book = $1 ORDER BY id_user", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var users []int
+	for rows.Next() {
+		var u int
+		err = rows.Scan(&u)
+		if err != nil {
+			return nil, err
+		}
+		users = append(users, u)
+	}
+	return users, rows.Err()
+}
+
+func (r *PostgreSQL) Delete(id, version int, mode string) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE books SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL", time.Now(), id, version)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return r.missingOrChanged(id)
+	}
 	if rowsAff != 1 {
 		return fmt.Errorf("weird behavior, total rows affected = %d", rowsAff)
 	}
 
+	//loan usecase code
+	// lock the loans along with the book so none is returned between the check and the commit
+	borrowers, err := lockedLoans(tx, "SELECT id_user FROM users_books WHERE id_book = $1 ORDER BY id_user FOR UPDATE", id)
+	if err != nil {
+		return err
+	}
+	if len(borrowers) > 0 && mode == entity.DeleteRestrict {
+		return &entity.OutstandingLoansError{UserIDs: borrowers}
+	}
+	onLoan := len(borrowers)
+	if mode == entity.DeleteForceReturn && onLoan > 0 {
+		_, err = tx.Exec("UPDATE books SET available = available + $1 WHERE id = $2", onLoan, id)
+		if err != nil {
+			return err
+		}
+		err = insertMovement(tx, id, 0, onLoan, &entity.Movement{Reason: entity.MovementReturn, Actor: systemActor, Reference: fmt.Sprintf("book %d deleted", id)})
+		if err != nil {
+			return err
+		}
+	}
+	if mode == entity.DeleteWriteOff && onLoan > 0 {
+		_, err = tx.Exec("UPDATE books SET quantity = quantity - $1 WHERE id = $2", onLoan, id)
+		if err != nil {
+			return err
+		}
+		err = insertMovement(tx, id, -onLoan, 0, &entity.Movement{Reason: entity.MovementLoss, Actor: systemActor, Reference: fmt.Sprintf("book %d deleted", id)})
+		if err != nil {
+			return err
+		}
+	}
+	_, err = tx.Exec("UPDATE users SET version = version + 1 WHERE id IN (SELECT id_user FROM users_books WHERE id_book = $1)", id)
+	if err != nil {
+		return err
+	}
+	_, err = tx.Exec("DELETE FROM users_books WHERE id_book = $1", id)
+	if err != nil {
+		return err
+	}
+	//end of loan usecase code
+	return tx.Commit()
+}
+
+func (r *PostgreSQL) Restore(id int) error {
+	res, err := r.db.Exec("UPDATE books SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL AND merged_into IS NULL", id)
+	if pgerr.IsUniqueViolation(err) {
+		// the ISBN was reused while the book was deleted
+		return entity.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrNotFound
+	}
+
 	return nil
 }
+
+func (r *PostgreSQL) PurgeDeleted(before time.Time) (int, error) {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
+	//loan usecase code
+	_, err = tx.Exec("DELETE FROM users_books WHERE id_book IN (SELECT id FROM books WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM loans WHERE id_book IN (SELECT id FROM books WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	//end of loan usecase code
+	_, err = tx.Exec("DELETE FROM book_reviews WHERE id_book IN (SELECT id FROM books WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM books WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAff), tx.Commit()
+}
+
+func lockedLoans(tx *sql.Tx, query string, id int) ([]int, error) {
+	rows, err := tx.Query(query, id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	ids := []int{}
+	for rows.Next() {
+		var other int
+		err = rows.Scan(&other)
+		if err != nil {
+			return nil, err
+		}
+		ids = append(ids, other)
+	}
+	return ids, rows.Err()
+}
+
+// missingOrChanged tells why a compare-and-swap on a book matched no row.
+func (r *PostgreSQL) missingOrChanged(id int) error {
+	var exists bool
+	err := r.db.QueryRow("SELECT EXISTS (SELECT 1 FROM books WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return entity.ErrNotFound
+	}
+	return entity.ErrVersionConflict
+}
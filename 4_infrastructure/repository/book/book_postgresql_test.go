@@ -2,6 +2,7 @@ package repositoryBook
 
 import (
 	"database/sql"
+	"fmt"
 	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
 	_ "github.com/lib/pq"
@@ -38,6 +39,22 @@ func setUp() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	_, err = db.Exec("DELETE FROM book_movements")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM series")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM book_reviews")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM loans")
+	if err != nil {
+		log.Fatal(err)
+	}
 	_, err = db.Exec("INSERT INTO books (id, tittle, author, pages, quantity, created_at, updated_at) VALUES($1,$2,$3,$4,$5,$6,$7)",
 		initialBook.ID, initialBook.Tittle, initialBook.Author, initialBook.Pages, initialBook.Quantity, time.Time{}, time.Time{})
 	if err != nil {
@@ -52,6 +69,18 @@ func tearDown() {
 	if err != nil {
 		log.Fatal(err)
 	}
+	_, err = db.Exec("DELETE FROM series")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM book_reviews")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM loans")
+	if err != nil {
+		log.Fatal(err)
+	}
 }
 
 func TestMain(m *testing.M) {
@@ -62,7 +91,7 @@ func TestMain(m *testing.M) {
 
 func TestCreate(t *testing.T) {
 	bookRepo := NewBooks(db)
-	bookArg1 := &entity.Book{ID: 2, Tittle: "Handbook of Steel Construction", Author: "CISC ICCA", Pages: 354, Quantity: 10, CreatedAt: time.Time{}, UpdatedAt: time.Time{}}
+	bookArg1 := &entity.Book{ID: 2, Tittle: "Handbook of Steel Construction", Author: "CISC ICCA", Pages: 354, Quantity: 10, Publisher: "Canadian Institute of Steel Construction", PublicationYear: 2021, Edition: "12th", Language: "en", Description: "Design of steel structures", Format: entity.FormatHardcover, Version: 1, CreatedAt: time.Time{}, UpdatedAt: time.Time{}}
 	tests := []bookTest{
 		{args: bookArgs{book: bookArg1}, want: bookWant{book: bookArg1, err: nil}},
 	}
@@ -82,6 +111,21 @@ func TestCreate(t *testing.T) {
 	}
 }
 
+func TestCreate_GeneratedID(t *testing.T) {
+	bookRepo := NewBooks(db)
+	book := &entity.Book{Tittle: "Steel Designers' Manual", Author: "Davison B", Pages: 1400, Quantity: 1}
+
+	err := bookRepo.Create(book)
+	assert.NoError(t, err)
+	assert.Greater(t, book.ID, 2)
+
+	bookGot, err := bookRepo.GetByID(book.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, book.Tittle, bookGot.Tittle)
+
+	assert.NoError(t, bookRepo.Delete(book.ID, book.Version, entity.DeleteRestrict))
+}
+
 func TestGetByID(t *testing.T) {
 	bookRepo := NewBooks(db)
 	bookArg1 := &entity.Book{ID: 1}
@@ -96,6 +140,23 @@ func TestGetByID(t *testing.T) {
 	}
 }
 
+func TestGetByISBN(t *testing.T) {
+	bookRepo := NewBooks(db)
+	bookArg1 := &entity.Book{ID: 3, ISBN: "9780140449136", Tittle: "The Odyssey", Author: "Homer", Pages: 541, Quantity: 3}
+	err := bookRepo.Create(bookArg1)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	bookGot, errGot := bookRepo.GetByISBN("9780140449136")
+	assert.NoError(t, errGot)
+	assert.Equal(t, bookArg1.ID, bookGot.ID)
+
+	bookGot, errGot = bookRepo.GetByISBN("9780306406157")
+	assert.Nil(t, bookGot)
+	assert.Equal(t, entity.ErrNotFound, errGot)
+}
+
 func TestGetAll(t *testing.T) {
 	bookRepo := NewBooks(db)
 
@@ -112,7 +173,7 @@ func TestGetAll(t *testing.T) {
 
 func TestUpdate(t *testing.T) {
 	bookRepo := NewBooks(db)
-	bookArg1 := &entity.Book{ID: 1, Tittle: "UPD_Concrete Design Handbook", Author: "UPD_Tarkovskyi T", Pages: 290, Quantity: 5}
+	bookArg1 := &entity.Book{ID: 1, Tittle: "UPD_Concrete Design Handbook", Author: "UPD_Tarkovskyi T", Pages: 290, Quantity: 5, Version: 1}
 	tests := []bookTest{
 		{args: bookArgs{book: bookArg1}, want: bookWant{book: bookArg1, err: nil}},
 	}
@@ -130,16 +191,25 @@ func TestUpdate(t *testing.T) {
 		assert.Equal(t, bt.want.book, bookGot)
 		assert.Equal(t, bt.want.err, errGot)
 	}
+
+	stale := &entity.Book{ID: 1, Tittle: "Stale Concrete Design Handbook", Author: "Tarkovskyi T", Pages: 290, Quantity: 5, Version: 1}
+	assert.Equal(t, entity.ErrVersionConflict, bookRepo.Update(stale))
+
+	missing := &entity.Book{ID: 999, Tittle: "Missing", Author: "Nobody", Pages: 1, Quantity: 1, Version: 1}
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Update(missing))
 }
 
 func TestDelete(t *testing.T) {
 	bookRepo := NewBooks(db)
-	bookArg1 := &entity.Book{ID: 1}
+	assert.Equal(t, entity.ErrVersionConflict, bookRepo.Delete(1, 1, entity.DeleteRestrict))
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Delete(999, 1, entity.DeleteRestrict))
+
+	bookArg1 := &entity.Book{ID: 1, Version: 2}
 	tests := []bookTest{
 		{args: bookArgs{book: bookArg1}, want: bookWant{err: nil}},
 	}
 	for _, bt := range tests {
-		errGot := bookRepo.Delete(bt.args.book.ID)
+		errGot := bookRepo.Delete(bt.args.book.ID, bt.args.book.Version, entity.DeleteRestrict)
 		bookGot, err := bookRepo.GetByID(bt.args.book.ID)
 
 		assert.NotNil(t, err)
@@ -147,3 +217,314 @@ func TestDelete(t *testing.T) {
 		assert.Equal(t, bt.want.err, errGot)
 	}
 }
+
+func TestRestore(t *testing.T) {
+	bookRepo := NewBooks(db)
+
+	err := bookRepo.Restore(1)
+	assert.NoError(t, err)
+	bookGot, err := bookRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, bookGot.Version)
+
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Restore(1))
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Restore(999))
+}
+
+func TestPurgeDeleted(t *testing.T) {
+	bookRepo := NewBooks(db)
+
+	bookGot, err := bookRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.NoError(t, bookRepo.Delete(1, bookGot.Version, entity.DeleteRestrict))
+
+	n, err := bookRepo.PurgeDeleted(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 0, n)
+
+	_, err = db.Exec("UPDATE books SET deleted_at = $1 WHERE id = 1", time.Now().Add(-2*time.Hour))
+	assert.NoError(t, err)
+	n, err = bookRepo.PurgeDeleted(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Restore(1))
+}
+
+func TestDelete_OutstandingLoans(t *testing.T) {
+	bookRepo := NewBooks(db)
+	returned := &entity.Book{Tittle: "Structural Steel Design", Author: "McCormac J", Pages: 720, Quantity: 2, Version: 1}
+	lost := &entity.Book{Tittle: "Reinforced Concrete", Author: "Wight J", Pages: 1150, Quantity: 2, Available: 1, Version: 1}
+	assert.NoError(t, bookRepo.Create(returned))
+	assert.NoError(t, bookRepo.Create(lost))
+
+	_, err := db.Exec("INSERT INTO users_books (id_user, id_book) VALUES (50, $1), (51, $1), (50, $2)", returned.ID, lost.ID)
+	assert.NoError(t, err)
+
+	borrowers, err := bookRepo.GetBorrowers(returned.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{50, 51}, borrowers)
+
+	err = bookRepo.Delete(returned.ID, 1, entity.DeleteRestrict)
+	var loansErr *entity.OutstandingLoansError
+	assert.ErrorAs(t, err, &loansErr)
+	assert.Equal(t, []int{50, 51}, loansErr.UserIDs)
+	// the refused delete rolled back and left the version alone
+	_, err = bookRepo.GetByID(returned.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, bookRepo.Delete(returned.ID, 1, entity.DeleteForceReturn))
+	assert.NoError(t, bookRepo.Delete(lost.ID, 1, entity.DeleteWriteOff))
+
+	var quantity, available int
+	err = db.QueryRow("SELECT quantity, available FROM books WHERE id = $1", returned.ID).Scan(&quantity, &available)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, quantity)
+	assert.Equal(t, 2, available)
+	err = db.QueryRow("SELECT quantity, available FROM books WHERE id = $1", lost.ID).Scan(&quantity, &available)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, quantity)
+	assert.Equal(t, 1, available)
+
+	borrowers, err = bookRepo.GetBorrowers(returned.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, borrowers)
+	borrowers, err = bookRepo.GetBorrowers(lost.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, borrowers)
+}
+
+func TestCheckOut(t *testing.T) {
+	bookRepo := NewBooks(db)
+	book := &entity.Book{Tittle: "Design of Wood Structures", Author: "Breyer D", Pages: 1000, Quantity: 2, Available: 1, Version: 1}
+	assert.NoError(t, bookRepo.Create(book))
+	_, err := db.Exec("INSERT INTO users (id, first_name, email, version, created_at) VALUES (49, 'Maksym', 'maksym49@gmail.com', 1, now()), (50, 'Lena', 'lena50@gmail.com', 1, now())")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id IN (49, 50)")
+	defer db.Exec("DELETE FROM users_books WHERE id_user IN (49, 50)")
+
+	assert.NoError(t, bookRepo.CheckOut(book.ID, 50))
+	assert.Equal(t, entity.ErrNoCopiesAvailable, bookRepo.CheckOut(book.ID, 49))
+	assert.Equal(t, entity.ErrNotFound, bookRepo.CheckOut(999, 50))
+	assert.ErrorIs(t, bookRepo.CheckOut(book.ID, 999), entity.ErrNotFound)
+
+	assert.NoError(t, bookRepo.CheckIn(book.ID, 50))
+	assert.Equal(t, entity.ErrNotBorrowed, bookRepo.CheckIn(book.ID, 50))
+	// the copy that was out before loans were recorded comes back too
+	_, err = db.Exec("INSERT INTO users_books (id_user, id_book) VALUES (49, $1)", book.ID)
+	assert.NoError(t, err)
+	assert.NoError(t, bookRepo.CheckIn(book.ID, 49))
+	_, err = db.Exec("INSERT INTO users_books (id_user, id_book) VALUES (49, $1)", book.ID)
+	assert.NoError(t, err)
+	assert.Error(t, bookRepo.CheckIn(book.ID, 49))
+	// the failed return keeps the loan
+	_, err = db.Exec("DELETE FROM users_books WHERE id_user = 49")
+	assert.NoError(t, err)
+
+	// both copies go out again, so the total can't drop to one
+	assert.NoError(t, bookRepo.CheckOut(book.ID, 50))
+	assert.NoError(t, bookRepo.CheckOut(book.ID, 49))
+	bookGot, err := bookRepo.GetByID(book.ID)
+	assert.NoError(t, err)
+	bookGot.Quantity = 1
+	assert.Equal(t, entity.ErrBelowOnLoan, bookRepo.Update(bookGot))
+	bookGot.Quantity = 3
+	bookGot.Movement = &entity.Movement{Reason: entity.MovementPurchase, Actor: "librarian", Reference: "INV-1042"}
+	assert.NoError(t, bookRepo.Update(bookGot))
+	assert.Equal(t, 1, bookGot.Available)
+
+	movements, err := bookRepo.GetMovements(book.ID)
+	assert.NoError(t, err)
+	var reasons []string
+	quantity, available := 0, 0
+	for _, m := range movements {
+		reasons = append(reasons, m.Reason)
+		quantity += m.Delta
+		available += m.AvailableDelta
+	}
+	assert.Equal(t, []string{"purchase", "loan", "return", "return", "loan", "loan", "purchase"}, reasons)
+	assert.Equal(t, "librarian", movements[len(movements)-1].Actor)
+	assert.Equal(t, 3, quantity)
+	assert.Equal(t, 1, available)
+
+	_, err = bookRepo.GetMovements(999)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestCheckOut_Loans(t *testing.T) {
+	bookRepo := NewBooks(db)
+	first := &entity.Book{Tittle: "Timber Design", Author: "Breyer D", Pages: 500, Quantity: 2, Available: 2, Version: 1}
+	second := &entity.Book{Tittle: "Masonry Design", Author: "Breyer D", Pages: 400, Quantity: 1, Available: 1, Version: 1}
+	assert.NoError(t, bookRepo.Create(first))
+	assert.NoError(t, bookRepo.Create(second))
+	_, err := db.Exec("INSERT INTO users (id, first_name, email, version, created_at) VALUES (48, 'Olha', 'olha48@gmail.com', 1, now())")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id = 48")
+	defer db.Exec("DELETE FROM users_books WHERE id_user = 48")
+
+	assert.NoError(t, bookRepo.CheckOut(first.ID, 48))
+	assert.NoError(t, bookRepo.CheckOut(second.ID, 48))
+	// one copy per user
+	assert.Equal(t, entity.ErrConflict, bookRepo.CheckOut(first.ID, 48))
+	bookGot, err := bookRepo.GetByID(first.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 1, bookGot.Available)
+
+	var borrowedAt, dueAt time.Time
+	err = db.QueryRow("SELECT borrowed_at, due_at FROM users_books WHERE id_user = 48 AND id_book = $1", first.ID).Scan(&borrowedAt, &dueAt)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.LoanPeriod, dueAt.Sub(borrowedAt))
+
+	// returning one book leaves the other loan as it was
+	assert.NoError(t, bookRepo.CheckIn(first.ID, 48))
+	borrowers, err := bookRepo.GetBorrowers(second.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{48}, borrowers)
+	borrowers, err = bookRepo.GetBorrowers(first.ID)
+	assert.NoError(t, err)
+	assert.Empty(t, borrowers)
+
+	var version int
+	assert.NoError(t, db.QueryRow("SELECT version FROM users WHERE id = 48").Scan(&version))
+	assert.Equal(t, 4, version)
+}
+
+func TestHasBorrowed_Rating(t *testing.T) {
+	bookRepo := NewBooks(db)
+	book := &entity.Book{Tittle: "Steel Design", Author: "Segui W", Pages: 700, Quantity: 1, Available: 1, Version: 1}
+	assert.NoError(t, bookRepo.Create(book))
+
+	_, err := db.Exec("INSERT INTO users (id, first_name, email, version, created_at) VALUES (51, 'Ivan', 'ivan51@gmail.com', 1, now())")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id = 51")
+
+	borrowed, err := bookRepo.HasBorrowed(book.ID, 51)
+	assert.NoError(t, err)
+	assert.False(t, borrowed)
+
+	defer db.Exec("DELETE FROM loans WHERE id_user = 51")
+
+	// a ledger entry signed with the user's name is no loan
+	_, err = db.Exec("INSERT INTO book_movements (id_book, delta, available_delta, reason, actor, reference) VALUES ($1, 0, 0, $2, 'user 51', '')", book.ID, entity.MovementLoan)
+	assert.NoError(t, err)
+	borrowed, err = bookRepo.HasBorrowed(book.ID, 51)
+	assert.NoError(t, err)
+	assert.False(t, borrowed)
+	_, err = db.Exec("DELETE FROM book_movements WHERE id_book = $1 AND actor = 'user 51'", book.ID)
+	assert.NoError(t, err)
+
+	assert.NoError(t, bookRepo.CheckOut(book.ID, 51))
+	assert.NoError(t, bookRepo.CheckIn(book.ID, 51))
+	borrowed, err = bookRepo.HasBorrowed(book.ID, 51)
+	assert.NoError(t, err)
+	assert.True(t, borrowed)
+
+	_, err = db.Exec("INSERT INTO book_reviews (id_book, id_user, rating, status, created_at, updated_at) VALUES ($1, 51, 4, 'published', now(), now()), ($1, 52, 1, 'published', now(), now()), ($1, 53, 1, 'hidden', now(), now())", book.ID)
+	assert.NoError(t, err)
+	bookGot, err := bookRepo.GetByID(book.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 2.5, bookGot.Rating)
+	assert.Equal(t, 2, bookGot.RatingCount)
+}
+
+func TestGetBySeries(t *testing.T) {
+	bookRepo := NewBooks(db)
+
+	var seriesID int
+	err := db.QueryRow("INSERT INTO series (name, created_at) VALUES ('The Expanse', now()) RETURNING id").Scan(&seriesID)
+	assert.NoError(t, err)
+
+	second := &entity.Book{Tittle: "Caliban's War", Author: "Corey J", Pages: 595, Quantity: 1, SeriesID: seriesID, Volume: 2, Version: 1}
+	first := &entity.Book{Tittle: "Leviathan Wakes", Author: "Corey J", Pages: 592, Quantity: 1, SeriesID: seriesID, Volume: 1, Version: 1}
+	extra := &entity.Book{Tittle: "The Churn", Author: "Corey J", Pages: 80, Quantity: 1, SeriesID: seriesID, Version: 1}
+	assert.NoError(t, bookRepo.Create(second))
+	assert.NoError(t, bookRepo.Create(extra))
+	assert.NoError(t, bookRepo.Create(first))
+
+	clash := &entity.Book{Tittle: "Abaddon's Gate", Author: "Corey J", Pages: 539, Quantity: 1, SeriesID: seriesID, Volume: 2, Version: 1}
+	assert.Equal(t, entity.ErrConflict, bookRepo.Create(clash))
+	noSeries := &entity.Book{Tittle: "Abaddon's Gate", Author: "Corey J", Pages: 539, Quantity: 1, SeriesID: seriesID + 1000, Volume: 3, Version: 1}
+	assert.Equal(t, entity.ErrInvalidEntity, bookRepo.Create(noSeries))
+
+	books, err := bookRepo.GetBySeries(seriesID)
+	assert.NoError(t, err)
+	var ids []int
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	assert.Equal(t, []int{first.ID, second.ID, extra.ID}, ids)
+	assert.Equal(t, 2, books[1].Volume)
+}
+
+func TestGetByBranch(t *testing.T) {
+	bookRepo := NewBooks(db)
+
+	second := &entity.Book{Tittle: "Intermezzo", Author: "Kotsiubynsky M", Pages: 40, Quantity: 1, Branch: "Chernihiv", Version: 1}
+	first := &entity.Book{Tittle: "Fata Morgana", Author: "Kotsiubynsky M", Pages: 180, Quantity: 2, Branch: "Chernihiv", Version: 1}
+	elsewhere := &entity.Book{Tittle: "Shadows of Forgotten Ancestors", Author: "Kotsiubynsky M", Pages: 120, Quantity: 1, Branch: "Kosiv", Version: 1}
+	deleted := &entity.Book{Tittle: "Apple Blossom", Author: "Kotsiubynsky M", Pages: 10, Quantity: 1, Branch: "Chernihiv", Version: 1}
+	for _, b := range []*entity.Book{first, second, elsewhere, deleted} {
+		assert.NoError(t, bookRepo.Create(b))
+	}
+	assert.NoError(t, bookRepo.Delete(deleted.ID, deleted.Version, entity.DeleteRestrict))
+
+	books, err := bookRepo.GetByBranch("Chernihiv")
+	assert.NoError(t, err)
+	var ids []int
+	for _, b := range books {
+		ids = append(ids, b.ID)
+	}
+	assert.Equal(t, []int{first.ID, second.ID}, ids)
+
+	books, err = bookRepo.GetByBranch("Uzhhorod")
+	assert.NoError(t, err)
+	assert.Empty(t, books)
+}
+
+func TestMerge(t *testing.T) {
+	bookRepo := NewBooks(db)
+	survivor := &entity.Book{Tittle: "Tobacco Road", Author: "Erskine Caldwell", Pages: 241, Quantity: 2, Available: 2, Version: 1}
+	dup := &entity.Book{ISBN: "9780820316581", Tittle: "Tobaco Road", Author: "Caldwell Erskine", Pages: 241, Quantity: 3, Available: 2, Version: 1}
+	assert.NoError(t, bookRepo.Create(survivor))
+	assert.NoError(t, bookRepo.Create(dup))
+	_, err := db.Exec("INSERT INTO users_books (id_user, id_book) VALUES (60, $1)", dup.ID)
+	assert.NoError(t, err)
+	// a loan of the duplicate that came back already
+	_, err = db.Exec("INSERT INTO loans (id_user, id_book) VALUES (61, $1)", dup.ID)
+	assert.NoError(t, err)
+
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Merge(survivor.ID, []int{dup.ID, 999}, "librarian"))
+	assert.NoError(t, bookRepo.Merge(survivor.ID, []int{dup.ID}, "librarian"))
+
+	bookGot, err := bookRepo.GetByID(survivor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, 5, bookGot.Quantity)
+	assert.Equal(t, 4, bookGot.Available)
+	assert.Equal(t, "9780820316581", bookGot.ISBN)
+	assert.Equal(t, 2, bookGot.Version)
+
+	borrowers, err := bookRepo.GetBorrowers(survivor.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{60}, borrowers)
+	borrowed, err := bookRepo.HasBorrowed(survivor.ID, 61)
+	assert.NoError(t, err)
+	assert.True(t, borrowed)
+
+	_, err = bookRepo.GetByID(dup.ID)
+	assert.Equal(t, entity.ErrNotFound, err)
+	assert.Equal(t, entity.ErrNotFound, bookRepo.Restore(dup.ID))
+
+	movements, err := bookRepo.GetMovements(dup.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, fmt.Sprintf("merged into book %d", survivor.ID), movements[len(movements)-1].Reference)
+
+	mismatches, err := bookRepo.Reconcile()
+	assert.NoError(t, err)
+	for _, m := range mismatches {
+		assert.NotEqual(t, survivor.ID, m.BookID)
+	}
+
+	_, err = db.Exec("DELETE FROM users_books WHERE id_user = 60")
+	assert.NoError(t, err)
+	_, err = db.Exec("DELETE FROM loans WHERE id_user = 61")
+	assert.NoError(t, err)
+}
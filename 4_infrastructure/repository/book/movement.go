@@ -0,0 +1,54 @@
+package repositoryBook
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"strconv"
+)
+
+// systemActor signs the movements nobody asked for explicitly, like copies
+// returned or written off when a book is deleted.
+const systemActor = "system"
+
+// insertMovement appends a ledger entry in the same transaction as the stock change it records.
+func insertMovement(tx *sql.Tx, bookID, delta, availableDelta int, note *entity.Movement) error {
+	_, err := tx.Exec("INSERT INTO book_movements (id_book, delta, available_delta, reason, actor, reference) VALUES ($1, $2, $3, $4, $5, $6)",
+		bookID, delta, availableDelta, note.Reason, note.Actor, note.Reference)
+	return err
+}
+
+// AdjustStock applies m to the book's copies and records it in the ledger, all in
+// tx, the caller's transaction. A book deleted or changed since version answers
+// entity.ErrVersionConflict.
+func AdjustStock(tx *sql.Tx, version int, m *entity.Movement) error {
+	res, err := tx.Exec("UPDATE books SET quantity = quantity + $1, available = available + $2, version = version + 1 WHERE id = $3 AND version = $4 AND deleted_at IS NULL",
+		m.Delta, m.AvailableDelta, m.BookID, version)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrVersionConflict
+	}
+	return insertMovement(tx, m.BookID, m.Delta, m.AvailableDelta, m)
+}
+
+// movementNote is the caller's note for a change, with reason filled in when the caller left it empty.
+func movementNote(note *entity.Movement, reason string) *entity.Movement {
+	m := entity.Movement{Reason: reason, Actor: systemActor}
+	if note != nil {
+		m = *note
+	}
+	if m.Reason == "" {
+		m.Reason = reason
+	}
+	return &m
+}
+
+func userActor(userID int) string {
+	return "user " + strconv.Itoa(userID)
+}
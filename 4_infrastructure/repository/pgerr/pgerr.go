@@ -0,0 +1,35 @@
+// Package pgerr tells apart the PostgreSQL errors the repositories turn into entity errors.
+package pgerr
+
+import (
+	"errors"
+	"github.com/lib/pq"
+)
+
+const (
+	uniqueViolation     = "23505"
+	foreignKeyViolation = "23503"
+)
+
+// IsUniqueViolation reports whether err is a unique constraint violation, of one of
+// the given constraints when any are named.
+func IsUniqueViolation(err error, constraints ...string) bool {
+	var pqErr *pq.Error
+	if !errors.As(err, &pqErr) || pqErr.Code != uniqueViolation {
+		return false
+	}
+	if len(constraints) == 0 {
+		return true
+	}
+	for _, c := range constraints {
+		if pqErr.Constraint == c {
+			return true
+		}
+	}
+	return false
+}
+
+func IsForeignKeyViolation(err error) bool {
+	var pqErr *pq.Error
+	return errors.As(err, &pqErr) && pqErr.Code == foreignKeyViolation
+}
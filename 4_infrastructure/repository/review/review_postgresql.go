@@ -0,0 +1,90 @@
+package repositoryReview
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/pgerr"
+	"time"
+)
+
+type PostgreSQL struct {
+	db *sql.DB
+}
+
+func NewReviews(db *sql.DB) *PostgreSQL {
+	return &PostgreSQL{db: db}
+}
+
+func (r *PostgreSQL) Create(e *entity.Review) error {
+	err := r.db.QueryRow("INSERT INTO book_reviews (id_book, id_user, rating, text, status, moderated_by, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8) RETURNING id",
+		e.BookID, e.UserID, e.Rating, e.Text, e.Status, e.ModeratedBy, e.CreatedAt, e.UpdatedAt).Scan(&e.ID)
+	if pgerr.IsUniqueViolation(err) {
+		// one review per user and book
+		return entity.ErrConflict
+	}
+	return err
+}
+
+func (r *PostgreSQL) GetByID(id int) (*entity.Review, error) {
+	var e entity.Review
+	err := r.db.QueryRow("SELECT id, id_book, id_user, rating, text, status, moderated_by, created_at, updated_at FROM book_reviews WHERE id = $1", id).
+		Scan(&e.ID, &e.BookID, &e.UserID, &e.Rating, &e.Text, &e.Status, &e.ModeratedBy, &e.CreatedAt, &e.UpdatedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	return &e, err
+}
+
+func (r *PostgreSQL) GetByBook(bookID int, status string) ([]*entity.Review, error) {
+	return r.query("SELECT id, id_book, id_user, rating, text, status, moderated_by, created_at, updated_at FROM book_reviews WHERE id_book = $1 AND status = $2 ORDER BY created_at DESC, id DESC", bookID, status)
+}
+
+func (r *PostgreSQL) GetAll(status string) ([]*entity.Review, error) {
+	return r.query("SELECT id, id_book, id_user, rating, text, status, moderated_by, created_at, updated_at FROM book_reviews WHERE $1 = '' OR status = $1 ORDER BY id", status)
+}
+
+func (r *PostgreSQL) SetStatus(id int, status, moderator string, at time.Time) error {
+	res, err := r.db.Exec("UPDATE book_reviews SET status = $1, moderated_by = $2, updated_at = $3 WHERE id = $4", status, moderator, at, id)
+	if err != nil {
+		return err
+	}
+	return notFoundIfNone(res)
+}
+
+func (r *PostgreSQL) Delete(id int) error {
+	res, err := r.db.Exec("DELETE FROM book_reviews WHERE id = $1", id)
+	if err != nil {
+		return err
+	}
+	return notFoundIfNone(res)
+}
+
+func (r *PostgreSQL) query(query string, args ...interface{}) ([]*entity.Review, error) {
+	rows, err := r.db.Query(query, args...)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	reviews := []*entity.Review{}
+	for rows.Next() {
+		var e entity.Review
+		err = rows.Scan(&e.ID, &e.BookID, &e.UserID, &e.Rating, &e.Text, &e.Status, &e.ModeratedBy, &e.CreatedAt, &e.UpdatedAt)
+		if err != nil {
+			return nil, err
+		}
+		reviews = append(reviews, &e)
+	}
+	return reviews, rows.Err()
+}
+
+func notFoundIfNone(res sql.Result) error {
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrNotFound
+	}
+	return nil
+}
@@ -0,0 +1,83 @@
+package repositoryReview
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+)
+
+var db *sql.DB
+
+func setUp() {
+	var err error
+	db, err = database.NewPostgresConnection(database.ConnectionInfo{Host: "localhost", Port: 5432, UserName: "crud-6", DBName: "crud-6-db", SSLMode: "disable", Password: "12345"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = db.Exec("DELETE FROM book_reviews")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func tearDown() {
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM book_reviews")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	setUp()
+	m.Run()
+	tearDown()
+}
+
+func TestReviews(t *testing.T) {
+	repo := NewReviews(db)
+	now := time.Now().UTC().Truncate(time.Second)
+
+	good := &entity.Review{BookID: 1, UserID: 2, Rating: 5, Text: "Great", Status: entity.ReviewPublished, CreatedAt: now, UpdatedAt: now}
+	assert.NoError(t, repo.Create(good))
+	assert.Greater(t, good.ID, 0)
+	assert.Equal(t, entity.ErrConflict, repo.Create(&entity.Review{BookID: 1, UserID: 2, Rating: 1, Status: entity.ReviewPublished, CreatedAt: now, UpdatedAt: now}))
+	spam := &entity.Review{BookID: 1, UserID: 3, Rating: 1, Text: "Buy now", Status: entity.ReviewPublished, CreatedAt: now.Add(time.Minute), UpdatedAt: now}
+	assert.NoError(t, repo.Create(spam))
+
+	reviewGot, err := repo.GetByID(good.ID)
+	assert.NoError(t, err)
+	reviewGot.CreatedAt = reviewGot.CreatedAt.UTC()
+	reviewGot.UpdatedAt = reviewGot.UpdatedAt.UTC()
+	assert.Equal(t, good, reviewGot)
+
+	reviews, err := repo.GetByBook(1, entity.ReviewPublished)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+	assert.Equal(t, spam.ID, reviews[0].ID)
+
+	assert.NoError(t, repo.SetStatus(spam.ID, entity.ReviewHidden, "librarian", now))
+	assert.Equal(t, entity.ErrNotFound, repo.SetStatus(999, entity.ReviewHidden, "librarian", now))
+	reviews, err = repo.GetByBook(1, entity.ReviewPublished)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+	reviews, err = repo.GetAll(entity.ReviewHidden)
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 1)
+	assert.Equal(t, "librarian", reviews[0].ModeratedBy)
+	reviews, err = repo.GetAll("")
+	assert.NoError(t, err)
+	assert.Len(t, reviews, 2)
+
+	assert.NoError(t, repo.Delete(spam.ID))
+	assert.Equal(t, entity.ErrNotFound, repo.Delete(spam.ID))
+	_, err = repo.GetByID(spam.ID)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
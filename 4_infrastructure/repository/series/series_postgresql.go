@@ -0,0 +1,53 @@
+package repositorySeries
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/pgerr"
+)
+
+type PostgreSQL struct {
+	db *sql.DB
+}
+
+func NewSeries(db *sql.DB) *PostgreSQL {
+	return &PostgreSQL{db: db}
+}
+
+func (r *PostgreSQL) Create(s *entity.Series) error {
+	err := r.db.QueryRow("INSERT INTO series (name, description, created_at) VALUES ($1, $2, $3) RETURNING id",
+		s.Name, s.Description, s.CreatedAt).Scan(&s.ID)
+	if pgerr.IsUniqueViolation(err) {
+		return entity.ErrConflict
+	}
+	return err
+}
+
+func (r *PostgreSQL) GetByID(id int) (*entity.Series, error) {
+	var s entity.Series
+	err := r.db.QueryRow("SELECT id, name, description, created_at FROM series WHERE id = $1", id).
+		Scan(&s.ID, &s.Name, &s.Description, &s.CreatedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	return &s, err
+}
+
+func (r *PostgreSQL) GetAll() ([]*entity.Series, error) {
+	rows, err := r.db.Query("SELECT id, name, description, created_at FROM series ORDER BY name")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var series []*entity.Series
+	for rows.Next() {
+		var s entity.Series
+		err = rows.Scan(&s.ID, &s.Name, &s.Description, &s.CreatedAt)
+		if err != nil {
+			return nil, err
+		}
+		series = append(series, &s)
+	}
+	return series, rows.Err()
+}
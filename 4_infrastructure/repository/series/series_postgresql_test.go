@@ -0,0 +1,73 @@
+package repositorySeries
+
+import (
+	"database/sql"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+)
+
+var db *sql.DB
+
+func setUp() {
+	var err error
+	db, err = database.NewPostgresConnection(database.ConnectionInfo{Host: "localhost", Port: 5432, UserName: "crud-6", DBName: "crud-6-db", SSLMode: "disable", Password: "12345"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = db.Exec("UPDATE books SET series_id = NULL, volume = 0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM series")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func tearDown() {
+	defer db.Close()
+
+	_, err := db.Exec("UPDATE books SET series_id = NULL, volume = 0")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM series")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	setUp()
+	m.Run()
+	tearDown()
+}
+
+func TestSeries(t *testing.T) {
+	repo := NewSeries(db)
+
+	expanse := &entity.Series{Name: "The Expanse", Description: "Space opera", CreatedAt: time.Now().UTC().Truncate(time.Second)}
+	assert.NoError(t, repo.Create(expanse))
+	assert.Greater(t, expanse.ID, 0)
+	assert.Equal(t, entity.ErrConflict, repo.Create(&entity.Series{Name: "The Expanse", CreatedAt: time.Now()}))
+	assert.NoError(t, repo.Create(&entity.Series{Name: "Discworld", CreatedAt: time.Now()}))
+
+	seriesGot, err := repo.GetByID(expanse.ID)
+	assert.NoError(t, err)
+	seriesGot.CreatedAt = seriesGot.CreatedAt.UTC()
+	assert.Equal(t, expanse, seriesGot)
+
+	_, err = repo.GetByID(999)
+	assert.Equal(t, entity.ErrNotFound, err)
+
+	all, err := repo.GetAll()
+	assert.NoError(t, err)
+	assert.Len(t, all, 2)
+	assert.Equal(t, "Discworld", all[0].Name)
+}
@@ -0,0 +1,119 @@
+package repositoryStocktake
+
+import (
+	"database/sql"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	repositoryBook "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/pgerr"
+	"time"
+)
+
+type PostgreSQL struct {
+	db *sql.DB
+}
+
+func NewStocktakes(db *sql.DB) *PostgreSQL {
+	return &PostgreSQL{db: db}
+}
+
+func (r *PostgreSQL) Create(s *entity.Stocktake) error {
+	err := r.db.QueryRow("INSERT INTO stocktakes (branch, status, opened_at) VALUES ($1, $2, $3) RETURNING id",
+		s.Branch, s.Status, s.OpenedAt).Scan(&s.ID)
+	if pgerr.IsUniqueViolation(err) {
+		// the branch already has an open session
+		return entity.ErrConflict
+	}
+	return err
+}
+
+func (r *PostgreSQL) GetByID(id int) (*entity.Stocktake, error) {
+	var s entity.Stocktake
+	var closedAt sql.NullTime
+	err := r.db.QueryRow("SELECT id, branch, status, opened_at, closed_at FROM stocktakes WHERE id = $1", id).
+		Scan(&s.ID, &s.Branch, &s.Status, &s.OpenedAt, &closedAt)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
+	if err != nil {
+		return nil, err
+	}
+	if closedAt.Valid {
+		s.ClosedAt = &closedAt.Time
+	}
+	return &s, nil
+}
+
+func (r *PostgreSQL) AddCounts(id int, counts []*entity.StocktakeCount) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for _, c := range counts {
+		_, err = tx.Exec("INSERT INTO stocktake_counts (id_stocktake, id_book, barcode, counted) VALUES ($1, $2, $3, $4)", id, c.BookID, c.Barcode, c.Counted)
+		if err != nil {
+			return err
+		}
+	}
+	return tx.Commit()
+}
+
+func (r *PostgreSQL) GetCounts(id int) ([]*entity.StocktakeCount, error) {
+	rows, err := r.db.Query("SELECT id_book, barcode, SUM(counted) FROM stocktake_counts WHERE id_stocktake = $1 GROUP BY id_book, barcode ORDER BY id_book, barcode", id)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var counts []*entity.StocktakeCount
+	for rows.Next() {
+		var c entity.StocktakeCount
+		err = rows.Scan(&c.BookID, &c.Barcode, &c.Counted)
+		if err != nil {
+			return nil, err
+		}
+		counts = append(counts, &c)
+	}
+	return counts, rows.Err()
+}
+
+// Close applies the corrections and closes the session in one transaction. The
+// stocktake service works the corrections out; a book changed since answers
+// entity.ErrVersionConflict and leaves everything as it was.
+func (r *PostgreSQL) Close(id int, corrections []*entity.StockCorrection) error {
+	tx, err := r.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var status string
+	err = tx.QueryRow("SELECT status FROM stocktakes WHERE id = $1 FOR UPDATE", id).Scan(&status)
+	if err == sql.ErrNoRows {
+		return entity.ErrNotFound
+	}
+	if err != nil {
+		return err
+	}
+	if status != entity.StocktakeOpen {
+		return entity.ErrStocktakeClosed
+	}
+
+	for _, c := range corrections {
+		err = repositoryBook.AdjustStock(tx, c.Version, c.Movement)
+		if err == entity.ErrVersionConflict {
+			return err
+		}
+		if err != nil {
+			return fmt.Errorf("book %d: %w", c.Movement.BookID, err)
+		}
+	}
+
+	_, err = tx.Exec("UPDATE stocktakes SET status = $1, closed_at = $2 WHERE id = $3", entity.StocktakeClosed, time.Now(), id)
+	if err != nil {
+		return err
+	}
+	return tx.Commit()
+}
@@ -0,0 +1,136 @@
+package repositoryStocktake
+
+import (
+	"database/sql"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
+	_ "github.com/lib/pq"
+	"github.com/stretchr/testify/assert"
+	"log"
+	"testing"
+	"time"
+)
+
+var db *sql.DB
+
+func setUp() {
+	var err error
+	db, err = database.NewPostgresConnection(database.ConnectionInfo{Host: "localhost", Port: 5432, UserName: "crud-6", DBName: "crud-6-db", SSLMode: "disable", Password: "12345"})
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	_, err = db.Exec("DELETE FROM stocktake_counts")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM stocktakes")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func tearDown() {
+	defer db.Close()
+
+	_, err := db.Exec("DELETE FROM stocktake_counts")
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, err = db.Exec("DELETE FROM stocktakes")
+	if err != nil {
+		log.Fatal(err)
+	}
+}
+
+func TestMain(m *testing.M) {
+	setUp()
+	m.Run()
+	tearDown()
+}
+
+func TestStocktake(t *testing.T) {
+	repo := NewStocktakes(db)
+
+	st := &entity.Stocktake{Branch: "main", Status: entity.StocktakeOpen, OpenedAt: time.Now()}
+	assert.NoError(t, repo.Create(st))
+	assert.Greater(t, st.ID, 0)
+	assert.Equal(t, entity.ErrConflict, repo.Create(&entity.Stocktake{Branch: "main", Status: entity.StocktakeOpen, OpenedAt: time.Now()}))
+
+	err := repo.AddCounts(st.ID, []*entity.StocktakeCount{{BookID: 1, Counted: 1}, {Barcode: "9780306406157", Counted: 1}})
+	assert.NoError(t, err)
+	err = repo.AddCounts(st.ID, []*entity.StocktakeCount{{BookID: 1, Counted: 2}})
+	assert.NoError(t, err)
+
+	counts, err := repo.GetCounts(st.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.StocktakeCount{{Barcode: "9780306406157", Counted: 1}, {BookID: 1, Counted: 3}}, counts)
+
+	assert.NoError(t, repo.Close(st.ID, nil))
+	assert.Equal(t, entity.ErrStocktakeClosed, repo.Close(st.ID, nil))
+	assert.Equal(t, entity.ErrNotFound, repo.Close(999, nil))
+
+	stGot, err := repo.GetByID(st.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StocktakeClosed, stGot.Status)
+	assert.NotNil(t, stGot.ClosedAt)
+
+	// closing frees the branch for the next session
+	assert.NoError(t, repo.Create(&entity.Stocktake{Branch: "main", Status: entity.StocktakeOpen, OpenedAt: time.Now()}))
+
+	_, err = repo.GetByID(999)
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestClose_Corrections(t *testing.T) {
+	repo := NewStocktakes(db)
+
+	_, err := db.Exec(`INSERT INTO books (id, tittle, author, pages, quantity, available, branch, version, created_at) VALUES
+		(9101, 'Tobacco Road', 'Erskine Caldwell', 241, 5, 3, 'west', 1, now()),
+		(9102, 'Journeyman', 'Erskine Caldwell', 235, 2, 2, 'west', 1, now()),
+		(9103, 'Trouble in July', 'Erskine Caldwell', 241, 4, 4, 'west', 3, now())`)
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM book_movements WHERE id_book IN (9101, 9102, 9103)")
+	defer db.Exec("DELETE FROM books WHERE id IN (9101, 9102, 9103)")
+
+	st := &entity.Stocktake{Branch: "west", Status: entity.StocktakeOpen, OpenedAt: time.Now()}
+	assert.NoError(t, repo.Create(st))
+	reference := fmt.Sprintf("stocktake %d", st.ID)
+
+	// 9103 changed since the report, so nothing is applied and the session stays open
+	stale := []*entity.StockCorrection{
+		{Version: 1, Movement: &entity.Movement{BookID: 9102, Delta: 1, AvailableDelta: 1, Reason: entity.MovementCorrection, Actor: "user 100", Reference: reference}},
+		{Version: 2, Movement: &entity.Movement{BookID: 9103, Delta: -1, AvailableDelta: -1, Reason: entity.MovementLoss, Actor: "user 100", Reference: reference}},
+	}
+	assert.Equal(t, entity.ErrVersionConflict, repo.Close(st.ID, stale))
+	var quantity, available, version int
+	err = db.QueryRow("SELECT quantity, available FROM books WHERE id = 9102").Scan(&quantity, &available)
+	assert.NoError(t, err)
+	assert.Equal(t, 2, quantity)
+	stGot, err := repo.GetByID(st.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StocktakeOpen, stGot.Status)
+
+	corrections := []*entity.StockCorrection{
+		{Version: 1, Movement: &entity.Movement{BookID: 9101, Delta: -1, AvailableDelta: -1, Reason: entity.MovementLoss, Actor: "user 100", Reference: reference}},
+	}
+	assert.NoError(t, repo.Close(st.ID, corrections))
+
+	err = db.QueryRow("SELECT quantity, available, version FROM books WHERE id = 9101").Scan(&quantity, &available, &version)
+	assert.NoError(t, err)
+	assert.Equal(t, 4, quantity)
+	assert.Equal(t, 2, available)
+	assert.Equal(t, 2, version)
+
+	var reason, actor, referenceGot string
+	err = db.QueryRow("SELECT reason, actor, reference FROM book_movements WHERE id_book = 9101").Scan(&reason, &actor, &referenceGot)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.MovementLoss, reason)
+	assert.Equal(t, "user 100", actor)
+	assert.Equal(t, reference, referenceGot)
+
+	stGot, err = repo.GetByID(st.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.StocktakeClosed, stGot.Status)
+}
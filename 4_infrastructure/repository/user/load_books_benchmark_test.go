@@ -0,0 +1,73 @@
+package repositoryUser
+
+import (
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"testing"
+)
+
+// booksQueryPerUser is how the loans used to be loaded: one query per user.
+func booksQueryPerUser(u *PostgreSQL, users []*entity.User) error {
+	for _, user := range users {
+		err := func() error {
+			books, err := u.db.Query("SELECT id_book FROM users_books WHERE id_user = $1", user.ID)
+			if err != nil {
+				return err
+			}
+			defer books.Close()
+
+			for books.Next() {
+				var b int
+				err = books.Scan(&b)
+				if err != nil {
+					return err
+				}
+				user.Books = append(user.Books, b)
+			}
+			return books.Err()
+		}()
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// BenchmarkLoadBooks compares how Search loads the loans of a page of users with
+// loading them user by user, for example:
+// go test -run '^$' -bench LoadBooks ./4_infrastructure/repository/user
+func BenchmarkLoadBooks(b *testing.B) {
+	userRepo := NewUsers(db)
+	for _, n := range []int{100, 1000, 10000} {
+		cleanUp := seedLoans(b, n)
+		users, _, err := userRepo.Search(entity.UserFilter{Query: "reader", Sort: "id", Page: 1, PerPage: n})
+		if err != nil {
+			b.Fatal(err)
+		}
+
+		b.Run(fmt.Sprintf("single query/%d users", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, u := range users {
+					u.Books = nil
+				}
+				err := userRepo.loadBooks(users)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+		b.Run(fmt.Sprintf("query per user/%d users", n), func(b *testing.B) {
+			for i := 0; i < b.N; i++ {
+				for _, u := range users {
+					u.Books = nil
+				}
+				err := booksQueryPerUser(userRepo, users)
+				if err != nil {
+					b.Fatal(err)
+				}
+			}
+		})
+
+		cleanUp()
+	}
+}
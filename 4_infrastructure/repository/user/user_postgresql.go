@@ -4,9 +4,16 @@ import (
 	"database/sql"
 	"fmt"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/pgerr"
+	"github.com/lib/pq"
+	"strconv"
+	"strings"
 	"time"
 )
 
+// emailIndex tells a clash on the email address from one on the primary key.
+const emailIndex = "users_email_unique"
+
 type PostgreSQL struct {
 	db *sql.DB
 }
@@ -16,15 +23,36 @@ func NewUsers(db *sql.DB) *PostgreSQL {
 }
 
 func (u *PostgreSQL) Create(user *entity.User) error {
-	_, err := u.db.Exec("INSERT INTO users (id, first_name, last_name, dob, location, cellphone_number, email, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
-		(*user).ID, user.FirstName, user.LastName, user.DOB, user.Location, user.CellPhoneNumber, user.Email, user.Password, user.CreatedAt, time.Time{})
+	if user.ID == 0 {
+		err := u.db.QueryRow("INSERT INTO users (first_name, last_name, dob, location, cellphone_number, email, password, role, email_verified_at, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12) RETURNING id",
+			user.FirstName, user.LastName, user.DOB, user.Location, user.CellPhoneNumber, user.Email, user.PasswordHash, user.Role, user.EmailVerifiedAt, user.Version, user.CreatedAt, time.Time{}).Scan(&user.ID)
+		if pgerr.IsUniqueViolation(err, emailIndex) {
+			return entity.ErrEmailTaken
+		}
+		return err
+	}
+
+	_, err := u.db.Exec("INSERT INTO users (id, first_name, last_name, dob, location, cellphone_number, email, password, role, email_verified_at, version, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10, $11, $12, $13)",
+		(*user).ID, user.FirstName, user.LastName, user.DOB, user.Location, user.CellPhoneNumber, user.Email, user.PasswordHash, user.Role, user.EmailVerifiedAt, user.Version, user.CreatedAt, time.Time{})
+	if pgerr.IsUniqueViolation(err, emailIndex) {
+		return entity.ErrEmailTaken
+	}
+	if pgerr.IsUniqueViolation(err) {
+		// a soft-deleted user still holds its id
+		return entity.ErrConflict
+	}
+	if err != nil {
+		return err
+	}
+	// keep the identity ahead of client-picked ids so generated ones don't collide
+	_, err = u.db.Exec("SELECT setval(pg_get_serial_sequence('users', 'id'), GREATEST((SELECT MAX(id) FROM users), COALESCE(pg_sequence_last_value(pg_get_serial_sequence('users', 'id')::regclass), 1)))")
 	return err
 }
 
 func (u *PostgreSQL) GetByID(id int) (*entity.User, error) {
 	var user entity.User
-	err := u.db.QueryRow("SELECT id, first_name, last_name, dob, location, cellphone_number, email, password, created_at, updated_at FROM users WHERE id = $1", id).
-		Scan(&user.ID, &user.FirstName, &user.LastName, &user.DOB, &user.Location, &user.CellPhoneNumber, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+	err := u.db.QueryRow("SELECT id, first_name, last_name, dob, location, cellphone_number, email, password, role, email_verified_at, version, created_at, updated_at FROM users WHERE id = $1 AND deleted_at IS NULL", id).
+		Scan(&user.ID, &user.FirstName, &user.LastName, &user.DOB, &user.Location, &user.CellPhoneNumber, &user.Email, &user.PasswordHash, &user.Role, &user.EmailVerifiedAt, &user.Version, &user.CreatedAt, &user.UpdatedAt)
 	if err != nil {
 		if err == sql.ErrNoRows {
 			return nil, entity.ErrNotFound
@@ -32,59 +60,193 @@ func (u *PostgreSQL) GetByID(id int) (*entity.User, error) {
 		return nil, err
 	}
 	//loan usecase code
-	rows, err := u.db.Query("SELECT id_book FROM users_books WHERE id_user = $1", id)
+	err = u.loadBooks([]*entity.User{&user})
 	if err != nil {
 		return nil, err
 	}
-	for rows.Next() {
-		var i int
-		err = rows.Scan(&i)
-		if err != nil {
-			return nil, err
-		}
-		user.Books = append(user.Books, i)
-	}
 	//end of loan usecase code
 	return &user, nil
 }
 
-func (u *PostgreSQL) GetAll() ([]*entity.User, error) {
-	rows, err := u.db.Query("SELECT id, first_name, last_name, dob, location, cellphone_number, email, password, created_at, updated_at FROM users")
+// GetByEmail matches the address case-insensitively, the way people type it when logging in.
+func (u *PostgreSQL) GetByEmail(email string) (*entity.User, error) {
+	var id int
+	err := u.db.QueryRow("SELECT id FROM users WHERE lower(email) = lower($1) AND deleted_at IS NULL ORDER BY id LIMIT 1", email).Scan(&id)
+	if err == sql.ErrNoRows {
+		return nil, entity.ErrNotFound
+	}
 	if err != nil {
 		return nil, err
 	}
-	var users []*entity.User
+	return u.GetByID(id)
+}
+
+var sortColumns = map[string]string{"id": "id", "first_name": "first_name", "last_name": "last_name", "email": "lower(email)", "created_at": "created_at"}
+
+// Search pages through the live users matching f, ordered by f.Sort and then id,
+// and counts all the matches.
+func (u *PostgreSQL) Search(f entity.UserFilter) ([]*entity.User, int, error) {
+	column, ok := sortColumns[strings.TrimPrefix(f.Sort, "-")]
+	if !ok {
+		return nil, 0, entity.ErrInvalidEntity
+	}
+	order := column
+	if strings.HasPrefix(f.Sort, "-") {
+		order += " DESC"
+	}
+
+	var args []interface{}
+	arg := func(v interface{}) string {
+		args = append(args, v)
+		return "$" + strconv.Itoa(len(args))
+	}
+
+	where := []string{"deleted_at IS NULL"}
+	if f.Query != "" {
+		p := arg("%" + escapeLike(f.Query) + "%")
+		match := "concat_ws(' ', first_name, last_name) ILIKE " + p + " OR email ILIKE " + p + " OR cellphone_number ILIKE " + p
+		// phone numbers are stored as +380931234567, but typed in every format
+		if digits := onlyDigits(f.Query); digits != "" {
+			match += " OR regexp_replace(cellphone_number, '[^0-9]', '', 'g') LIKE " + arg("%"+digits+"%")
+		}
+		where = append(where, "("+match+")")
+	}
+	if f.Location != "" {
+		where = append(where, "location ILIKE "+arg("%"+escapeLike(f.Location)+"%"))
+	}
+	if f.HasOverdue != nil {
+		overdue := "EXISTS (SELECT 1 FROM users_books WHERE id_user = users.id AND due_at < now())"
+		if !*f.HasOverdue {
+			overdue = "NOT " + overdue
+		}
+		where = append(where, overdue)
+	}
+	cond := strings.Join(where, " AND ")
+
+	var total int
+	err := u.db.QueryRow("SELECT COUNT(*) FROM users WHERE "+cond, args...).Scan(&total)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	rows, err := u.db.Query("SELECT id, first_name, last_name, dob, location, cellphone_number, email, password, role, email_verified_at, version, created_at, updated_at FROM users WHERE "+cond+
+		" ORDER BY "+order+", id LIMIT "+arg(f.PerPage)+" OFFSET "+arg((f.Page-1)*f.PerPage), args...)
+	if err != nil {
+		return nil, 0, err
+	}
+	defer rows.Close()
+
+	users := []*entity.User{}
 	for rows.Next() {
 		var user entity.User
-		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.DOB, &user.Location, &user.CellPhoneNumber, &user.Email, &user.Password, &user.CreatedAt, &user.UpdatedAt)
+		err = rows.Scan(&user.ID, &user.FirstName, &user.LastName, &user.DOB, &user.Location, &user.CellPhoneNumber, &user.Email, &user.PasswordHash, &user.Role, &user.EmailVerifiedAt, &user.Version, &user.CreatedAt, &user.UpdatedAt)
 		if err != nil {
-			return nil, err
+			return nil, 0, err
 		}
 		users = append(users, &user)
 	}
+	err = rows.Err()
+	if err != nil {
+		return nil, 0, err
+	}
 
-	//loan usecase code
+	err = u.loadBooks(users)
+	if err != nil {
+		return nil, 0, err
+	}
+	return users, total, nil
+}
+
+// loadBooks fills in the books the users have borrowed with a single query, however
+// many users there are.
+func (u *PostgreSQL) loadBooks(users []*entity.User) error {
+	if len(users) == 0 {
+		return nil
+	}
+
+	byID := make(map[int]*entity.User, len(users))
+	ids := make([]int, 0, len(users))
 	for _, user := range users {
-		rows, err = u.db.Query("SELECT id_book FROM users_books WHERE id_user = $1", user.ID)
+		byID[user.ID] = user
+		ids = append(ids, user.ID)
+	}
+
+	rows, err := u.db.Query("SELECT id_user, id_book FROM users_books WHERE id_user = ANY($1) ORDER BY id_user, borrowed_at, id_book", pq.Array(ids))
+	if err != nil {
+		return err
+	}
+	defer rows.Close()
+
+	for rows.Next() {
+		var userID, bookID int
+		err = rows.Scan(&userID, &bookID)
 		if err != nil {
-			return nil, err
-		}
-		for rows.Next() {
-			var b int
-			err = rows.Scan(&b)
-			if err != nil {
-				return nil, err
-			}
-			user.Books = append(user.Books, b)
+			return err
 		}
+		byID[userID].Books = append(byID[userID].Books, bookID)
 	}
-	//end of loan usecases code
-	return users, nil
+	return rows.Err()
+}
+
+// escapeLike makes s match literally inside a LIKE pattern.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func onlyDigits(s string) string {
+	return strings.Map(func(r rune) rune {
+		if r < '0' || r > '9' {
+			return -1
+		}
+		return r
+	}, s)
 }
 
+// Update saves the profile. The borrowed books are left alone: loans only change
+// through the book repository's CheckOut and CheckIn.
 func (u *PostgreSQL) Update(user *entity.User) error {
-	res, err := u.db.Exec("UPDATE users SET first_name = $1, last_name = $2, dob = $3, location = $4, cellphone_number = $5, email = $6, password = $7, updated_at = $8 WHERE id = $9",
-		user.FirstName, user.LastName, user.DOB, user.Location, user.CellPhoneNumber, user.Email, user.Password, user.UpdatedAt, user.ID)
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	var version int
+	err = tx.QueryRow("UPDATE users SET first_name = $1, last_name = $2, dob = $3, location = $4, cellphone_number = $5, email = $6, password = COALESCE(NULLIF($7, ''), password), email_verified_at = $8, updated_at = $9, version = version + 1 WHERE id = $10 AND version = $11 AND deleted_at IS NULL RETURNING version",
+		user.FirstName, user.LastName, user.DOB, user.Location, user.CellPhoneNumber, user.Email, user.PasswordHash, user.EmailVerifiedAt, user.UpdatedAt, user.ID, user.Version).Scan(&version)
+	if err == sql.ErrNoRows {
+		return u.missingOrChanged(user.ID)
+	}
+	if pgerr.IsUniqueViolation(err, emailIndex) {
+		return entity.ErrEmailTaken
+	}
+	if err != nil {
+		return err
+	}
+	// a new password logs the user out everywhere, like a reset does
+	if user.PasswordHash != "" {
+		_, err = tx.Exec("UPDATE sessions SET revoked_at = $1 WHERE id_user = $2 AND revoked_at IS NULL", user.UpdatedAt, user.ID)
+		if err != nil {
+			return err
+		}
+	}
+	err = tx.Commit()
+	if err != nil {
+		return err
+	}
+
+	user.Version = version
+	return nil
+}
+
+func (u *PostgreSQL) Delete(id, version int, mode string) error {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	res, err := tx.Exec("UPDATE users SET deleted_at = $1, version = version + 1 WHERE id = $2 AND version = $3 AND deleted_at IS NULL", time.Now(), id, version)
 	if err != nil {
 		return err
 	}
@@ -93,26 +255,73 @@ func (u *PostgreSQL) Update(user *entity.User) error {
 	if err != nil {
 		return err
 	}
+	if rowsAff == 0 {
+		return u.missingOrChanged(id)
+	}
 	if rowsAff != 1 {
 		return fmt.Errorf("weird behavior, total rows affected = %d", rowsAff)
 	}
+
 	//loan usecase code
-	_, err = u.db.Exec("DELETE FROM users_books WHERE id_user = $1", user.ID)
+	// lock the loans along with the user so none is returned between the check and the commit
+	rows, err := tx.Query("SELECT id_book FROM users_books WHERE id_user = $1 ORDER BY borrowed_at, id_book FOR UPDATE", id)
 	if err != nil {
 		return err
 	}
-	for _, bookId := range user.Books {
-		_, err = u.db.Exec("INSERT INTO users_books (id_user, id_book) VALUES($1, $2)", user.ID, bookId)
+	books := []int{}
+	for rows.Next() {
+		var bookID int
+		err = rows.Scan(&bookID)
+		if err != nil {
+			rows.Close()
+			return err
+		}
+		books = append(books, bookID)
+	}
+	rows.Close()
+	if rows.Err() != nil {
+		return rows.Err()
+	}
+	if len(books) > 0 && mode == entity.DeleteRestrict {
+		return &entity.OutstandingLoansError{BookIDs: books}
+	}
+	reference := fmt.Sprintf("user %d deleted", id)
+	if mode == entity.DeleteForceReturn {
+		_, err = tx.Exec("UPDATE books SET available = available + 1, version = version + 1 WHERE id IN (SELECT id_book FROM users_books WHERE id_user = $1)", id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO book_movements (id_book, delta, available_delta, reason, actor, reference) SELECT id_book, 0, 1, $1, 'system', $2 FROM users_books WHERE id_user = $3",
+			entity.MovementReturn, reference, id)
+		if err != nil {
+			return err
+		}
+	}
+	if mode == entity.DeleteWriteOff {
+		_, err = tx.Exec("UPDATE books SET quantity = quantity - 1, version = version + 1 WHERE id IN (SELECT id_book FROM users_books WHERE id_user = $1)", id)
+		if err != nil {
+			return err
+		}
+		_, err = tx.Exec("INSERT INTO book_movements (id_book, delta, available_delta, reason, actor, reference) SELECT id_book, -1, 0, $1, 'system', $2 FROM users_books WHERE id_user = $3",
+			entity.MovementLoss, reference, id)
 		if err != nil {
 			return err
 		}
 	}
+	_, err = tx.Exec("DELETE FROM users_books WHERE id_user = $1", id)
+	if err != nil {
+		return err
+	}
 	//end of loan usecase code
-	return nil
+	return tx.Commit()
 }
 
-func (u *PostgreSQL) Delete(id int) error {
-	res, err := u.db.Exec("DELETE FROM users WHERE id = $1 ", id)
+func (u *PostgreSQL) Restore(id int) error {
+	res, err := u.db.Exec("UPDATE users SET deleted_at = NULL, version = version + 1 WHERE id = $1 AND deleted_at IS NOT NULL", id)
+	if pgerr.IsUniqueViolation(err, emailIndex) {
+		// someone signed up with the address while the user was deleted
+		return entity.ErrEmailTaken
+	}
 	if err != nil {
 		return err
 	}
@@ -121,14 +330,132 @@ func (u *PostgreSQL) Delete(id int) error {
 	if err != nil {
 		return err
 	}
-	if rowsAff != 1 {
-		return fmt.Errorf("weird behavior, total rows affected = %d", rowsAff)
+	if rowsAff == 0 {
+		return entity.ErrNotFound
 	}
+
+	return nil
+}
+
+func (u *PostgreSQL) PurgeDeleted(before time.Time) (int, error) {
+	tx, err := u.db.Begin()
+	if err != nil {
+		return 0, err
+	}
+	defer tx.Rollback()
+
 	//loan usecase code
-	_, err = u.db.Exec("DELETE FROM users_books WHERE id_user = $1", id)
+	_, err = tx.Exec("DELETE FROM users_books WHERE id_user IN (SELECT id FROM users WHERE deleted_at < $1)", before)
 	if err != nil {
-		return err
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM loans WHERE id_user IN (SELECT id FROM users WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
 	}
 	//end of loan usecase code
+	_, err = tx.Exec("DELETE FROM book_reviews WHERE id_user IN (SELECT id FROM users WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM sessions WHERE id_user IN (SELECT id FROM users WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	_, err = tx.Exec("DELETE FROM user_tokens WHERE id_user IN (SELECT id FROM users WHERE deleted_at < $1)", before)
+	if err != nil {
+		return 0, err
+	}
+	res, err := tx.Exec("DELETE FROM users WHERE deleted_at < $1", before)
+	if err != nil {
+		return 0, err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return 0, err
+	}
+	return int(rowsAff), tx.Commit()
+}
+
+// SetRole is kept apart from Update, which never changes the role.
+func (u *PostgreSQL) SetRole(id int, role string) error {
+	res, err := u.db.Exec("UPDATE users SET role = $1, updated_at = $2, version = version + 1 WHERE id = $3 AND deleted_at IS NULL", role, time.Now(), id)
+	if err != nil {
+		return err
+	}
+
+	rowsAff, err := res.RowsAffected()
+	if err != nil {
+		return err
+	}
+	if rowsAff == 0 {
+		return entity.ErrNotFound
+	}
 	return nil
 }
+
+// GetPasswordHashes maps every user, deleted ones too, to the stored password.
+func (u *PostgreSQL) GetPasswordHashes() (map[int]string, error) {
+	rows, err := u.db.Query("SELECT id, COALESCE(password, '') FROM users")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	hashes := make(map[int]string)
+	for rows.Next() {
+		var id int
+		var hash string
+		err = rows.Scan(&id, &hash)
+		if err != nil {
+			return nil, err
+		}
+		hashes[id] = hash
+	}
+	return hashes, rows.Err()
+}
+
+// SetPasswordHash replaces the stored password without bumping the version: the
+// user's record doesn't change for clients, which never see the password.
+func (u *PostgreSQL) SetPasswordHash(id int, hash string) error {
+	_, err := u.db.Exec("UPDATE users SET password = $1 WHERE id = $2", hash, id)
+	return err
+}
+
+// GetDuplicateEmails groups the live users that share an address, ignoring case.
+func (u *PostgreSQL) GetDuplicateEmails() ([]*entity.EmailDuplicate, error) {
+	rows, err := u.db.Query("SELECT lower(trim(email)), array_agg(id ORDER BY id) FROM users WHERE deleted_at IS NULL GROUP BY lower(trim(email)) HAVING COUNT(*) > 1 ORDER BY 1")
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var dups []*entity.EmailDuplicate
+	for rows.Next() {
+		var d entity.EmailDuplicate
+		var ids pq.Int64Array
+		err = rows.Scan(&d.Email, &ids)
+		if err != nil {
+			return nil, err
+		}
+		for _, id := range ids {
+			d.UserIDs = append(d.UserIDs, int(id))
+		}
+		dups = append(dups, &d)
+	}
+	return dups, rows.Err()
+}
+
+// missingOrChanged tells why a compare-and-swap on a user matched no row.
+func (u *PostgreSQL) missingOrChanged(id int) error {
+	var exists bool
+	err := u.db.QueryRow("SELECT EXISTS (SELECT 1 FROM users WHERE id = $1 AND deleted_at IS NULL)", id).Scan(&exists)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return entity.ErrNotFound
+	}
+	return entity.ErrVersionConflict
+}
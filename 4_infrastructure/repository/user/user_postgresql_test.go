@@ -32,7 +32,7 @@ func setUp() {
 	if err != nil {
 		log.Fatal(err)
 	}
-	var initialUser = &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345qwerty", Books: []int{1, 2, 3}}
+	var initialUser = &entity.User{ID: 1, FirstName: "Taras", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", PasswordHash: "12345qwerty", Books: []int{1, 2, 3}}
 
 	userRepo := NewUsers(db)
 	_, err = userRepo.db.Exec("DELETE FROM users")
@@ -40,7 +40,7 @@ func setUp() {
 		log.Fatal(err)
 	}
 	_, err = userRepo.db.Exec("INSERT INTO users (id, first_name, last_name, dob, location, cellphone_number, email, password, created_at, updated_at) VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)",
-		initialUser.ID, initialUser.FirstName, initialUser.LastName, initialUser.DOB, initialUser.Location, initialUser.CellPhoneNumber, initialUser.Email, initialUser.Password, time.Time{}, time.Time{})
+		initialUser.ID, initialUser.FirstName, initialUser.LastName, initialUser.DOB, initialUser.Location, initialUser.CellPhoneNumber, initialUser.Email, initialUser.PasswordHash, time.Time{}, time.Time{})
 	if err != nil {
 		log.Fatal(err)
 	}
@@ -64,7 +64,7 @@ func TestMain(m *testing.M) {
 
 func TestCreateUser(t *testing.T) {
 	userRepo := NewUsers(db)
-	userArg1 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554422", Email: "sergeypoc@gmail.com", Password: "12345qwerty"}
+	userArg1 := &entity.User{ID: 2, FirstName: "Sergey", LastName: "Onishenko", DOB: time.Date(1990, 12, 28, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554422", Email: "sergeypoc@gmail.com", PasswordHash: "12345qwerty", Version: 1}
 	tests := []userTest{
 		{args: userArgs{user: userArg1}, want: userWant{user: userArg1, err: nil}},
 	}
@@ -85,39 +85,41 @@ func TestCreateUser(t *testing.T) {
 	}
 }
 
-func TestGetByIDUser(t *testing.T) {
+func TestCreateUser_GeneratedID(t *testing.T) {
 	userRepo := NewUsers(db)
-	userArg1 := &entity.User{ID: 1}
-	tests := []userTest{
-		{args: userArgs{user: userArg1}, want: userWant{user: userArg1, err: nil}},
-	}
+	user := &entity.User{FirstName: "Olena", LastName: "Koval", DOB: time.Date(1995, 5, 4, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554433", Email: "olenakoval@gmail.com", PasswordHash: "12345qwerty"}
 
-	for _, ut := range tests {
-		userGot, errGot := userRepo.GetByID(ut.args.user.ID)
+	err := userRepo.Create(user)
+	assert.NoError(t, err)
+	assert.Greater(t, user.ID, 2)
 
-		assert.Equal(t, ut.want.user.ID, userGot.ID)
-		assert.Equal(t, ut.want.err, errGot)
-	}
+	userGot, err := userRepo.GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, user.Email, userGot.Email)
+
+	assert.NoError(t, userRepo.Delete(user.ID, user.Version, entity.DeleteRestrict))
 }
 
-func TestGetAllUsers(t *testing.T) {
+func TestGetByIDUser(t *testing.T) {
 	userRepo := NewUsers(db)
-
+	userArg1 := &entity.User{ID: 1}
 	tests := []userTest{
-		{want: userWant{err: nil}},
+		{args: userArgs{user: userArg1}, want: userWant{user: userArg1, err: nil}},
 	}
 
 	for _, ut := range tests {
-		usersGot, errGot := userRepo.GetAll()
+		userGot, errGot := userRepo.GetByID(ut.args.user.ID)
 
-		assert.NotNil(t, usersGot)
+		assert.Equal(t, ut.want.user.ID, userGot.ID)
 		assert.Equal(t, ut.want.err, errGot)
 	}
 }
 
 func TestUpdateUser(t *testing.T) {
 	userRepo := NewUsers(db)
-	userArg1 := &entity.User{ID: 1, FirstName: "UPD_Taras", LastName: "UPD_Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Password: "12345qwerty", Books: []int{4, 5, 6}}
+	_, err := db.Exec("INSERT INTO users_books (id_user, id_book) VALUES (1, 4), (1, 5), (1, 6)")
+	assert.NoError(t, err)
+	userArg1 := &entity.User{ID: 1, FirstName: "UPD_Taras", LastName: "UPD_Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", PasswordHash: "12345qwerty", Role: entity.RoleMember, Version: 1, Books: []int{4, 5, 6}}
 	tests := []userTest{
 		{args: userArgs{user: userArg1}, want: userWant{user: userArg1, err: nil}},
 	}
@@ -136,17 +138,41 @@ func TestUpdateUser(t *testing.T) {
 		assert.Equal(t, ut.want.user, userGot)
 		assert.Equal(t, ut.want.err, errGot)
 	}
+
+	// the loans only change through checking books out and in
+	noLoans := &entity.User{ID: 1, FirstName: "UPD_Taras", LastName: "UPD_Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", Version: 2, Books: []int{7}}
+	assert.NoError(t, userRepo.Update(noLoans))
+	userGot, err := userRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 6}, userGot.Books)
+
+	stale := &entity.User{ID: 1, FirstName: "Stale", LastName: "Tarkovskyi", DOB: time.Date(1992, 01, 23, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0933115485", Email: "taras6317492@gmail.com", PasswordHash: "12345qwerty", Version: 1}
+	assert.Equal(t, entity.ErrVersionConflict, userRepo.Update(stale))
+	userGot, err = userRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.Equal(t, []int{4, 5, 6}, userGot.Books)
 }
 
 func TestDeleteUser(t *testing.T) {
 	userRepo := NewUsers(db)
-	userArg1 := &entity.User{ID: 1}
+	assert.Equal(t, entity.ErrVersionConflict, userRepo.Delete(1, 1, entity.DeleteRestrict))
+	assert.Equal(t, entity.ErrNotFound, userRepo.Delete(999, 1, entity.DeleteRestrict))
+
+	err := userRepo.Delete(1, 2, entity.DeleteRestrict)
+	var loansErr *entity.OutstandingLoansError
+	assert.ErrorAs(t, err, &loansErr)
+	assert.Equal(t, []int{4, 5, 6}, loansErr.BookIDs)
+	// the refused delete rolled back, so the version is still 2
+	_, err = db.Exec("DELETE FROM users_books WHERE id_user = 1")
+	assert.NoError(t, err)
+
+	userArg1 := &entity.User{ID: 1, Version: 2}
 	tests := []userTest{
 		{args: userArgs{user: userArg1}, want: userWant{err: nil}},
 	}
 
 	for _, ut := range tests {
-		errGot := userRepo.Delete(ut.args.user.ID)
+		errGot := userRepo.Delete(ut.args.user.ID, ut.args.user.Version, entity.DeleteRestrict)
 		userGot, err := userRepo.GetByID(ut.args.user.ID)
 
 		assert.NotNil(t, err)
@@ -154,3 +180,247 @@ func TestDeleteUser(t *testing.T) {
 		assert.Equal(t, ut.want.err, errGot)
 	}
 }
+
+func TestRestoreUser(t *testing.T) {
+	userRepo := NewUsers(db)
+
+	err := userRepo.Restore(1)
+	assert.NoError(t, err)
+	userGot, err := userRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.Empty(t, userGot.Books)
+
+	assert.Equal(t, entity.ErrNotFound, userRepo.Restore(1))
+	assert.Equal(t, entity.ErrNotFound, userRepo.Restore(999))
+}
+
+func TestPurgeDeletedUsers(t *testing.T) {
+	userRepo := NewUsers(db)
+
+	userGot, err := userRepo.GetByID(1)
+	assert.NoError(t, err)
+	assert.NoError(t, userRepo.Delete(1, userGot.Version, entity.DeleteRestrict))
+
+	_, err = db.Exec("UPDATE users SET deleted_at = $1 WHERE id = 1", time.Now().Add(-2*time.Hour))
+	assert.NoError(t, err)
+	n, err := userRepo.PurgeDeleted(time.Now().Add(-time.Hour))
+	assert.NoError(t, err)
+	assert.Equal(t, 1, n)
+
+	var links int
+	err = db.QueryRow("SELECT COUNT(*) FROM users_books WHERE id_user = 1").Scan(&links)
+	assert.NoError(t, err)
+	assert.Equal(t, 0, links)
+}
+
+func TestPasswordHashes(t *testing.T) {
+	userRepo := NewUsers(db)
+	user := &entity.User{FirstName: "Ivan", LastName: "Franko", DOB: time.Date(1991, 8, 27, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554455", Email: "ivanfranko@gmail.com", PasswordHash: "plaintext1", Version: 1}
+	assert.NoError(t, userRepo.Create(user))
+
+	hashes, err := userRepo.GetPasswordHashes()
+	assert.NoError(t, err)
+	assert.Equal(t, "plaintext1", hashes[user.ID])
+
+	assert.NoError(t, userRepo.SetPasswordHash(user.ID, "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy"))
+	userGot, err := userRepo.GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", userGot.PasswordHash)
+	assert.Equal(t, 1, userGot.Version)
+
+	// an update without a new hash keeps the stored one
+	userGot.PasswordHash = ""
+	assert.NoError(t, userRepo.Update(userGot))
+	userGot, err = userRepo.GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, "$2a$10$N9qo8uLOickgx2ZMRZoMyeIjZAgcfl7p92ldGxad68LJZdL17lhWy", userGot.PasswordHash)
+}
+
+func TestGetByEmail(t *testing.T) {
+	userRepo := NewUsers(db)
+	user := &entity.User{FirstName: "Lesya", LastName: "Ukrainka", DOB: time.Date(1991, 2, 25, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554466", Email: "Lesya.Ukrainka@gmail.com", PasswordHash: "hash", Version: 1}
+	assert.NoError(t, userRepo.Create(user))
+
+	userGot, err := userRepo.GetByEmail("lesya.ukrainka@GMAIL.com")
+	assert.NoError(t, err)
+	assert.Equal(t, user.ID, userGot.ID)
+
+	_, err = userRepo.GetByEmail("nobody@gmail.com")
+	assert.Equal(t, entity.ErrNotFound, err)
+}
+
+func TestSetRole(t *testing.T) {
+	userRepo := NewUsers(db)
+	user := &entity.User{FirstName: "Hryhorii", LastName: "Skovoroda", DOB: time.Date(1991, 12, 3, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "0935554477", Email: "skovoroda@gmail.com", PasswordHash: "hash", Role: entity.RoleMember, Version: 1}
+	assert.NoError(t, userRepo.Create(user))
+
+	assert.NoError(t, userRepo.SetRole(user.ID, entity.RoleLibrarian))
+	userGot, err := userRepo.GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleLibrarian, userGot.Role)
+	assert.Equal(t, 2, userGot.Version)
+
+	// an ordinary update leaves the role alone
+	assert.NoError(t, userRepo.Update(userGot))
+	userGot, err = userRepo.GetByID(user.ID)
+	assert.NoError(t, err)
+	assert.Equal(t, entity.RoleLibrarian, userGot.Role)
+
+	assert.Equal(t, entity.ErrNotFound, userRepo.SetRole(999, entity.RoleAdmin))
+}
+
+func TestEmailTaken(t *testing.T) {
+	userRepo := NewUsers(db)
+	user := &entity.User{FirstName: "Marko", LastName: "Vovchok", DOB: time.Date(1991, 12, 22, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "+380935554488", Email: "vovchok@gmail.com", PasswordHash: "hash", Version: 1}
+	assert.NoError(t, userRepo.Create(user))
+
+	twin := &entity.User{FirstName: "Maria", LastName: "Vilinska", DOB: time.Date(1991, 12, 22, 0, 0, 0, 0, time.UTC), Location: "Ukraine", CellPhoneNumber: "+380935554499", Email: "Vovchok@Gmail.com", PasswordHash: "hash", Version: 1}
+	assert.Equal(t, entity.ErrEmailTaken, userRepo.Create(twin))
+
+	twin.Email = "vilinska@gmail.com"
+	assert.NoError(t, userRepo.Create(twin))
+	twin.Email = "vovchok@gmail.com"
+	assert.Equal(t, entity.ErrEmailTaken, userRepo.Update(twin))
+
+	// a deleted user gives up the address until restored
+	assert.NoError(t, userRepo.Delete(user.ID, user.Version, entity.DeleteRestrict))
+	assert.NoError(t, userRepo.Update(twin))
+	assert.Equal(t, entity.ErrEmailTaken, userRepo.Restore(user.ID))
+
+	dups, err := userRepo.GetDuplicateEmails()
+	assert.NoError(t, err)
+	assert.Empty(t, dups)
+}
+
+func TestGetDuplicateEmails(t *testing.T) {
+	userRepo := NewUsers(db)
+
+	// rows from before the unique index
+	_, err := db.Exec("DROP INDEX users_email_unique")
+	assert.NoError(t, err)
+	defer db.Exec("CREATE UNIQUE INDEX users_email_unique ON users (lower(email)) WHERE deleted_at IS NULL")
+	_, err = db.Exec("INSERT INTO users (id, first_name, email) VALUES (901, 'Twin', 'twins@gmail.com'), (902, 'Twin', 'Twins@gmail.com '), (903, 'Single', 'single@gmail.com')")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id IN (901, 902, 903)")
+
+	dups, err := userRepo.GetDuplicateEmails()
+	assert.NoError(t, err)
+	assert.Equal(t, []*entity.EmailDuplicate{{Email: "twins@gmail.com", UserIDs: []int{901, 902}}}, dups)
+}
+
+func TestSearch(t *testing.T) {
+	userRepo := NewUsers(db)
+
+	_, err := db.Exec(`INSERT INTO users (id, first_name, last_name, location, cellphone_number, email, version, created_at) VALUES
+		(911, 'Anna', 'Melnyk', 'Lviv, Ukraine', '+380931112233', 'anna@gmail.com', 1, '2024-01-01'),
+		(912, 'Bohdan', 'Melnyk', 'Kyiv, Ukraine', '+380934445566', 'bohdan@gmail.com', 1, '2024-01-02'),
+		(913, 'Petro', 'Shevchenko', 'Kyiv, Ukraine', '+380937778899', 'petro_100%@gmail.com', 1, '2024-01-03')`)
+	assert.NoError(t, err)
+	_, err = db.Exec("INSERT INTO users_books (id_user, id_book, borrowed_at, due_at) VALUES (912, 1, now() - interval '30 days', now() - interval '16 days'), (913, 2, now(), now() + interval '14 days')")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users_books WHERE id_user IN (911, 912, 913)")
+	defer db.Exec("DELETE FROM users WHERE id IN (911, 912, 913)")
+
+	overdue, notOverdue := true, false
+	tests := []struct {
+		filter entity.UserFilter
+		ids    []int
+		total  int
+	}{
+		{filter: entity.UserFilter{Query: "melnyk"}, ids: []int{911, 912}, total: 2},
+		{filter: entity.UserFilter{Query: "anna m"}, ids: []int{911}, total: 1},
+		{filter: entity.UserFilter{Query: "093 444"}, ids: []int{912}, total: 1},
+		{filter: entity.UserFilter{Query: "0934445566"}, ids: []int{912}, total: 1},
+		{filter: entity.UserFilter{Query: "100%"}, ids: []int{913}, total: 1},
+		{filter: entity.UserFilter{Query: "_"}, ids: []int{913}, total: 1},
+		{filter: entity.UserFilter{Location: "kyiv"}, ids: []int{912, 913}, total: 2},
+		{filter: entity.UserFilter{Location: "kyiv", HasOverdue: &overdue}, ids: []int{912}, total: 1},
+		{filter: entity.UserFilter{Location: "kyiv", HasOverdue: &notOverdue}, ids: []int{913}, total: 1},
+		{filter: entity.UserFilter{Location: ", ukraine", Sort: "-created_at", PerPage: 2}, ids: []int{913, 912}, total: 3},
+		{filter: entity.UserFilter{Location: ", ukraine", Sort: "-created_at", Page: 2, PerPage: 2}, ids: []int{911}, total: 3},
+		{filter: entity.UserFilter{Location: ", ukraine", Sort: "last_name", Page: 3, PerPage: 2}, ids: []int{}, total: 3},
+	}
+	for _, tt := range tests {
+		f := tt.filter
+		if f.Sort == "" {
+			f.Sort = "id"
+		}
+		if f.Page == 0 {
+			f.Page = 1
+		}
+		if f.PerPage == 0 {
+			f.PerPage = 20
+		}
+
+		users, total, err := userRepo.Search(f)
+		assert.NoError(t, err)
+		ids := []int{}
+		for _, u := range users {
+			ids = append(ids, u.ID)
+		}
+		assert.Equal(t, tt.ids, ids, "%+v", tt.filter)
+		assert.Equal(t, tt.total, total, "%+v", tt.filter)
+	}
+
+	users, _, err := userRepo.Search(entity.UserFilter{Query: "bohdan", Sort: "id", Page: 1, PerPage: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{1}, users[0].Books)
+
+	_, _, err = userRepo.Search(entity.UserFilter{Sort: "password", Page: 1, PerPage: 20})
+	assert.Equal(t, entity.ErrInvalidEntity, err)
+}
+
+// seedLoans adds n users from id 10001 on, each with two borrowed books.
+func seedLoans(tb testing.TB, n int) func() {
+	_, err := db.Exec("INSERT INTO users (id, first_name, email, version, created_at) SELECT i, 'Reader', 'reader' || i || '@gmail.com', 1, now() FROM generate_series(10001, 10000 + $1) AS i", n)
+	if err != nil {
+		tb.Fatal(err)
+	}
+	_, err = db.Exec("INSERT INTO users_books (id_user, id_book) SELECT i, b FROM generate_series(10001, 10000 + $1) AS i, generate_series(1, 2) AS b", n)
+	if err != nil {
+		tb.Fatal(err)
+	}
+
+	return func() {
+		db.Exec("DELETE FROM users_books WHERE id_user > 10000")
+		db.Exec("DELETE FROM users WHERE id > 10000")
+	}
+}
+
+func TestSearch_Books(t *testing.T) {
+	userRepo := NewUsers(db)
+	defer seedLoans(t, 3)()
+
+	users, total, err := userRepo.Search(entity.UserFilter{Query: "reader", Sort: "id", Page: 1, PerPage: 20})
+	assert.NoError(t, err)
+	assert.Equal(t, 3, total)
+	for _, u := range users {
+		assert.Equal(t, []int{1, 2}, u.Books, "user %d", u.ID)
+	}
+}
+
+func TestUpdateUser_Password(t *testing.T) {
+	userRepo := NewUsers(db)
+
+	_, err := db.Exec("INSERT INTO users (id, first_name, email, password, version, created_at) VALUES (922, 'Ivan', 'ivan@gmail.com', 'old', 1, now())")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM users WHERE id = 922")
+	_, err = db.Exec("INSERT INTO sessions (id, id_user, nonce, created_at, expires_at) VALUES ('cccccccccccccccccccccccccccccc01', 922, 'n', now(), now() + interval '1 hour')")
+	assert.NoError(t, err)
+	defer db.Exec("DELETE FROM sessions WHERE id_user = 922")
+
+	user, err := userRepo.GetByID(922)
+	assert.NoError(t, err)
+	user.UpdatedAt = time.Now()
+
+	// an update that keeps the password keeps the sessions
+	assert.NoError(t, userRepo.Update(user))
+	var revoked int
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id_user = 922 AND revoked_at IS NOT NULL").Scan(&revoked))
+	assert.Equal(t, 0, revoked)
+
+	user.PasswordHash = "new"
+	assert.NoError(t, userRepo.Update(user))
+	assert.NoError(t, db.QueryRow("SELECT COUNT(*) FROM sessions WHERE id_user = 922 AND revoked_at IS NOT NULL").Scan(&revoked))
+	assert.Equal(t, 1, revoked)
+}
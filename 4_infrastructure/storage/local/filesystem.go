@@ -0,0 +1,92 @@
+package storageLocal
+
+import (
+	"errors"
+	"fmt"
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+type FileSystem struct {
+	root string
+}
+
+func NewFileSystem(root string) *FileSystem {
+	return &FileSystem{root: root}
+}
+
+func (f *FileSystem) Put(key string, data []byte) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll(filepath.Dir(path), 0o755)
+	if err != nil {
+		return err
+	}
+
+	// write next to the target and rename so readers never see a half-written blob
+	tmp, err := os.CreateTemp(filepath.Dir(path), ".upload-*")
+	if err != nil {
+		return err
+	}
+	defer os.Remove(tmp.Name())
+
+	_, err = tmp.Write(data)
+	if err != nil {
+		tmp.Close()
+		return err
+	}
+	err = tmp.Close()
+	if err != nil {
+		return err
+	}
+
+	return os.Rename(tmp.Name(), path)
+}
+
+func (f *FileSystem) Get(key string) ([]byte, time.Time, error) {
+	path, err := f.path(key)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+
+	info, err := os.Stat(path)
+	if err != nil {
+		if errors.Is(err, fs.ErrNotExist) {
+			return nil, time.Time{}, entity.ErrNotFound
+		}
+		return nil, time.Time{}, err
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	return data, info.ModTime(), nil
+}
+
+func (f *FileSystem) Delete(key string) error {
+	path, err := f.path(key)
+	if err != nil {
+		return err
+	}
+
+	err = os.Remove(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return entity.ErrNotFound
+	}
+	return err
+}
+
+func (f *FileSystem) path(key string) (string, error) {
+	if key == "" || strings.HasPrefix(key, "/") || strings.Contains(key, "..") || strings.Contains(key, `\`) {
+		return "", fmt.Errorf("invalid blob key %q", key)
+	}
+	return filepath.Join(f.root, filepath.FromSlash(key)), nil
+}
@@ -0,0 +1,36 @@
+package storageLocal
+
+import (
+	entity "github.com/TarasTarkovskyi/crud-3-clean-architecture/1_entity"
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestPutGetDelete(t *testing.T) {
+	fs := NewFileSystem(t.TempDir())
+
+	err := fs.Put("covers/1/original", []byte("first"))
+	assert.NoError(t, err)
+	err = fs.Put("covers/1/original", []byte("second"))
+	assert.NoError(t, err)
+
+	data, modTime, err := fs.Get("covers/1/original")
+	assert.NoError(t, err)
+	assert.Equal(t, []byte("second"), data)
+	assert.False(t, modTime.IsZero())
+
+	err = fs.Delete("covers/1/original")
+	assert.NoError(t, err)
+
+	_, _, err = fs.Get("covers/1/original")
+	assert.Equal(t, entity.ErrNotFound, err)
+	assert.Equal(t, entity.ErrNotFound, fs.Delete("covers/1/original"))
+}
+
+func TestInvalidKey(t *testing.T) {
+	fs := NewFileSystem(t.TempDir())
+
+	for _, key := range []string{"", "/etc/passwd", "../outside", `covers\1`} {
+		assert.Error(t, fs.Put(key, []byte("x")), key)
+	}
+}
@@ -0,0 +1,72 @@
+package email
+
+import (
+	"errors"
+	"net/mail"
+	"strings"
+)
+
+var (
+	ErrEmpty   = errors.New("is required")
+	ErrSyntax  = errors.New("is not a valid address")
+	ErrTooLong = errors.New("is too long")
+	ErrDomain  = errors.New("has no valid domain")
+)
+
+// Normalize checks that s is a bare RFC 5322 addr-spec, without a display name
+// or comments, and returns it lower-cased. Mail servers may treat the local part
+// as case-sensitive, but none we send to do, and it keeps addresses comparable.
+func Normalize(s string) (string, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return "", ErrEmpty
+	}
+	if len(s) > 254 {
+		return "", ErrTooLong
+	}
+	if strings.ContainsAny(s, "<>()") {
+		return "", ErrSyntax
+	}
+
+	addr, err := mail.ParseAddress(s)
+	if err != nil || addr.Name != "" {
+		return "", ErrSyntax
+	}
+
+	// s, not addr.Address, which has the quotes of a quoted local part taken off
+	at := strings.LastIndexByte(s, '@')
+	local, domain := s[:at], s[at+1:]
+	if len(local) > 64 {
+		return "", ErrTooLong
+	}
+	if !validDomain(domain) {
+		return "", ErrDomain
+	}
+	return strings.ToLower(s), nil
+}
+
+// validDomain wants a DNS host name with at least two labels; address literals
+// such as [127.0.0.1] are valid syntax but no use for reaching a person.
+func validDomain(d string) bool {
+	labels := strings.Split(d, ".")
+	if len(labels) < 2 {
+		return false
+	}
+	for _, l := range labels {
+		if l == "" || len(l) > 63 || l[0] == '-' || l[len(l)-1] == '-' {
+			return false
+		}
+		for _, r := range l {
+			if !(r >= 'a' && r <= 'z' || r >= 'A' && r <= 'Z' || r >= '0' && r <= '9' || r == '-') {
+				return false
+			}
+		}
+	}
+	tld := labels[len(labels)-1]
+	for _, r := range tld {
+		if r >= '0' && r <= '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,38 @@
+package email
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		in   string
+		want string
+		err  error
+	}{
+		{in: "Peter@Gmail.com", want: "peter@gmail.com"},
+		{in: "  taras6317492@gmail.com ", want: "taras6317492@gmail.com"},
+		{in: "first.last+tag@mail.example.co.uk", want: "first.last+tag@mail.example.co.uk"},
+		{in: `"john doe"@example.com`, want: `"john doe"@example.com`},
+		{in: "o'brien@example.ie", want: "o'brien@example.ie"},
+		{in: "", err: ErrEmpty},
+		{in: "peter", err: ErrSyntax},
+		{in: "peter@", err: ErrSyntax},
+		{in: "@gmail.com", err: ErrSyntax},
+		{in: "peter..parker@gmail.com", err: ErrSyntax},
+		{in: "Peter <peter@gmail.com>", err: ErrSyntax},
+		{in: "peter@gmail.com, paul@gmail.com", err: ErrSyntax},
+		{in: "peter@localhost", err: ErrDomain},
+		{in: "peter@[127.0.0.1]", err: ErrDomain},
+		{in: "peter@-gmail.com", err: ErrDomain},
+		{in: "peter@gmail.123", err: ErrDomain},
+		{in: "a234567890123456789012345678901234567890123456789012345678901234@example.com", want: "a234567890123456789012345678901234567890123456789012345678901234@example.com"},
+		{in: "a2345678901234567890123456789012345678901234567890123456789012345@example.com", err: ErrTooLong},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.in)
+		assert.Equal(t, tt.err, err, tt.in)
+		assert.Equal(t, tt.want, got, tt.in)
+	}
+}
@@ -0,0 +1,51 @@
+package imaging
+
+import (
+	"image"
+	"image/draw"
+)
+
+// Thumbnail scales src down to the given width keeping its aspect ratio.
+// Images that are already narrow enough are returned unchanged.
+func Thumbnail(src image.Image, width int) image.Image {
+	b := src.Bounds()
+	if width <= 0 || b.Dx() <= width {
+		return src
+	}
+	height := b.Dy() * width / b.Dx()
+	if height < 1 {
+		height = 1
+	}
+
+	rgba := image.NewRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(rgba, rgba.Bounds(), src, b.Min, draw.Src)
+
+	// every destination pixel is the average of the box of source pixels it covers
+	dst := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := y*b.Dy()/height, (y+1)*b.Dy()/height
+		for x := 0; x < width; x++ {
+			x0, x1 := x*b.Dx()/width, (x+1)*b.Dx()/width
+
+			var sum [4]uint32
+			var n uint32
+			for sy := y0; sy < y1; sy++ {
+				off := rgba.PixOffset(x0, sy)
+				for sx := x0; sx < x1; sx++ {
+					sum[0] += uint32(rgba.Pix[off])
+					sum[1] += uint32(rgba.Pix[off+1])
+					sum[2] += uint32(rgba.Pix[off+2])
+					sum[3] += uint32(rgba.Pix[off+3])
+					off += 4
+					n++
+				}
+			}
+
+			off := dst.PixOffset(x, y)
+			for i := range sum {
+				dst.Pix[off+i] = uint8(sum[i] / n)
+			}
+		}
+	}
+	return dst
+}
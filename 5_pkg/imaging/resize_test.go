@@ -0,0 +1,25 @@
+package imaging
+
+import (
+	"github.com/stretchr/testify/assert"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestThumbnail(t *testing.T) {
+	src := image.NewRGBA(image.Rect(0, 0, 4, 2))
+	for y := 0; y < 2; y++ {
+		src.Set(0, y, color.RGBA{R: 255, A: 255})
+		src.Set(1, y, color.RGBA{R: 255, A: 255})
+		src.Set(2, y, color.RGBA{B: 200, A: 255})
+		src.Set(3, y, color.RGBA{B: 100, A: 255})
+	}
+
+	dst := Thumbnail(src, 2)
+	assert.Equal(t, image.Rect(0, 0, 2, 1), dst.Bounds())
+	assert.Equal(t, color.RGBA{R: 255, A: 255}, dst.At(0, 0))
+	assert.Equal(t, color.RGBA{B: 150, A: 255}, dst.At(1, 0))
+
+	assert.Same(t, src, Thumbnail(src, 10))
+}
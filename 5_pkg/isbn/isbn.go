@@ -0,0 +1,72 @@
+package isbn
+
+import "strings"
+
+// Normalize strips the usual hyphens and spaces and upper-cases an ISBN-10 "x" check digit.
+func Normalize(s string) string {
+	var b strings.Builder
+	for _, r := range strings.TrimSpace(s) {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case r == 'x' || r == 'X':
+			b.WriteByte('X')
+		case r == '-' || r == ' ':
+		default:
+			return strings.TrimSpace(s)
+		}
+	}
+	return b.String()
+}
+
+// Valid reports whether a normalized ISBN-10 or ISBN-13 has a correct check digit.
+func Valid(s string) bool {
+	switch len(s) {
+	case 10:
+		sum := 0
+		for i, r := range s {
+			var d int
+			switch {
+			case r >= '0' && r <= '9':
+				d = int(r - '0')
+			case r == 'X' && i == 9:
+				d = 10
+			default:
+				return false
+			}
+			sum += (10 - i) * d
+		}
+		return sum%11 == 0
+	case 13:
+		sum := 0
+		for i, r := range s {
+			if r < '0' || r > '9' {
+				return false
+			}
+			if i%2 == 0 {
+				sum += int(r - '0')
+			} else {
+				sum += 3 * int(r-'0')
+			}
+		}
+		return sum%10 == 0
+	}
+	return false
+}
+
+// To13 converts a valid ISBN-10 to its ISBN-13 form; other input is returned as is.
+func To13(s string) string {
+	if len(s) != 10 || !Valid(s) {
+		return s
+	}
+	body := "978" + s[:9]
+	sum := 0
+	for i, r := range body {
+		if i%2 == 0 {
+			sum += int(r - '0')
+		} else {
+			sum += 3 * int(r-'0')
+		}
+	}
+	return body + string(rune('0'+(10-sum%10)%10))
+}
@@ -0,0 +1,28 @@
+package isbn
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "9780140449136", Normalize(" 978-0-14-044913-6 "))
+	assert.Equal(t, "080442957X", Normalize("0-8044-2957-x"))
+	assert.Equal(t, "not an isbn", Normalize("not an isbn"))
+}
+
+func TestValid(t *testing.T) {
+	assert.True(t, Valid("9780140449136"))
+	assert.True(t, Valid("080442957X"))
+	assert.True(t, Valid("0306406152"))
+	assert.False(t, Valid("9780140449137"))
+	assert.False(t, Valid("0306406153"))
+	assert.False(t, Valid("X306406152"))
+	assert.False(t, Valid("12345"))
+}
+
+func TestTo13(t *testing.T) {
+	assert.Equal(t, "9780306406157", To13("0306406152"))
+	assert.Equal(t, "9780804429573", To13("080442957X"))
+	assert.Equal(t, "9780140449136", To13("9780140449136"))
+}
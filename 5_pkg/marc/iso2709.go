@@ -0,0 +1,165 @@
+package marc
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+)
+
+const (
+	subfieldDelimiter = 0x1F
+	fieldTerminator   = 0x1E
+	recordTerminator  = 0x1D
+	leaderLength      = 24
+	directoryEntry    = 12
+)
+
+const (
+	maxFieldLength  = 9999
+	maxRecordLength = 99999
+)
+
+var (
+	ErrMalformed = errors.New("malformed MARC record")
+	ErrTooLong   = errors.New("too long for a MARC record")
+)
+
+// Reader reads binary MARC21 (ISO 2709) records one at a time.
+type Reader struct {
+	r *bufio.Reader
+}
+
+func NewReader(r io.Reader) *Reader {
+	return &Reader{r: bufio.NewReader(r)}
+}
+
+// Read returns the next record or io.EOF when the input is exhausted.
+func (mr *Reader) Read() (*Record, error) {
+	raw, err := mr.r.ReadBytes(recordTerminator)
+	if err == io.EOF {
+		if len(bytes.TrimSpace(raw)) == 0 {
+			return nil, io.EOF
+		}
+		return nil, fmt.Errorf("%w: missing record terminator", ErrMalformed)
+	}
+	if err != nil {
+		return nil, err
+	}
+	// tolerate line breaks some tools put between records
+	raw = bytes.TrimLeft(raw, "\r\n")
+	return Unmarshal(raw)
+}
+
+func Unmarshal(raw []byte) (*Record, error) {
+	if len(raw) < leaderLength {
+		return nil, fmt.Errorf("%w: record shorter than its leader", ErrMalformed)
+	}
+	base, ok := number(raw[12:17])
+	if !ok || base <= leaderLength || base > len(raw) {
+		return nil, fmt.Errorf("%w: bad base address", ErrMalformed)
+	}
+
+	rec := &Record{Leader: string(raw[:leaderLength])}
+	directory := raw[leaderLength : base-1]
+	if len(directory)%directoryEntry != 0 {
+		return nil, fmt.Errorf("%w: bad directory length", ErrMalformed)
+	}
+
+	for i := 0; i < len(directory); i += directoryEntry {
+		entry := directory[i : i+directoryEntry]
+		tag := string(entry[:3])
+		length, ok1 := number(entry[3:7])
+		start, ok2 := number(entry[7:12])
+		if !ok1 || !ok2 || length < 1 || base+start+length > len(raw) {
+			return nil, fmt.Errorf("%w: bad directory entry for %s", ErrMalformed, tag)
+		}
+		// drop the field terminator
+		data := raw[base+start : base+start+length-1]
+
+		if isControlTag(tag) {
+			rec.AddControl(tag, string(data))
+			continue
+		}
+		if len(data) < 2 {
+			return nil, fmt.Errorf("%w: field %s has no indicators", ErrMalformed, tag)
+		}
+		f := DataField{Tag: tag, Ind1: data[0], Ind2: data[1]}
+		for _, sf := range bytes.Split(data[2:], []byte{subfieldDelimiter}) {
+			if len(sf) == 0 {
+				continue
+			}
+			f.Subfields = append(f.Subfields, Subfield{Code: sf[0], Value: string(sf[1:])})
+		}
+		rec.DataFields = append(rec.DataFields, f)
+	}
+	return rec, nil
+}
+
+// Marshal encodes the record as ISO 2709, recomputing the leader's length and base
+// address. A field over 9999 bytes or a record over 99999 can't be encoded: ErrTooLong.
+func Marshal(rec *Record) ([]byte, error) {
+	var directory, data bytes.Buffer
+	addField := func(tag string, field []byte) error {
+		field = append(field, fieldTerminator)
+		if len(field) > maxFieldLength {
+			return fmt.Errorf("field %s is %w", tag, ErrTooLong)
+		}
+		fmt.Fprintf(&directory, "%s%04d%05d", tag, len(field), data.Len())
+		data.Write(field)
+		return nil
+	}
+	for _, f := range rec.ControlFields {
+		err := addField(f.Tag, []byte(f.Value))
+		if err != nil {
+			return nil, err
+		}
+	}
+	for _, f := range rec.DataFields {
+		field := []byte{blank(f.Ind1), blank(f.Ind2)}
+		for _, sf := range f.Subfields {
+			field = append(field, subfieldDelimiter, sf.Code)
+			field = append(field, sf.Value...)
+		}
+		err := addField(f.Tag, field)
+		if err != nil {
+			return nil, err
+		}
+	}
+	directory.WriteByte(fieldTerminator)
+
+	base := leaderLength + directory.Len()
+	length := base + data.Len() + 1
+	if length > maxRecordLength {
+		return nil, fmt.Errorf("record is %w", ErrTooLong)
+	}
+	leader := []byte(normalizeLeader(rec.Leader))
+	copy(leader[0:5], fmt.Sprintf("%05d", length))
+	copy(leader[12:17], fmt.Sprintf("%05d", base))
+
+	out := make([]byte, 0, length)
+	out = append(out, leader...)
+	out = append(out, directory.Bytes()...)
+	out = append(out, data.Bytes()...)
+	return append(out, recordTerminator), nil
+}
+
+// number reads a directory or leader number, which is all digits.
+func number(b []byte) (int, bool) {
+	n := 0
+	for _, c := range b {
+		if c < '0' || c > '9' {
+			return 0, false
+		}
+		n = n*10 + int(c-'0')
+	}
+	return n, len(b) > 0
+}
+
+func normalizeLeader(leader string) string {
+	if len(leader) >= leaderLength {
+		return leader[:leaderLength]
+	}
+	return leader + string(bytes.Repeat([]byte{' '}, leaderLength-len(leader)))
+}
@@ -0,0 +1,111 @@
+package marc
+
+import (
+	"bytes"
+	"fmt"
+	"github.com/stretchr/testify/assert"
+	"io"
+	"strings"
+	"testing"
+)
+
+func testRecord() *Record {
+	rec := &Record{Leader: "00000nam a2200000 a 4500"}
+	rec.AddControl("001", "42")
+	rec.AddData("020", ' ', ' ', "a", "9780140449136 (pbk.)")
+	rec.AddData("100", '1', ' ', "a", "Homer.")
+	rec.AddData("245", '1', '4', "a", "The Odyssey /", "c", "Homer ; translated by E.V. Rieu.")
+	rec.AddData("250", ' ', ' ', "a", "")
+	return rec
+}
+
+func TestMarshalUnmarshal(t *testing.T) {
+	rec := testRecord()
+	raw, err := Marshal(rec)
+	assert.NoError(t, err)
+
+	assert.Equal(t, byte(recordTerminator), raw[len(raw)-1])
+	assert.Equal(t, fmt.Sprintf("%05d", len(raw)), string(raw[:5]))
+
+	got, err := Unmarshal(raw)
+	assert.NoError(t, err)
+	assert.Equal(t, "42", got.Control("001"))
+	assert.Equal(t, "Homer.", got.Subfield('a', "100"))
+	assert.Equal(t, "The Odyssey /", got.Subfield('a', "245"))
+	assert.Equal(t, "Homer ; translated by E.V. Rieu.", got.Subfield('c', "245"))
+	assert.Equal(t, byte('4'), got.DataFields[2].Ind2)
+	assert.Len(t, got.DataFields, 3)
+	assert.Equal(t, "", got.Subfield('a', "250", "260"))
+}
+
+func TestReader(t *testing.T) {
+	first, err := Marshal(testRecord())
+	assert.NoError(t, err)
+	second, err := Marshal(&Record{Leader: "00000nam a2200000 a 4500", ControlFields: []ControlField{{Tag: "001", Value: "43"}}})
+	assert.NoError(t, err)
+
+	var stream bytes.Buffer
+	stream.Write(first)
+	stream.WriteString("\n")
+	stream.Write(second)
+
+	r := NewReader(&stream)
+	rec, err := r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "42", rec.Control("001"))
+
+	rec, err = r.Read()
+	assert.NoError(t, err)
+	assert.Equal(t, "43", rec.Control("001"))
+
+	_, err = r.Read()
+	assert.Equal(t, io.EOF, err)
+
+	_, err = NewReader(strings.NewReader("00010nam")).Read()
+	assert.ErrorIs(t, err, ErrMalformed)
+	_, err = Unmarshal([]byte("00030nam a2299999 a 4500\x1e\x1d"))
+	assert.ErrorIs(t, err, ErrMalformed)
+}
+
+func TestUnmarshal_BadDirectory(t *testing.T) {
+	for _, entry := range []string{"2450005-0040", "24500-5000000", "2450005+0000", "245 0050000", "2450050000"} {
+		raw := "00000nam a2200000 i 4500" + entry + "\x1eabcde\x1e\x1d"
+		raw = fmt.Sprintf("%05d", len(raw)) + raw[5:12] + fmt.Sprintf("%05d", 24+len(entry)+1) + raw[17:]
+		_, err := Unmarshal([]byte(raw))
+		assert.ErrorIs(t, err, ErrMalformed, entry)
+	}
+}
+
+func TestMarshal_TooLong(t *testing.T) {
+	rec := testRecord()
+	rec.AddData("520", ' ', ' ', "a", strings.Repeat("x", maxFieldLength))
+	_, err := Marshal(rec)
+	assert.ErrorIs(t, err, ErrTooLong)
+
+	rec = testRecord()
+	for i := 0; i < 20; i++ {
+		rec.AddData("520", ' ', ' ', "a", strings.Repeat("x", 5000))
+	}
+	_, err = Marshal(rec)
+	assert.ErrorIs(t, err, ErrTooLong)
+}
+
+func TestXML(t *testing.T) {
+	var buf bytes.Buffer
+	err := WriteXML(&buf, []*Record{testRecord()})
+	assert.NoError(t, err)
+	assert.Contains(t, buf.String(), `<collection xmlns="http://www.loc.gov/MARC21/slim">`)
+	assert.Contains(t, buf.String(), `<datafield tag="245" ind1="1" ind2="4">`)
+
+	records, err := ReadXML(&buf)
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, testRecord().DataFields, records[0].DataFields)
+	assert.Equal(t, "42", records[0].Control("001"))
+
+	// a bare <record> with a namespace prefix is accepted too
+	records, err = ReadXML(strings.NewReader(`<marc:record xmlns:marc="http://www.loc.gov/MARC21/slim"><marc:leader>00000nam a2200000 a 4500</marc:leader><marc:datafield tag="100" ind1="1" ind2=" "><marc:subfield code="a">Caldwell, Erskine.</marc:subfield></marc:datafield></marc:record>`))
+	assert.NoError(t, err)
+	assert.Len(t, records, 1)
+	assert.Equal(t, "Caldwell, Erskine.", records[0].Subfield('a', "100"))
+}
@@ -0,0 +1,126 @@
+package marc
+
+import (
+	"encoding/xml"
+	"io"
+)
+
+const Namespace = "http://www.loc.gov/MARC21/slim"
+
+type xmlRecord struct {
+	XMLName       xml.Name          `xml:"record"`
+	Leader        string            `xml:"leader"`
+	ControlFields []xmlControlField `xml:"controlfield"`
+	DataFields    []xmlDataField    `xml:"datafield"`
+}
+
+type xmlControlField struct {
+	Tag   string `xml:"tag,attr"`
+	Value string `xml:",chardata"`
+}
+
+type xmlDataField struct {
+	Tag       string        `xml:"tag,attr"`
+	Ind1      string        `xml:"ind1,attr"`
+	Ind2      string        `xml:"ind2,attr"`
+	Subfields []xmlSubfield `xml:"subfield"`
+}
+
+type xmlSubfield struct {
+	Code  string `xml:"code,attr"`
+	Value string `xml:",chardata"`
+}
+
+// ReadXML returns every <record> in a MARCXML document, whether wrapped in a
+// <collection> or not.
+func ReadXML(r io.Reader) ([]*Record, error) {
+	var records []*Record
+	dec := xml.NewDecoder(r)
+	for {
+		tok, err := dec.Token()
+		if err == io.EOF {
+			return records, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+
+		start, ok := tok.(xml.StartElement)
+		if !ok || start.Name.Local != "record" {
+			continue
+		}
+		var xr xmlRecord
+		err = dec.DecodeElement(&xr, &start)
+		if err != nil {
+			return nil, err
+		}
+		records = append(records, fromXML(&xr))
+	}
+}
+
+// WriteXML writes the records as a MARCXML <collection>.
+func WriteXML(w io.Writer, records []*Record) error {
+	_, err := io.WriteString(w, xml.Header)
+	if err != nil {
+		return err
+	}
+
+	enc := xml.NewEncoder(w)
+	enc.Indent("", "  ")
+	collection := xml.StartElement{Name: xml.Name{Local: "collection"}, Attr: []xml.Attr{{Name: xml.Name{Local: "xmlns"}, Value: Namespace}}}
+	err = enc.EncodeToken(collection)
+	if err != nil {
+		return err
+	}
+	for _, rec := range records {
+		err = enc.Encode(toXML(rec))
+		if err != nil {
+			return err
+		}
+	}
+	err = enc.EncodeToken(collection.End())
+	if err != nil {
+		return err
+	}
+	return enc.Flush()
+}
+
+func fromXML(xr *xmlRecord) *Record {
+	rec := &Record{Leader: xr.Leader}
+	for _, cf := range xr.ControlFields {
+		rec.AddControl(cf.Tag, cf.Value)
+	}
+	for _, df := range xr.DataFields {
+		f := DataField{Tag: df.Tag, Ind1: indicator(df.Ind1), Ind2: indicator(df.Ind2)}
+		for _, sf := range df.Subfields {
+			if sf.Code == "" {
+				continue
+			}
+			f.Subfields = append(f.Subfields, Subfield{Code: sf.Code[0], Value: sf.Value})
+		}
+		rec.DataFields = append(rec.DataFields, f)
+	}
+	return rec
+}
+
+func toXML(rec *Record) *xmlRecord {
+	xr := &xmlRecord{Leader: normalizeLeader(rec.Leader)}
+	for _, cf := range rec.ControlFields {
+		xr.ControlFields = append(xr.ControlFields, xmlControlField{Tag: cf.Tag, Value: cf.Value})
+	}
+	for _, df := range rec.DataFields {
+		f := xmlDataField{Tag: df.Tag, Ind1: string(blank(df.Ind1)), Ind2: string(blank(df.Ind2))}
+		for _, sf := range df.Subfields {
+			f.Subfields = append(f.Subfields, xmlSubfield{Code: string(sf.Code), Value: sf.Value})
+		}
+		xr.DataFields = append(xr.DataFields, f)
+	}
+	return xr
+}
+
+func indicator(s string) byte {
+	if s == "" {
+		return ' '
+	}
+	return s[0]
+}
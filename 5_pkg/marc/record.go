@@ -0,0 +1,82 @@
+package marc
+
+import "strings"
+
+type Record struct {
+	Leader        string
+	ControlFields []ControlField
+	DataFields    []DataField
+}
+
+type ControlField struct {
+	Tag   string
+	Value string
+}
+
+type DataField struct {
+	Tag       string
+	Ind1      byte
+	Ind2      byte
+	Subfields []Subfield
+}
+
+type Subfield struct {
+	Code  byte
+	Value string
+}
+
+// Control returns the value of the first control field with the tag.
+func (r *Record) Control(tag string) string {
+	for _, f := range r.ControlFields {
+		if f.Tag == tag {
+			return f.Value
+		}
+	}
+	return ""
+}
+
+// Subfield returns the first subfield value found in the listed tags, in order.
+func (r *Record) Subfield(code byte, tags ...string) string {
+	for _, tag := range tags {
+		for _, f := range r.DataFields {
+			if f.Tag != tag {
+				continue
+			}
+			for _, sf := range f.Subfields {
+				if sf.Code == code {
+					return strings.TrimSpace(sf.Value)
+				}
+			}
+		}
+	}
+	return ""
+}
+
+func (r *Record) AddControl(tag, value string) {
+	r.ControlFields = append(r.ControlFields, ControlField{Tag: tag, Value: value})
+}
+
+// AddData appends a data field built from code/value pairs, skipping empty values.
+// Fields left without any subfield are not added.
+func (r *Record) AddData(tag string, ind1, ind2 byte, pairs ...string) {
+	f := DataField{Tag: tag, Ind1: ind1, Ind2: ind2}
+	for i := 0; i+1 < len(pairs); i += 2 {
+		if pairs[i+1] != "" {
+			f.Subfields = append(f.Subfields, Subfield{Code: pairs[i][0], Value: pairs[i+1]})
+		}
+	}
+	if len(f.Subfields) > 0 {
+		r.DataFields = append(r.DataFields, f)
+	}
+}
+
+func isControlTag(tag string) bool {
+	return strings.HasPrefix(tag, "00")
+}
+
+func blank(ind byte) byte {
+	if ind == 0 {
+		return ' '
+	}
+	return ind
+}
@@ -0,0 +1,58 @@
+package mergepatch
+
+import (
+	"bytes"
+	"encoding/json"
+)
+
+// Apply applies an RFC 7396 JSON merge patch to doc: objects are merged member by
+// member, null removes a member and any other value replaces the target outright.
+func Apply(doc, patch []byte) ([]byte, error) {
+	p, err := decode(patch)
+	if err != nil {
+		return nil, err
+	}
+
+	var d interface{}
+	if len(bytes.TrimSpace(doc)) > 0 {
+		d, err = decode(doc)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	return json.Marshal(merge(d, p))
+}
+
+func decode(data []byte) (interface{}, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+
+	var v interface{}
+	err := dec.Decode(&v)
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func merge(target, patch interface{}) interface{} {
+	p, ok := patch.(map[string]interface{})
+	if !ok {
+		return patch
+	}
+
+	t, ok := target.(map[string]interface{})
+	if !ok {
+		t = map[string]interface{}{}
+	}
+
+	for k, v := range p {
+		if v == nil {
+			delete(t, k)
+			continue
+		}
+		t[k] = merge(t[k], v)
+	}
+	return t
+}
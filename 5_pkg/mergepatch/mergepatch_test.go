@@ -0,0 +1,45 @@
+package mergepatch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestApply(t *testing.T) {
+	tests := []struct {
+		doc   string
+		patch string
+		want  string
+	}{
+		{doc: `{"a":"b"}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"b":"c"}`, want: `{"a":"b","b":"c"}`},
+		{doc: `{"a":"b"}`, patch: `{"a":null}`, want: `{}`},
+		{doc: `{"a":"b","b":"c"}`, patch: `{"a":null}`, want: `{"b":"c"}`},
+		{doc: `{"a":["b"]}`, patch: `{"a":"c"}`, want: `{"a":"c"}`},
+		{doc: `{"a":"c"}`, patch: `{"a":["b"]}`, want: `{"a":["b"]}`},
+		{doc: `{"a":{"b":"c"}}`, patch: `{"a":{"b":"d","c":null}}`, want: `{"a":{"b":"d"}}`},
+		{doc: `{"a":[{"b":"c"}]}`, patch: `{"a":[1]}`, want: `{"a":[1]}`},
+		{doc: `["a","b"]`, patch: `["c","d"]`, want: `["c","d"]`},
+		{doc: `{"a":"b"}`, patch: `["c"]`, want: `["c"]`},
+		{doc: `{"a":"foo"}`, patch: `null`, want: `null`},
+		{doc: `{"a":"foo"}`, patch: `"bar"`, want: `"bar"`},
+		{doc: `{"e":null}`, patch: `{"a":1}`, want: `{"a":1,"e":null}`},
+		{doc: `[1,2]`, patch: `{"a":"b","c":null}`, want: `{"a":"b"}`},
+		{doc: `{}`, patch: `{"a":{"bb":{"ccc":null}}}`, want: `{"a":{"bb":{}}}`},
+		{doc: `{"n":9007199254740993}`, patch: `{}`, want: `{"n":9007199254740993}`},
+	}
+
+	for _, tt := range tests {
+		got, err := Apply([]byte(tt.doc), []byte(tt.patch))
+		assert.NoError(t, err)
+		assert.JSONEq(t, tt.want, string(got), tt.patch)
+	}
+}
+
+func TestApply_Error(t *testing.T) {
+	_, err := Apply([]byte(`{"a":"b"}`), []byte(`{"a":`))
+	assert.Error(t, err)
+
+	_, err = Apply([]byte(`not json`), []byte(`{"a":"b"}`))
+	assert.Error(t, err)
+}
@@ -0,0 +1,56 @@
+// Package password hashes passwords with bcrypt and checks them against a minimal
+// strength policy.
+package password
+
+import (
+	"golang.org/x/crypto/bcrypt"
+	"unicode"
+)
+
+const (
+	Cost = bcrypt.DefaultCost
+
+	MinLength = 8
+	// MaxLength is in bytes: bcrypt only reads the first 72
+	MaxLength = 72
+)
+
+// Hash returns the bcrypt hash of plain with a fresh random salt.
+func Hash(plain string) (string, error) {
+	hash, err := bcrypt.GenerateFromPassword([]byte(plain), Cost)
+	if err != nil {
+		return "", err
+	}
+	return string(hash), nil
+}
+
+// Verify tells whether plain is the password behind hash, in constant time.
+func Verify(hash, plain string) bool {
+	return bcrypt.CompareHashAndPassword([]byte(hash), []byte(plain)) == nil
+}
+
+// IsHash tells a stored hash from a password kept in plaintext by older versions.
+func IsHash(s string) bool {
+	_, err := bcrypt.Cost([]byte(s))
+	return err == nil
+}
+
+// Strong is the policy for new passwords: MinLength characters to MaxLength bytes
+// with at least one letter and one digit or symbol.
+func Strong(plain string) bool {
+	if len([]rune(plain)) < MinLength || len(plain) > MaxLength {
+		return false
+	}
+
+	var letter, other bool
+	for _, r := range plain {
+		switch {
+		case unicode.IsLetter(r):
+			letter = true
+		case unicode.IsSpace(r):
+		default:
+			other = true
+		}
+	}
+	return letter && other
+}
@@ -0,0 +1,36 @@
+package password
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+)
+
+func TestHash(t *testing.T) {
+	hash, err := Hash("pw124567")
+	assert.NoError(t, err)
+	assert.True(t, strings.HasPrefix(hash, "$2a$10$"))
+	assert.True(t, IsHash(hash))
+	assert.True(t, Verify(hash, "pw124567"))
+	assert.False(t, Verify(hash, "pw124568"))
+
+	other, err := Hash("pw124567")
+	assert.NoError(t, err)
+	assert.NotEqual(t, hash, other)
+
+	assert.False(t, IsHash("pw124567"))
+	assert.False(t, Verify("pw124567", "pw124567"))
+	assert.False(t, IsHash("$2a$10$short"))
+
+	_, err = Hash(strings.Repeat("a1", 37))
+	assert.Error(t, err)
+}
+
+func TestStrong(t *testing.T) {
+	for _, plain := range []string{"pw124567", "correct horse battery!", "пароль-2023", strings.Repeat("a1", 36)} {
+		assert.True(t, Strong(plain), plain)
+	}
+	for _, plain := range []string{"", "pw1", "password", "12345678", "        ", strings.Repeat("a1", 37), strings.Repeat("пароль-1", 6)} {
+		assert.False(t, Strong(plain), plain)
+	}
+}
@@ -0,0 +1,124 @@
+package phone
+
+import (
+	"errors"
+	"strings"
+)
+
+var (
+	ErrEmpty         = errors.New("is required")
+	ErrSyntax        = errors.New("may only hold digits, spaces, dashes, dots, parentheses and a leading +")
+	ErrNoCountryCode = errors.New("needs a country code, e.g. +380, or a country we know in location")
+	ErrLength        = errors.New("has the wrong number of digits for its country")
+)
+
+// country is what it takes to read a national number: the calling code, the
+// trunk prefix dialled before it at home, and how long the rest may be.
+type country struct {
+	code       string
+	trunk      string
+	minLen     int
+	maxLen     int
+	exitPrefix string
+}
+
+var (
+	nanp    = country{code: "1", trunk: "1", minLen: 10, maxLen: 10, exitPrefix: "011"}
+	ukraine = country{code: "380", trunk: "0", minLen: 9, maxLen: 9, exitPrefix: "00"}
+	uk      = country{code: "44", trunk: "0", minLen: 9, maxLen: 10, exitPrefix: "00"}
+	germany = country{code: "49", trunk: "0", minLen: 6, maxLen: 13, exitPrefix: "00"}
+	poland  = country{code: "48", minLen: 9, maxLen: 9, exitPrefix: "00"}
+	france  = country{code: "33", trunk: "0", minLen: 9, maxLen: 9, exitPrefix: "00"}
+)
+
+// countries maps the ways people write a country in Location to its numbering plan.
+var countries = map[string]country{
+	"usa": nanp, "us": nanp, "united states": nanp, "united states of america": nanp, "america": nanp,
+	"canada": nanp, "ca": nanp,
+	"ukraine": ukraine, "ua": ukraine, "україна": ukraine,
+	"uk": uk, "gb": uk, "united kingdom": uk, "great britain": uk, "england": uk, "scotland": uk, "wales": uk,
+	"germany": germany, "de": germany, "deutschland": germany,
+	"poland": poland, "pl": poland, "polska": poland,
+	"france": france, "fr": france,
+}
+
+// byCode finds a numbering plan by calling code, for checking international numbers.
+var byCode = map[string]country{}
+
+func init() {
+	for _, c := range countries {
+		byCode[c.code] = c
+	}
+}
+
+// Normalize returns number in E.164 form, "+" and up to 15 digits. Numbers written
+// without a country code are read in the country that location names, either
+// alone ("Ukraine") or last in a list ("Kyiv, Ukraine").
+func Normalize(number, location string) (string, error) {
+	number = strings.TrimSpace(number)
+	if number == "" {
+		return "", ErrEmpty
+	}
+
+	var digits strings.Builder
+	plus := false
+	for i, r := range number {
+		switch {
+		case r >= '0' && r <= '9':
+			digits.WriteRune(r)
+		case r == '+' && i == 0:
+			plus = true
+		case r == ' ' || r == '-' || r == '.' || r == '(' || r == ')':
+		default:
+			return "", ErrSyntax
+		}
+	}
+	d := digits.String()
+
+	home, known := countryOf(location)
+	switch {
+	case plus:
+	case known && home.exitPrefix != "" && strings.HasPrefix(d, home.exitPrefix):
+		d = d[len(home.exitPrefix):]
+	case known:
+		d = strings.TrimPrefix(d, home.trunk)
+		if len(d) < home.minLen || len(d) > home.maxLen {
+			return "", ErrLength
+		}
+		return "+" + home.code + d, nil
+	default:
+		return "", ErrNoCountryCode
+	}
+
+	return international(d)
+}
+
+// international checks digits that start with a calling code.
+func international(d string) (string, error) {
+	if len(d) < 8 || len(d) > 15 || d[0] == '0' {
+		return "", ErrLength
+	}
+	// calling codes are prefix-free, so at most one of these matches
+	for n := 1; n <= 3; n++ {
+		c, ok := byCode[d[:n]]
+		if !ok {
+			continue
+		}
+		national := len(d) - n
+		if national < c.minLen || national > c.maxLen {
+			return "", ErrLength
+		}
+		break
+	}
+	return "+" + d, nil
+}
+
+func countryOf(location string) (country, bool) {
+	location = strings.ToLower(strings.TrimSpace(location))
+	if c, ok := countries[location]; ok {
+		return c, true
+	}
+	parts := strings.Split(location, ",")
+	c, ok := countries[strings.TrimSpace(parts[len(parts)-1])]
+	return c, ok
+}
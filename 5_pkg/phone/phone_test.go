@@ -0,0 +1,41 @@
+package phone
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	tests := []struct {
+		number   string
+		location string
+		want     string
+		err      error
+	}{
+		{number: "0933115485", location: "Ukraine", want: "+380933115485"},
+		{number: "093 311 54 85", location: "Kyiv, Ukraine", want: "+380933115485"},
+		{number: "+38 (093) 311-54-85", location: "", want: "+380933115485"},
+		{number: "00380933115485", location: "ukraine", want: "+380933115485"},
+		{number: "+16479250145", location: "USA", want: "+16479250145"},
+		{number: "(647) 925-0145", location: "Canada", want: "+16479250145"},
+		{number: "1 647 925 0145", location: "Toronto, Canada", want: "+16479250145"},
+		{number: "011 44 20 7946 0958", location: "USA", want: "+442079460958"},
+		{number: "020 7946 0958", location: "United Kingdom", want: "+442079460958"},
+		{number: "+48 512 345 678", location: "Ukraine", want: "+48512345678"},
+		{number: "+99512345678", location: "", want: "+99512345678"},
+		{number: "", location: "Ukraine", err: ErrEmpty},
+		{number: "093-311-54-85 ext 2", location: "Ukraine", err: ErrSyntax},
+		{number: "0933+115485", location: "Ukraine", err: ErrSyntax},
+		{number: "0933115485", location: "Kyiv", err: ErrNoCountryCode},
+		{number: "093311548", location: "Ukraine", err: ErrLength},
+		{number: "+38093311548", location: "", err: ErrLength},
+		{number: "+1647925014", location: "", err: ErrLength},
+		{number: "+0933115485", location: "", err: ErrLength},
+		{number: "+1234567890123456", location: "", err: ErrLength},
+	}
+	for _, tt := range tests {
+		got, err := Normalize(tt.number, tt.location)
+		assert.Equal(t, tt.err, err, tt.number)
+		assert.Equal(t, tt.want, got, tt.number)
+	}
+}
@@ -0,0 +1,101 @@
+// Package textmatch compares free-text catalogue fields that people type in
+// slightly differently, like titles and author names.
+package textmatch
+
+import (
+	"sort"
+	"strings"
+	"unicode"
+)
+
+var folder = strings.NewReplacer(
+	"á", "a", "à", "a", "â", "a", "ä", "a", "ã", "a", "å", "a",
+	"é", "e", "è", "e", "ê", "e", "ë", "e",
+	"í", "i", "ì", "i", "î", "i", "ï", "i",
+	"ó", "o", "ò", "o", "ô", "o", "ö", "o", "õ", "o", "ø", "o",
+	"ú", "u", "ù", "u", "û", "u", "ü", "u",
+	"ç", "c", "ñ", "n", "ß", "ss",
+)
+
+var articles = map[string]bool{"the": true, "a": true, "an": true}
+
+// Normalize lower-cases s, folds common accents, turns punctuation into spaces
+// and drops a leading article, so "The Odyssey." and "odyssey" compare equal.
+func Normalize(s string) string {
+	s = folder.Replace(strings.ToLower(s))
+	words := strings.FieldsFunc(s, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) > 1 && articles[words[0]] {
+		words = words[1:]
+	}
+	return strings.Join(words, " ")
+}
+
+// Tokens are the words of a normalized string.
+func Tokens(s string) []string {
+	return strings.Fields(s)
+}
+
+// Similarity scores two normalized strings from 0 to 1 by edit distance. Word
+// order doesn't count, so "caldwell erskine" matches "erskine caldwell".
+func Similarity(a, b string) float64 {
+	if a == b {
+		return 1
+	}
+
+	score := ratio(a, b)
+	if sorted := ratio(sortWords(a), sortWords(b)); sorted > score {
+		score = sorted
+	}
+	return score
+}
+
+func sortWords(s string) string {
+	words := strings.Fields(s)
+	sort.Strings(words)
+	return strings.Join(words, " ")
+}
+
+func ratio(a, b string) float64 {
+	ra, rb := []rune(a), []rune(b)
+	longest := len(ra)
+	if len(rb) > longest {
+		longest = len(rb)
+	}
+	if longest == 0 {
+		return 1
+	}
+	return 1 - float64(levenshtein(ra, rb))/float64(longest)
+}
+
+func levenshtein(a, b []rune) int {
+	prev := make([]int, len(b)+1)
+	cur := make([]int, len(b)+1)
+	for j := range prev {
+		prev[j] = j
+	}
+
+	for i := 1; i <= len(a); i++ {
+		cur[0] = i
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			cur[j] = min(prev[j]+1, cur[j-1]+1, prev[j-1]+cost)
+		}
+		prev, cur = cur, prev
+	}
+	return prev[len(b)]
+}
+
+func min(values ...int) int {
+	m := values[0]
+	for _, v := range values[1:] {
+		if v < m {
+			m = v
+		}
+	}
+	return m
+}
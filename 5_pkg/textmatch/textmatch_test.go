@@ -0,0 +1,22 @@
+package textmatch
+
+import (
+	"github.com/stretchr/testify/assert"
+	"testing"
+)
+
+func TestNormalize(t *testing.T) {
+	assert.Equal(t, "odyssey", Normalize("The Odyssey."))
+	assert.Equal(t, "god s little acre", Normalize("  God's   Little ACRE "))
+	assert.Equal(t, "garcia marquez gabriel", Normalize("García Márquez, Gabriel"))
+	assert.Equal(t, "a", Normalize("A"))
+	assert.Equal(t, "", Normalize(" -- "))
+}
+
+func TestSimilarity(t *testing.T) {
+	assert.Equal(t, 1.0, Similarity("odyssey", "odyssey"))
+	assert.Equal(t, 1.0, Similarity("caldwell erskine", "erskine caldwell"))
+	assert.InDelta(t, 0.875, Similarity("tobaco road", "tobacco road"), 0.05)
+	assert.Less(t, Similarity("tobacco road", "journeyman"), 0.5)
+	assert.Equal(t, 1.0, Similarity("", ""))
+}
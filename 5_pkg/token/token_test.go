@@ -0,0 +1,44 @@
+package token
+
+import (
+	"github.com/stretchr/testify/assert"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSignParse(t *testing.T) {
+	secret := []byte("0123456789abcdef0123456789abcdef")
+	now := time.Unix(1700000000, 0)
+	claims := Claims{Subject: "1", Session: "s1", Use: UseAccess, IssuedAt: now.Unix(), ExpiresAt: now.Add(15 * time.Minute).Unix()}
+
+	tok, err := Sign(claims, secret)
+	assert.NoError(t, err)
+	assert.Equal(t, 3, len(strings.Split(tok, ".")))
+
+	claimsGot, err := Parse(tok, secret, now.Add(time.Minute))
+	assert.NoError(t, err)
+	assert.Equal(t, claims, *claimsGot)
+
+	_, err = Parse(tok, secret, now.Add(15*time.Minute))
+	assert.Equal(t, ErrExpired, err)
+
+	_, err = Parse(tok, []byte("another secret"), now)
+	assert.Equal(t, ErrInvalid, err)
+
+	// a payload swapped under the original signature
+	other, err := Sign(Claims{Subject: "2", ExpiresAt: claims.ExpiresAt}, []byte("attacker"))
+	assert.NoError(t, err)
+	parts, otherParts := strings.Split(tok, "."), strings.Split(other, ".")
+	_, err = Parse(parts[0]+"."+otherParts[1]+"."+parts[2], secret, now)
+	assert.Equal(t, ErrInvalid, err)
+
+	// alg none
+	_, err = Parse("eyJhbGciOiJub25lIiwidHlwIjoiSldUIn0."+parts[1]+".", secret, now)
+	assert.Equal(t, ErrInvalid, err)
+
+	for _, bad := range []string{"", "a.b", "a.b.c.d", parts[0] + ".!!." + parts[2]} {
+		_, err = Parse(bad, secret, now)
+		assert.Equal(t, ErrInvalid, err)
+	}
+}
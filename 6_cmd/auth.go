@@ -0,0 +1,53 @@
+package main
+
+import (
+	"crypto/rand"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth"
+	notifierLog "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/notifier/log"
+	notifierSMTP "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/notifier/smtp"
+	"log"
+	"os"
+)
+
+const minSecretLength = 32
+
+// authSecret reads the token signing key from AUTH_SECRET. Without one a random key
+// is used, which logs everybody out whenever the server restarts.
+func authSecret() []byte {
+	secret := os.Getenv("AUTH_SECRET")
+	if len(secret) >= minSecretLength {
+		return []byte(secret)
+	}
+	if secret != "" {
+		log.Printf("AUTH_SECRET must be at least %d bytes, using a random key", minSecretLength)
+	} else {
+		log.Println("AUTH_SECRET is not set, using a random key")
+	}
+
+	key := make([]byte, minSecretLength)
+	_, err := rand.Read(key)
+	if err != nil {
+		log.Fatal(err)
+	}
+	return key
+}
+
+// notifier mails through SMTP_ADDR when it is set; otherwise the messages, links
+// included, only go to the log.
+func notifier() auth.Notifier {
+	addr := os.Getenv("SMTP_ADDR")
+	if addr == "" {
+		log.Println("SMTP_ADDR is not set, verification links are written to the log")
+		return notifierLog.NewLog(log.Default())
+	}
+	return notifierSMTP.NewSMTP(addr, os.Getenv("SMTP_FROM"), os.Getenv("SMTP_USERNAME"), os.Getenv("SMTP_PASSWORD"))
+}
+
+// publicURL is where clients reach the server, used for the links in mails.
+func publicURL() string {
+	u := os.Getenv("PUBLIC_URL")
+	if u == "" {
+		return "http://localhost:8080"
+	}
+	return u
+}
@@ -0,0 +1,168 @@
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"errors"
+	"flag"
+	"fmt"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/bookimport"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// runImport implements "import [-format csv|jsonl|marc|marcxml] [-dry-run] <file>".
+func runImport(importer bookimport.UseCase, args []string) error {
+	fs := flag.NewFlagSet("import", flag.ExitOnError)
+	format := fs.String("format", "", "csv, jsonl, marc or marcxml, guessed from the file extension when empty")
+	dryRun := fs.Bool("dry-run", false, "validate and report without writing to the database")
+	fs.Parse(args)
+
+	if fs.NArg() != 1 {
+		return errors.New("usage: import [-format csv|jsonl|marc|marcxml] [-dry-run] <file>")
+	}
+	path := fs.Arg(0)
+
+	if *format == "" {
+		switch strings.ToLower(filepath.Ext(path)) {
+		case ".csv":
+			*format = bookimport.FormatCSV
+		case ".jsonl", ".ndjson":
+			*format = bookimport.FormatJSONL
+		case ".mrc", ".marc":
+			*format = catalog.FormatMARC
+		case ".xml":
+			*format = catalog.FormatMARCXML
+		default:
+			return fmt.Errorf("cannot guess the format of %s, pass -format", path)
+		}
+	}
+
+	f, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	report, err := importer.Import(f, *format, *dryRun)
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	return enc.Encode(report)
+}
+
+// runExport implements "export [-format marcxml|marc] [id...]", writing to stdout.
+func runExport(c catalog.UseCase, args []string) error {
+	fs := flag.NewFlagSet("export", flag.ExitOnError)
+	format := fs.String("format", catalog.FormatMARCXML, "marcxml or marc")
+	fs.Parse(args)
+
+	var ids []int
+	for _, arg := range fs.Args() {
+		id, err := strconv.Atoi(arg)
+		if err != nil {
+			return fmt.Errorf("invalid book id %q", arg)
+		}
+		ids = append(ids, id)
+	}
+
+	w := bufio.NewWriter(os.Stdout)
+	err := c.Export(w, *format, ids)
+	if err != nil {
+		return err
+	}
+	return w.Flush()
+}
+
+// runPurge implements "purge [-retention 720h]" for running the purge from cron instead of the server.
+func runPurge(books book.UseCase, users user.UseCase, args []string) error {
+	fs := flag.NewFlagSet("purge", flag.ExitOnError)
+	keep := fs.Duration("retention", retention(), "how long deleted books and users are kept")
+	fs.Parse(args)
+
+	return purgeDeleted(books, users, *keep)
+}
+
+// runReconcile implements "reconcile", printing the books whose stock disagrees with
+// their movement ledger; it fails when there is any so cron can alert on it.
+func runReconcile(books book.UseCase) error {
+	mismatches, err := books.ReconcileStock()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	enc.SetIndent("", "  ")
+	for _, m := range mismatches {
+		err = enc.Encode(m)
+		if err != nil {
+			return err
+		}
+	}
+	if len(mismatches) > 0 {
+		return fmt.Errorf("%d books don't match their inventory ledger", len(mismatches))
+	}
+	return nil
+}
+
+// runHashPasswords implements "hash-passwords", hashing the passwords that older versions
+// stored in plaintext; run it once after applying migrations/0012_password_hashes.sql.
+func runHashPasswords(users user.UseCase) error {
+	hashed, err := users.HashLegacyPasswords()
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("hashed %d passwords\n", hashed)
+	return nil
+}
+
+// runSetRole implements "set-role <user id> <member|librarian|admin>", which is how
+// the first admin is made.
+func runSetRole(users user.UseCase, args []string) error {
+	if len(args) != 2 {
+		return errors.New("usage: set-role <user id> <member|librarian|admin>")
+	}
+	id, err := strconv.Atoi(args[0])
+	if err != nil {
+		return err
+	}
+
+	u, err := users.SetRoleUser(id, args[1])
+	if err != nil {
+		return err
+	}
+
+	fmt.Printf("user %d is now %s\n", u.ID, u.Role)
+	return nil
+}
+
+// runEmailDuplicates implements "email-duplicates", printing the addresses that live users
+// share. migrations/0015_unique_email.sql refuses to run until there are none, so it
+// fails when there is any.
+func runEmailDuplicates(users user.UseCase) error {
+	dups, err := users.FindDuplicateEmails()
+	if err != nil {
+		return err
+	}
+
+	enc := json.NewEncoder(os.Stdout)
+	for _, d := range dups {
+		err = enc.Encode(d)
+		if err != nil {
+			return err
+		}
+	}
+	if len(dups) > 0 {
+		return fmt.Errorf("%d email addresses are shared by several users", len(dups))
+	}
+	return nil
+}
@@ -2,17 +2,30 @@ package main
 
 import (
 	"fmt"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/auth"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/bookimport"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/catalog"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/cover"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/loan"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/review"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/series"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/stocktake"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/3_api/handler"
+	repositoryAuth "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/auth"
 	repositoryBook "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/book"
+	repositoryReview "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/review"
+	repositorySeries "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/series"
+	repositoryStocktake "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/stocktake"
 	repositoryUser "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/repository/user"
+	storageLocal "github.com/TarasTarkovskyi/crud-3-clean-architecture/4_infrastructure/storage/local"
 	"github.com/TarasTarkovskyi/crud-3-clean-architecture/5_pkg/database"
 	"github.com/gorilla/mux"
 	_ "github.com/lib/pq"
 	"log"
 	"net/http"
+	"os"
 )
 
 func main() {
@@ -30,13 +43,97 @@ func main() {
 	bookService := book.NewService(bookRepo)
 	bookHandler := handler.NewBookHandler(bookService)
 
+	if os.Getenv("ALLOW_CLIENT_IDS") == "false" {
+		userService.AllowClientIDs(false)
+		bookService.AllowClientIDs(false)
+	}
+
+	importService := bookimport.NewService(bookService)
+	importHandler := handler.NewImportHandler(importService)
+
+	catalogService := catalog.NewService(bookService)
+	catalogHandler := handler.NewCatalogHandler(catalogService)
+
+	if len(os.Args) > 1 {
+		switch os.Args[1] {
+		case "import":
+			err = runImport(importService, os.Args[2:])
+		case "export":
+			err = runExport(catalogService, os.Args[2:])
+		case "purge":
+			err = runPurge(bookService, userService, os.Args[2:])
+		case "reconcile":
+			err = runReconcile(bookService)
+		case "hash-passwords":
+			err = runHashPasswords(userService)
+		case "email-duplicates":
+			err = runEmailDuplicates(userService)
+		case "set-role":
+			err = runSetRole(userService, os.Args[2:])
+		default:
+			err = fmt.Errorf("unknown command %q", os.Args[1])
+		}
+		if err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	go runPurgeJob(bookService, userService, retention())
+
 	loanService := loan.NewLoan(userService, bookService)
 	loanHandler := handler.NewLoanHandler(loanService)
 
+	coverStore := storageLocal.NewFileSystem("data")
+	coverService := cover.NewService(coverStore, bookService)
+	coverHandler := handler.NewCoverHandler(coverService)
+
+	seriesRepo := repositorySeries.NewSeries(db)
+	seriesService := series.NewService(seriesRepo, bookService)
+	seriesHandler := handler.NewSeriesHandler(seriesService)
+
+	stocktakeRepo := repositoryStocktake.NewStocktakes(db)
+	stocktakeService := stocktake.NewService(stocktakeRepo, bookService)
+	stocktakeHandler := handler.NewStocktakeHandler(stocktakeService)
+
+	reviewRepo := repositoryReview.NewReviews(db)
+	reviewService := review.NewService(reviewRepo, bookService)
+	reviewHandler := handler.NewReviewHandler(reviewService)
+
+	authRepo := repositoryAuth.NewSessions(db)
+	authService := auth.NewService(authRepo, userService, authSecret())
+	authService.SetTTLs(durationEnv("ACCESS_TOKEN_TTL", auth.DefaultAccessTTL), durationEnv("REFRESH_TOKEN_TTL", auth.DefaultRefreshTTL))
+	authService.SetNotifier(notifier(), publicURL())
+	userService.SetVerifier(authService)
+	authHandler := handler.NewAuthHandler(authService)
+
 	r := mux.NewRouter()
-	userHandler.MakeUserHandler(r)
-	bookHandler.MakeBookHandler(r)
-	loanHandler.MakeLoanHandler(r)
+	r.Use(authHandler.Middleware, handler.Authorize)
+	// /v1 and the unprefixed routes serve the entities as they are
+	for _, v1 := range []*mux.Router{r, r.PathPrefix("/v1").Subrouter()} {
+		authHandler.MakeAuthHandler(v1)
+		userHandler.MakeUserHandler(v1)
+		importHandler.MakeImportHandler(v1)
+		catalogHandler.MakeCatalogHandler(v1)
+		bookHandler.MakeBookHandler(v1)
+		loanHandler.MakeLoanHandler(v1)
+		coverHandler.MakeCoverHandler(v1)
+		stocktakeHandler.MakeStocktakeHandler(v1)
+		seriesHandler.MakeSeriesHandler(v1)
+		reviewHandler.MakeReviewHandler(v1)
+	}
+	v2 := r.PathPrefix("/v2").Subrouter()
+	authHandler.V2().MakeAuthHandler(v2)
+	userHandler.V2().MakeUserHandler(v2)
+	importHandler.V2().MakeImportHandler(v2)
+	// covers and MARC exports have no JSON bodies, so both versions serve them alike
+	catalogHandler.MakeCatalogHandler(v2)
+	bookHandler.V2().MakeBookHandler(v2)
+	loanHandler.MakeLoanHandler(v2)
+	coverHandler.MakeCoverHandler(v2)
+	stocktakeHandler.V2().MakeStocktakeHandler(v2)
+	seriesHandler.V2().MakeSeriesHandler(v2)
+	reviewHandler.V2().MakeReviewHandler(v2)
 
 	serv := http.Server{
 		Addr:    ":8080",
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/book"
+	"github.com/TarasTarkovskyi/crud-3-clean-architecture/2_usecase/user"
+	"log"
+	"os"
+	"time"
+)
+
+const (
+	defaultRetention = 30 * 24 * time.Hour
+	purgeInterval    = time.Hour
+)
+
+// retention reads PURGE_RETENTION (a Go duration such as "720h") and falls back to 30 days.
+func retention() time.Duration {
+	return durationEnv("PURGE_RETENTION", defaultRetention)
+}
+
+// durationEnv reads a positive Go duration from the environment variable name.
+func durationEnv(name string, fallback time.Duration) time.Duration {
+	v := os.Getenv(name)
+	if v == "" {
+		return fallback
+	}
+
+	d, err := time.ParseDuration(v)
+	if err != nil || d <= 0 {
+		log.Printf("invalid %s %q, using %s", name, v, fallback)
+		return fallback
+	}
+	return d
+}
+
+func purgeDeleted(books book.UseCase, users user.UseCase, retention time.Duration) error {
+	n, err := books.PurgeDeletedBooks(retention)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("purged %d deleted books", n)
+	}
+
+	n, err = users.PurgeDeletedUsers(retention)
+	if err != nil {
+		return err
+	}
+	if n > 0 {
+		log.Printf("purged %d deleted users", n)
+	}
+	return nil
+}
+
+// runPurgeJob purges soft-deleted rows once an hour for as long as the server runs.
+func runPurgeJob(books book.UseCase, users user.UseCase, retention time.Duration) {
+	ticker := time.NewTicker(purgeInterval)
+	defer ticker.Stop()
+
+	for {
+		err := purgeDeleted(books, users, retention)
+		if err != nil {
+			log.Println("purge:", err)
+		}
+		<-ticker.C
+	}
+}
@@ -7,6 +7,7 @@ require (
 	github.com/gorilla/mux v1.8.0
 	github.com/lib/pq v1.10.7
 	github.com/stretchr/testify v1.8.2
+	golang.org/x/crypto v0.14.0
 )
 
 require (
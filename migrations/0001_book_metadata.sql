@@ -0,0 +1,7 @@
+ALTER TABLE books
+    ADD COLUMN IF NOT EXISTS publisher VARCHAR(100) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS publication_year INT NOT NULL DEFAULT 0,
+    ADD COLUMN IF NOT EXISTS edition VARCHAR(50) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS language VARCHAR(3) NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS description TEXT NOT NULL DEFAULT '',
+    ADD COLUMN IF NOT EXISTS format VARCHAR(20) NOT NULL DEFAULT '';
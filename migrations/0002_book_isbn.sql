@@ -0,0 +1,2 @@
+ALTER TABLE books ADD COLUMN IF NOT EXISTS isbn VARCHAR(13) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX IF NOT EXISTS books_isbn_unique ON books (isbn) WHERE isbn <> '';
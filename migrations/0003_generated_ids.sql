@@ -0,0 +1,9 @@
+ALTER TABLE books ALTER COLUMN id SET NOT NULL;
+ALTER TABLE books ADD PRIMARY KEY (id);
+ALTER TABLE books ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('books', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM books;
+
+ALTER TABLE users ALTER COLUMN id SET NOT NULL;
+ALTER TABLE users ADD PRIMARY KEY (id);
+ALTER TABLE users ALTER COLUMN id ADD GENERATED BY DEFAULT AS IDENTITY;
+SELECT setval(pg_get_serial_sequence('users', 'id'), COALESCE(MAX(id), 0) + 1, false) FROM users;
@@ -0,0 +1,5 @@
+ALTER TABLE books ADD COLUMN deleted_at TIMESTAMP;
+ALTER TABLE users ADD COLUMN deleted_at TIMESTAMP;
+
+DROP INDEX books_isbn_unique;
+CREATE UNIQUE INDEX books_isbn_unique ON books (isbn) WHERE isbn <> '' AND deleted_at IS NULL;
@@ -0,0 +1,6 @@
+-- quantity used to be decremented on every loan; it now holds the total number of copies
+ALTER TABLE books ADD COLUMN available INT;
+UPDATE books SET available = COALESCE(quantity, 0);
+UPDATE books SET quantity = COALESCE(quantity, 0) + (SELECT COUNT(*) FROM users_books WHERE users_books.id_book = books.id);
+ALTER TABLE books ALTER COLUMN available SET NOT NULL;
+ALTER TABLE books ADD CONSTRAINT books_available_range CHECK (available >= 0 AND available <= quantity);
@@ -0,0 +1,15 @@
+CREATE TABLE book_movements (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    id_book INT NOT NULL,
+    delta INT NOT NULL,
+    available_delta INT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    reference VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX book_movements_book ON book_movements (id_book, id);
+
+-- open every existing book's ledger with the stock it has today
+INSERT INTO book_movements (id_book, delta, available_delta, reason, actor, reference)
+SELECT id, quantity, available, 'correction', 'system', 'opening balance' FROM books;
@@ -0,0 +1,17 @@
+CREATE TABLE stocktakes (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    branch VARCHAR(100) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    opened_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP
+);
+CREATE UNIQUE INDEX stocktakes_open_branch ON stocktakes (branch) WHERE status = 'open';
+
+CREATE TABLE stocktake_counts (
+    id_stocktake INT NOT NULL,
+    id_book INT NOT NULL DEFAULT 0,
+    barcode VARCHAR(20) NOT NULL DEFAULT '',
+    counted INT NOT NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX stocktake_counts_stocktake ON stocktake_counts (id_stocktake);
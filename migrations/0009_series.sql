@@ -0,0 +1,10 @@
+CREATE TABLE series (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
+ALTER TABLE books ADD COLUMN series_id INT REFERENCES series (id);
+ALTER TABLE books ADD COLUMN volume INT NOT NULL DEFAULT 0;
+CREATE UNIQUE INDEX books_series_volume_unique ON books (series_id, volume) WHERE volume > 0 AND deleted_at IS NULL;
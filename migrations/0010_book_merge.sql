@@ -0,0 +1,2 @@
+-- set on duplicates folded into another book; they stay deleted for good
+ALTER TABLE books ADD COLUMN merged_into INT;
@@ -0,0 +1,12 @@
+CREATE TABLE book_reviews (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    id_book INT NOT NULL,
+    id_user INT NOT NULL,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL,
+    moderated_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX book_reviews_book_user ON book_reviews (id_book, id_user);
@@ -0,0 +1,3 @@
+-- room for bcrypt hashes ("$2a$10$" and 53 more characters); the plaintext passwords
+-- already stored are hashed by "go run ./6_cmd hash-passwords"
+ALTER TABLE users ALTER COLUMN password TYPE VARCHAR(255);
@@ -0,0 +1,9 @@
+CREATE TABLE sessions (
+    id VARCHAR(32) PRIMARY KEY,
+    id_user INT NOT NULL,
+    nonce VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+CREATE INDEX sessions_user ON sessions (id_user);
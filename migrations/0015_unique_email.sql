@@ -0,0 +1,23 @@
+-- Refuses to run, listing them, while live users share an address: merge or change
+-- those accounts first. `go run ./6_cmd email-duplicates` prints the same report.
+DO $$
+DECLARE
+    shared TEXT;
+BEGIN
+    SELECT string_agg(email || ' (users ' || ids || ')', ', ') INTO shared
+    FROM (
+        SELECT lower(trim(email)) AS email, string_agg(id::TEXT, ', ' ORDER BY id) AS ids
+        FROM users
+        WHERE deleted_at IS NULL
+        GROUP BY lower(trim(email))
+        HAVING COUNT(*) > 1
+    ) dups;
+
+    IF shared IS NOT NULL THEN
+        RAISE EXCEPTION 'email addresses shared by several users: %', shared;
+    END IF;
+END $$;
+
+ALTER TABLE users ALTER COLUMN email TYPE VARCHAR(254);
+UPDATE users SET email = lower(trim(email)) WHERE email <> lower(trim(email));
+CREATE UNIQUE INDEX users_email_unique ON users (lower(email)) WHERE deleted_at IS NULL;
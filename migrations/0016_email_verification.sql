@@ -0,0 +1,14 @@
+ALTER TABLE users ADD COLUMN email_verified_at TIMESTAMP;
+-- accounts from before verification keep borrowing
+UPDATE users SET email_verified_at = now();
+
+CREATE TABLE user_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    id_user INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+CREATE INDEX user_tokens_user ON user_tokens (id_user, purpose, created_at);
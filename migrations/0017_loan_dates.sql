@@ -0,0 +1,4 @@
+-- the loans already out count from the upgrade, so nobody is overdue straight away
+ALTER TABLE users_books ADD COLUMN borrowed_at TIMESTAMP NOT NULL DEFAULT now();
+ALTER TABLE users_books ADD COLUMN due_at TIMESTAMP NOT NULL DEFAULT now() + interval '14 days';
+CREATE INDEX users_books_user ON users_books (id_user, due_at);
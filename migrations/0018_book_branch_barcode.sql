@@ -0,0 +1,5 @@
+-- books shelved nowhere in particular are left out of every branch's stocktake
+ALTER TABLE books ADD COLUMN branch VARCHAR(100) NOT NULL DEFAULT '';
+ALTER TABLE books ADD COLUMN barcode VARCHAR(20) NOT NULL DEFAULT '';
+CREATE UNIQUE INDEX books_barcode_unique ON books (barcode) WHERE barcode <> '' AND deleted_at IS NULL;
+CREATE INDEX books_branch ON books (branch) WHERE deleted_at IS NULL;
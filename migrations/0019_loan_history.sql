@@ -0,0 +1,14 @@
+-- every loan ever made, returned or not, so a review can tell who has read a book;
+-- it starts from the loans still out and the ones the ledger signed with a user
+CREATE TABLE loans (
+    id SERIAL PRIMARY KEY,
+    id_user INTEGER NOT NULL,
+    id_book INTEGER NOT NULL,
+    borrowed_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX loans_book_user ON loans (id_book, id_user);
+INSERT INTO loans (id_user, id_book, borrowed_at)
+SELECT substring(actor from 6)::int, id_book, created_at FROM book_movements WHERE reason = 'loan' AND actor ~ '^user [0-9]+$';
+INSERT INTO loans (id_user, id_book, borrowed_at)
+SELECT id_user, id_book, borrowed_at FROM users_books ub
+WHERE NOT EXISTS (SELECT 1 FROM loans l WHERE l.id_user = ub.id_user AND l.id_book = ub.id_book);
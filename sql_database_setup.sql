@@ -8,31 +8,138 @@ CREATE DATABASE "crud-6-db" OWNER "crud-6";
 
 DROP TABLE users;
 CREATE TABLE users (
-    id INT,
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
     first_name VARCHAR(50),
     last_name VARCHAR(50),
     dob  TIMESTAMP,
     location VARCHAR(50),
     cellphone_number VARCHAR(50),
-    email VARCHAR(50),
-    password VARCHAR(50),
+    email VARCHAR(254),
+    password VARCHAR(255),
+    role VARCHAR(20) NOT NULL DEFAULT 'member',
+    email_verified_at TIMESTAMP,
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP,
-    updated_at TIMESTAMP
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP
 );
+CREATE UNIQUE INDEX users_email_unique ON users (lower(email)) WHERE deleted_at IS NULL;
 ALTER TABLE persons OWNER TO "crud-6";
 
+CREATE TABLE series (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    name VARCHAR(100) NOT NULL UNIQUE,
+    description TEXT NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL
+);
+
 CREATE TABLE books (
-    id INT,
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    isbn VARCHAR(13) NOT NULL DEFAULT '',
     tittle VARCHAR(50),
     author VARCHAR(50),
     pages INT,
     quantity INT,
+    available INT NOT NULL DEFAULT 0,
+    publisher VARCHAR(100) NOT NULL DEFAULT '',
+    publication_year INT NOT NULL DEFAULT 0,
+    edition VARCHAR(50) NOT NULL DEFAULT '',
+    language VARCHAR(3) NOT NULL DEFAULT '',
+    description TEXT NOT NULL DEFAULT '',
+    format VARCHAR(20) NOT NULL DEFAULT '',
+    series_id INT REFERENCES series (id),
+    volume INT NOT NULL DEFAULT 0,
+    branch VARCHAR(100) NOT NULL DEFAULT '',
+    barcode VARCHAR(20) NOT NULL DEFAULT '',
+    version INT NOT NULL DEFAULT 1,
     created_at TIMESTAMP,
-    updated_at TIMESTAMP
+    updated_at TIMESTAMP,
+    deleted_at TIMESTAMP,
+    merged_into INT,
+    CONSTRAINT books_available_range CHECK (available >= 0 AND available <= quantity)
+);
+CREATE UNIQUE INDEX books_isbn_unique ON books (isbn) WHERE isbn <> '' AND deleted_at IS NULL;
+CREATE UNIQUE INDEX books_series_volume_unique ON books (series_id, volume) WHERE volume > 0 AND deleted_at IS NULL;
+CREATE UNIQUE INDEX books_barcode_unique ON books (barcode) WHERE barcode <> '' AND deleted_at IS NULL;
+CREATE INDEX books_branch ON books (branch) WHERE deleted_at IS NULL;
+
+CREATE TABLE book_movements (
+    id BIGINT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    id_book INT NOT NULL,
+    delta INT NOT NULL,
+    available_delta INT NOT NULL,
+    reason VARCHAR(20) NOT NULL,
+    actor VARCHAR(100) NOT NULL DEFAULT '',
+    reference VARCHAR(200) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL DEFAULT now()
+);
+CREATE INDEX book_movements_book ON book_movements (id_book, id);
+
+CREATE TABLE stocktakes (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    branch VARCHAR(100) NOT NULL,
+    status VARCHAR(10) NOT NULL,
+    opened_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP
+);
+CREATE UNIQUE INDEX stocktakes_open_branch ON stocktakes (branch) WHERE status = 'open';
+
+CREATE TABLE stocktake_counts (
+    id_stocktake INT NOT NULL,
+    id_book INT NOT NULL DEFAULT 0,
+    barcode VARCHAR(20) NOT NULL DEFAULT '',
+    counted INT NOT NULL,
+    counted_at TIMESTAMP NOT NULL DEFAULT now()
 );
+CREATE INDEX stocktake_counts_stocktake ON stocktake_counts (id_stocktake);
+
+CREATE TABLE book_reviews (
+    id INT GENERATED BY DEFAULT AS IDENTITY PRIMARY KEY,
+    id_book INT NOT NULL,
+    id_user INT NOT NULL,
+    rating INT NOT NULL CHECK (rating BETWEEN 1 AND 5),
+    text TEXT NOT NULL DEFAULT '',
+    status VARCHAR(10) NOT NULL,
+    moderated_by VARCHAR(100) NOT NULL DEFAULT '',
+    created_at TIMESTAMP NOT NULL,
+    updated_at TIMESTAMP NOT NULL
+);
+CREATE UNIQUE INDEX book_reviews_book_user ON book_reviews (id_book, id_user);
+
+CREATE TABLE sessions (
+    id VARCHAR(32) PRIMARY KEY,
+    id_user INT NOT NULL,
+    nonce VARCHAR(32) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    revoked_at TIMESTAMP
+);
+CREATE INDEX sessions_user ON sessions (id_user);
+
+CREATE TABLE user_tokens (
+    token_hash VARCHAR(64) PRIMARY KEY,
+    id_user INT NOT NULL,
+    purpose VARCHAR(20) NOT NULL,
+    email VARCHAR(254) NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    expires_at TIMESTAMP NOT NULL,
+    used_at TIMESTAMP
+);
+CREATE INDEX user_tokens_user ON user_tokens (id_user, purpose, created_at);
 
 CREATE TABLE users_books (
     id_user INTEGER,
-    id_book INTEGER
+    id_book INTEGER,
+    borrowed_at TIMESTAMP NOT NULL DEFAULT now(),
+    due_at TIMESTAMP NOT NULL DEFAULT now() + interval '14 days'
+);
+CREATE INDEX users_books_user ON users_books (id_user, due_at);
+
+CREATE TABLE loans (
+    id SERIAL PRIMARY KEY,
+    id_user INTEGER NOT NULL,
+    id_book INTEGER NOT NULL,
+    borrowed_at TIMESTAMP NOT NULL DEFAULT now()
 );
+CREATE INDEX loans_book_user ON loans (id_book, id_user);
 